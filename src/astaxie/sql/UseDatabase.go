@@ -2,14 +2,38 @@ package main
 
 import (
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
 	//"time"
 
+	"astaxie/txt/config"
+
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// dbConfig 可以写在 database.yaml 中，DSN 可以用环境变量 DATABASE_URL 或命令行参数 -dsn 覆盖，密码不必写在代码里
+type dbConfig struct {
+	Driver string `json:"driver" default:"mysql" validate:"oneof=mysql" usage:"数据库驱动"`
+	DSN    string `json:"dsn" env:"DATABASE_URL" default:"root:123456@/test?charset=utf8" validate:"required" usage:"数据库连接字符串"`
+}
+
 func main() {
-	db, err := sql.Open("mysql", "root:123456@/test?charset=utf8")
+	var cfg dbConfig
+	err := config.Load(&cfg, config.Options{
+		Name:         "UseDatabase",
+		File:         "database.yaml",
+		FileOptional: true,
+		EnvPrefix:    "DB_",
+		Args:         os.Args[1:],
+	})
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	checkErr(err)
+
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
 	checkErr(err)
 
 	//插入数据
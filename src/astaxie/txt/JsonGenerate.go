@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"astaxie/txt/codec"
 )
 
 // 小写不会导出
@@ -46,4 +48,23 @@ func main() {
 	}
 	byteArr, _ := json.Marshal(str)
 	os.Stdout.Write(byteArr)
+	fmt.Println()
+
+	// 同样的 json 标签也用于其他格式：ID 不输出，ServerIP 为空时省略
+	for _, name := range []string{"servers.xml", "servers.yaml", "servers.toml", "servers.csv"} {
+		c, _ := codec.ForFile(name)
+		b, err := c.Marshal(s)
+		if err != nil {
+			fmt.Println(name, "err:", err)
+			continue
+		}
+		fmt.Printf("--- %s (%s)\n%s", name, c.MediaType(), b)
+
+		var back Serverslice1
+		if err := c.Unmarshal(b, &back); err != nil {
+			fmt.Println(name, "err:", err)
+		}
+	}
+	b, _ = codec.YAML.Marshal(str)
+	fmt.Printf("--- Server2 in YAML\n%s", b)
 }
@@ -3,6 +3,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+
+	"astaxie/txt/jsoninfer"
+	"astaxie/txt/jsonschema"
+	"astaxie/txt/jsonstream"
 )
 
 type Server struct {
@@ -22,6 +27,23 @@ func main() {
 	json.Unmarshal([]byte(str), &s)
 	fmt.Println(s.Servers[0])
 
+	// json.Unmarshal 会悄悄忽略未知的字段和类型不对的值，用 JSON Schema 先校验，HTTP 中的用法见 JsonValidate.go
+	validator := jsonschema.MustCompile(`{"type": "object", "properties": {"servers": {"type": "array", "items": {
+		"type": "object", "required": ["serverName", "serverIP"],
+		"properties": {"serverName": {"type": "string"}, "serverIP": {"type": "string", "format": "ipv4"}}}}}}`)
+	validator.RejectUnknown = true
+	fmt.Println(validator.ValidateJSON([]byte(str)))
+	fmt.Println(validator.ValidateJSON([]byte(`{"servers":[{"serverName":"Shanghai_VPN","serverIp":"127.0.0.1"}]}`)))
+
+	// 文档很大时不必一次解码整个 Serverslice，用 jsonstream 逐个读取数组中的元素
+	for server, err := range jsonstream.Each[Server](strings.NewReader(str), "servers") {
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(server)
+	}
+
 	b := []byte(`{"Name":"Wednesday","Age":6,"Parents":["Gomez","Morticia"]}`)
 	json.Unmarshal(b, &f)
 	fmt.Println(f)
@@ -41,21 +63,39 @@ func main() {
 
 	// map的遍历是无序的
 	for k, v := range m {
-		switch vv := v.(type) {
-		case string:
-			fmt.Println(k, "is string", vv)
-		case int:
-			fmt.Println(k, "is int", vv)
-		case float64:
-			fmt.Println(k, "is float64", vv)
-		case []interface{}:
-			fmt.Println(k, "is an array:")
-			for i, u := range vv {
-				fmt.Println(i, u)
-			}
-		default:
-			fmt.Println(k, "is of a type I don't know how to handle")
-		}
+		walk(k, v, 0)
 	}
 
+	// 不知道 JSON 的结构时，可以从样本推断出结构体的定义，见 JsonInfer.go
+	schema := jsoninfer.Infer(f)
+	fmt.Print(schema)
+	code, _ := schema.Go(jsoninfer.Options{Name: "Person"})
+	fmt.Print(string(code))
+}
+
+// walk 用类型断言递归地遍历解码到 interface{} 中的值，json.Unmarshal 得到的数字都是 float64
+func walk(k string, v interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch vv := v.(type) {
+	case string:
+		fmt.Println(indent+k, "is string", vv)
+	case float64:
+		fmt.Println(indent+k, "is float64", vv)
+	case bool:
+		fmt.Println(indent+k, "is bool", vv)
+	case nil:
+		fmt.Println(indent+k, "is null")
+	case []interface{}:
+		fmt.Println(indent+k, "is an array:")
+		for i, u := range vv {
+			walk(fmt.Sprint(i), u, depth+1)
+		}
+	case map[string]interface{}:
+		fmt.Println(indent+k, "is an object:")
+		for kk, u := range vv {
+			walk(kk, u, depth+1)
+		}
+	default:
+		fmt.Println(indent+k, "is of a type I don't know how to handle")
+	}
 }
@@ -0,0 +1,57 @@
+package main
+
+/* 从 JSON 样本推断结构，生成 Go 结构体定义
+
+   curl -s https://api.github.com/users/golang | go run JsonInfer.go -name User
+   go run JsonInfer.go -each -name Server servers.json      顶层数组的每个元素是一个样本
+   go run JsonInfer.go -schema a.json b.json                 合并多个样本，输出推断的结构
+
+   没有文件参数时从标准输入读取，输入可以是多个连续的 JSON 值（例如 NDJSON）。
+*/
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"astaxie/txt/jsoninfer"
+)
+
+func main() {
+	name := flag.String("name", "Root", "顶层类型的名字")
+	pkg := flag.String("pkg", "", "非空时输出 package 子句")
+	each := flag.Bool("each", false, "把顶层数组的每个元素作为一个样本")
+	pointers := flag.Bool("pointers", false, "见过 null 的字段用指针类型")
+	describe := flag.Bool("schema", false, "输出推断的结构而不是 Go 代码")
+	flag.Parse()
+	log.SetFlags(0)
+
+	var r io.Reader = os.Stdin
+	if flag.NArg() > 0 {
+		var readers []io.Reader
+		for _, name := range flag.Args() {
+			f, err := os.Open(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+			readers = append(readers, f)
+		}
+		r = io.MultiReader(readers...)
+	}
+	s, err := jsoninfer.Read(r, *each)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *describe {
+		fmt.Print(s)
+		return
+	}
+	code, err := s.Go(jsoninfer.Options{Package: *pkg, Name: *name, Pointers: *pointers})
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(code)
+}
@@ -0,0 +1,129 @@
+package main
+
+/* 用 JSONPath 查询和修改标准输入中的 JSON
+
+   echo '{"a":[{"b":1},{"b":2}]}' | go run JsonQuery.go '$.a[?(@.b > 1)]'
+   echo '{"a":1}' | go run JsonQuery.go -set '$.b.c=[1,2]' -delete '$.a'
+   echo '{"a":[{"b":1}]}' | go run JsonQuery.go -set '$.a[?(@.b==1)].c=2'
+   go run JsonQuery.go -raw -first '$..name' < data.json
+
+   有查询时每个结果输出一行，没有查询时输出修改后的整个文档。
+*/
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"astaxie/txt/jsonpath"
+)
+
+// list 是可以重复的参数
+type list []string
+
+func (l *list) String() string     { return strings.Join(*l, ", ") }
+func (l *list) Set(s string) error { *l = append(*l, s); return nil }
+
+func main() {
+	var sets, deletes list
+	flag.Var(&sets, "set", "path=json，把 path 设置为 json 值，可以重复")
+	flag.Var(&deletes, "delete", "删除 path 选中的值，可以重复")
+	first := flag.Bool("first", false, "只输出第一个结果")
+	raw := flag.Bool("raw", false, "字符串结果不加引号输出")
+	indent := flag.Bool("indent", false, "缩进输出")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [path...] < input.json\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+
+	doc, err := jsonpath.Decode(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, s := range sets {
+		path, text, ok := splitSet(s)
+		if !ok {
+			log.Fatalf("-set %q: want path=json", s)
+		}
+		v, err := jsonpath.Parse([]byte(text))
+		if err != nil {
+			log.Fatalf("-set %q: %v", s, err)
+		}
+		if err := doc.Set(path, v); err != nil {
+			log.Fatalf("-set %q: %v", s, err)
+		}
+	}
+	for _, path := range deletes {
+		if _, err := doc.Delete(path); err != nil {
+			log.Fatalf("-delete %q: %v", path, err)
+		}
+	}
+
+	if flag.NArg() == 0 {
+		output(doc, *raw, *indent)
+		return
+	}
+	found := false
+	for _, path := range flag.Args() {
+		vs, err := doc.Query(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, v := range vs {
+			found = true
+			output(v, *raw, *indent)
+			if *first {
+				return
+			}
+		}
+	}
+	if !found {
+		os.Exit(1)
+	}
+}
+
+// splitSet 在方括号、圆括号和引号之外的第一个 = 处把 path=json 分开，
+// 所以路径中可以有 ?(@.b==1) 和 ['a=b']
+func splitSet(s string) (path, value string, ok bool) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == '=' && depth == 0:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+func output(v *jsonpath.Value, raw, indent bool) {
+	if s, err := v.Str(); err == nil && raw {
+		fmt.Println(s)
+		return
+	}
+	var b []byte
+	if indent {
+		b, _ = json.MarshalIndent(v, "", "  ")
+	} else {
+		b, _ = json.Marshal(v)
+	}
+	fmt.Println(string(b))
+}
@@ -0,0 +1,69 @@
+package main
+
+/* 用 JSON Schema 校验 HTTP 请求体
+
+   go run JsonValidate.go
+   curl -d '{"servers":[{"serverName":"Shanghai_VPN","serverIP":"127.0.0.1"}]}' localhost:8080/servers
+   curl -d '{"servers":[{"serverName":"","serverIP":"localhost","port":1}]}' localhost:8080/servers
+   第二个请求返回 422，errors 中列出每个错误的位置：
+   /servers/0/port 是未知字段，/servers/0/serverIP 不是 IPv4 地址，/servers/0/serverName 太短
+*/
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"astaxie/txt/jsonschema"
+)
+
+type Server struct {
+	ServerName string `json:"serverName"`
+	ServerIP   string `json:"serverIP"`
+}
+
+type Serverslice struct {
+	Servers []Server `json:"servers"`
+}
+
+var serversSchema = jsonschema.MustCompile(`{
+	"type": "object",
+	"required": ["servers"],
+	"properties": {
+		"servers": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/server"}}
+	},
+	"$defs": {
+		"server": {
+			"type": "object",
+			"required": ["serverName", "serverIP"],
+			"properties": {
+				"serverName": {"type": "string", "minLength": 1, "maxLength": 64},
+				"serverIP": {"type": "string", "format": "ipv4"}
+			}
+		}
+	}
+}`)
+
+func main() {
+	// json.Unmarshal 会忽略未知字段，这里让它们成为错误
+	serversSchema.RejectUnknown = true
+
+	var mu sync.Mutex
+	var servers []Server
+	http.HandleFunc("/servers", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost {
+			var s Serverslice
+			if err := serversSchema.DecodeRequest(r, &s); err != nil {
+				jsonschema.WriteError(w, err)
+				return
+			}
+			servers = append(servers, s.Servers...)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Serverslice{servers})
+	})
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
@@ -6,8 +6,9 @@ import (
 	"regexp"
 )
 
+// 每一段限定在 0-255 之间，只检查位数的话 999.999.999.999 也会被当成 IP
 func IsIP(ip string) (m bool) {
-	m, _ = regexp.MatchString("^[0-9]{1,3}\\.[0-9]{1,3}\\.[0-9]{1,3}\\.[0-9]{1,3}$", ip)
+	m, _ = regexp.MatchString(`^((25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])$`, ip)
 	return
 }
 
@@ -2,11 +2,12 @@ package main
 
 import (
 	"fmt"
-	simplejson "github.com/bitly/go-simplejson"
+
+	"astaxie/txt/jsonpath"
 )
 
 func main() {
-	js, _ := simplejson.NewJson([]byte(`{
+	js, err := jsonpath.Parse([]byte(`{
 	"test": {
 		"array": [1, "2", 3],
 		"int": 10,
@@ -16,12 +17,31 @@ func main() {
 		"bool": true
 	}
 }`))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	arr, _ := js.Get("test").Get("array").Array()
 	i, _ := js.Get("test").Get("int").Int()
-	ms := js.Get("test").Get("string").MustString()
+	ms := js.Get("test").Get("string").StrOr("")
 
 	fmt.Println(arr)
 	fmt.Println(i)
 	fmt.Println(ms)
+
+	// 大整数不会因为转成 float64 丢失精度
+	big, _ := js.First("$.test.bignum").Int()
+	fmt.Println(big)
+	// 不存在的值返回默认值
+	fmt.Println(js.First("$.test.missing").IntOr(-1))
+
+	// 选出数组中所有的数字
+	nums, _ := js.Query("$.test.array[?(@ > 0)]")
+	fmt.Println(nums)
+
+	js.Set("$.test.array[3]", 4)
+	js.Set("$.test.nested.name", "new")
+	js.Delete("$.test.bool")
+	fmt.Println(js)
 }
@@ -3,19 +3,20 @@ package main
 import (
 	"fmt"
 	"os"
-	"text/template"
+
+	"astaxie/txt/view"
 )
 
 func main() {
-	s1, error := template.ParseFiles("header.tmpl", "content.tmpl", "footer.tmpl")
-	if error != nil {
-		fmt.Println(error)
+	// views/partials 中的 header 和 footer 被所有页面共享，content 继承 views/layouts/base
+	views, err := view.New(view.Options{Dir: "views"})
+	if err != nil {
+		fmt.Println(err) // 模板有错误时输出文件和行号，例如 views/content.tmpl:3: unexpected EOF
+		return
+	}
+	fmt.Println(views.Names())
+	err = views.Render(os.Stdout, "content", map[string]string{"Title": "演示嵌套"})
+	if err != nil {
+		fmt.Println(err)
 	}
-	s1.ExecuteTemplate(os.Stdout, "header", nil)
-	fmt.Println(1)
-	s1.ExecuteTemplate(os.Stdout, "content", nil)
-	fmt.Println(2)
-	s1.ExecuteTemplate(os.Stdout, "footer", nil)
-	fmt.Println(3)
-	s1.Execute(os.Stdout, nil)
 }
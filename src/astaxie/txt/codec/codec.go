@@ -0,0 +1,137 @@
+// Package codec 用同一组 json 标签在 JSON、XML、YAML、TOML 和 CSV 之间编码和解码。
+//
+// 结构体只需要写 json 标签：字段名、"-" 和 omitempty 在所有格式中的含义都和 encoding/json 相同。
+// 做法是先用 encoding/json 把值编码成保留字段顺序的树，再把树写成目标格式；
+// 解码时把读到的树按目标类型调整（例如 XML 和 CSV 中的数字都是字符串），再交给 encoding/json。
+// 所有格式中的 time.Duration 都可以写成 "1m30s" 这样的字符串，编码时仍然是纳秒数。
+//
+// YAML 和 TOML 只实现了配置文件常用的部分，见 YAML 和 TOML 的说明。
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Codec 是一种格式的编码和解码
+type Codec interface {
+	// Name 是格式的名字，例如 yaml
+	Name() string
+	// MediaType 是 HTTP 中使用的 MIME 类型
+	MediaType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// ErrUnknownFormat 表示没有和扩展名或 MIME 类型对应的格式
+var ErrUnknownFormat = errors.New("codec: unknown format")
+
+type format struct {
+	codec      Codec
+	exts       []string
+	mediaTypes []string
+}
+
+var formats = []format{
+	{JSON, []string{".json"}, []string{"application/json", "text/json"}},
+	{XML, []string{".xml"}, []string{"application/xml", "text/xml"}},
+	{YAML, []string{".yaml", ".yml"}, []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"}},
+	{TOML, []string{".toml"}, []string{"application/toml", "text/toml"}},
+	{CSV, []string{".csv"}, []string{"text/csv", "application/csv"}},
+}
+
+// ForFile 按文件的扩展名选择格式，例如 config.yaml 使用 YAML
+func ForFile(path string) (Codec, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, f := range formats {
+		for _, e := range f.exts {
+			if e == ext {
+				return f.codec, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: file extension %q", ErrUnknownFormat, ext)
+}
+
+// ForMediaType 按 MIME 类型选择格式，参数（例如 charset）被忽略，
+// application/problem+json 这样带 +json、+xml、+yaml 后缀的类型也可以识别
+func ForMediaType(contentType string) (Codec, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: media type %q", ErrUnknownFormat, contentType)
+	}
+	for _, f := range formats {
+		for _, t := range f.mediaTypes {
+			if t == mt {
+				return f.codec, nil
+			}
+		}
+	}
+	if _, suffix, ok := strings.Cut(mt, "+"); ok {
+		for _, f := range formats {
+			if f.codec.Name() == suffix {
+				return f.codec, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: media type %q", ErrUnknownFormat, contentType)
+}
+
+// ReadFile 按扩展名选择格式，把文件解码到 v 中
+func ReadFile(path string, v any) error {
+	c, err := ForFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteFile 按扩展名选择格式，把 v 编码后写入文件
+func WriteFile(path string, v any) error {
+	c, err := ForFile(path)
+	if err != nil {
+		return err
+	}
+	data, err := c.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// treeCodec 是先转换成树再编码的格式
+type treeCodec struct {
+	name, mediaType string
+	encode          func(tree any, v any) ([]byte, error)
+	decode          func(data []byte) (any, error)
+}
+
+func (c *treeCodec) Name() string      { return c.name }
+func (c *treeCodec) MediaType() string { return c.mediaType }
+
+func (c *treeCodec) Marshal(v any) ([]byte, error) {
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.encode(tree, v)
+}
+
+func (c *treeCodec) Unmarshal(data []byte, v any) error {
+	tree, err := c.decode(data)
+	if err != nil {
+		return err
+	}
+	return fromTree(tree, v)
+}
@@ -0,0 +1,435 @@
+package codec
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type server struct {
+	ID         int    `json:"-"`
+	ServerName string `json:"serverName"`
+	ServerIP   string `json:"serverIP,omitempty"`
+	Port       int    `json:"port"`
+	Enabled    bool   `json:"enabled"`
+}
+
+type serverslice struct {
+	Servers []server
+}
+
+type limits struct {
+	Rate  float64 `json:"rate"`
+	Burst uint16  `json:"burst,omitempty"`
+}
+
+type config struct {
+	Name     string            `json:"name"`
+	Version  string            `json:"version"`
+	Debug    bool              `json:"debug"`
+	Created  time.Time         `json:"created"`
+	Tags     []string          `json:"tags"`
+	Limits   limits            `json:"limits"`
+	Backup   *limits           `json:"backup,omitempty"`
+	Servers  []server          `json:"servers"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Matrix   [][]int           `json:"matrix"`
+	Note     string            `json:"note,omitempty"`
+	Password string            `json:"-"`
+	Embedded
+}
+
+type Embedded struct {
+	Owner string `json:"owner"`
+}
+
+func sampleConfig() config {
+	return config{
+		Name:     "demo: \"quoted\" # not a comment",
+		Version:  "1.0",
+		Debug:    true,
+		Created:  time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Tags:     []string{"a", "true", "", " lead", "trailing "},
+		Limits:   limits{Rate: 0.5, Burst: 10},
+		Servers:  []server{{ServerName: "Shanghai_VPN", ServerIP: "127.0.0.1", Port: 80, Enabled: true}, {ServerName: "Beijing_VPN", Port: 8080}},
+		Labels:   map[string]string{"env": "prod"},
+		Matrix:   [][]int{{1, 2}, {3}},
+		Password: "secret",
+		Embedded: Embedded{Owner: "ops\nteam\n"},
+	}
+}
+
+func Test_RoundTrip(t *testing.T) {
+	want := sampleConfig()
+	want.Password = ""
+	for _, c := range []Codec{JSON, XML, YAML, TOML} {
+		t.Run(c.Name(), func(t *testing.T) {
+			data, err := c.Marshal(sampleConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), "secret") || strings.Contains(string(data), "note") {
+				t.Errorf("json:\"-\" or omitempty field written:\n%s", data)
+			}
+			var got config
+			if err := c.Unmarshal(data, &got); err != nil {
+				t.Fatalf("%v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v\n%s", got, want, data)
+			}
+		})
+	}
+}
+
+func Test_CSV(t *testing.T) {
+	in := serverslice{[]server{
+		{ID: 1, ServerName: "Shanghai_VPN", ServerIP: "127.0.0.1", Port: 80, Enabled: true},
+		{ID: 2, ServerName: "Beijing, \"VPN\"", Port: 8080},
+	}}
+	data, err := CSV.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "serverName,serverIP,port,enabled\nShanghai_VPN,127.0.0.1,80,true\n\"Beijing, \"\"VPN\"\"\",,8080,false\n"
+	if string(data) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", data, want)
+	}
+	var got serverslice
+	if err := CSV.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	in.Servers[0].ID, in.Servers[1].ID = 0, 0
+	if !reflect.DeepEqual(got, in) {
+		t.Errorf("got %+v", got)
+	}
+
+	// 嵌套的对象展开成 a.b 列，数组写成 JSON
+	rows := []config{{Name: "x", Limits: limits{Rate: 1}, Tags: []string{"a", "b"}}}
+	data, err = CSV.Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "name,version,debug,created,tags,limits.rate,servers,matrix,owner\n") ||
+		!strings.Contains(string(data), `"[""a"",""b""]",1`) {
+		t.Errorf("got:\n%s", data)
+	}
+	var back []config
+	if err := CSV.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 1 || back[0].Limits.Rate != 1 || !reflect.DeepEqual(back[0].Tags, []string{"a", "b"}) {
+		t.Errorf("got %+v", back)
+	}
+	if _, err := CSV.Marshal(server{}); err == nil {
+		t.Error("CSV of a single object succeeded")
+	}
+}
+
+func Test_YAMLDecode(t *testing.T) {
+	src := `---
+# 注释
+name: demo   # 行尾注释
+version: "1.0"
+debug: yes
+created: 2024-05-01T12:30:00Z
+tags: [a, 'it''s', "x#y"]
+limits: {rate: .5, burst: 0x10}
+servers:
+- serverName: Shanghai_VPN
+  serverIP: 127.0.0.1
+  port: 80
+-   serverName: Beijing_VPN
+    port: 8080
+    enabled: true
+labels:
+  env: prod
+  "key with: colon": v
+matrix:
+  - - 1
+    - 2
+  - []
+note: |
+  line 1
+    indented
+  line 3
+owner: >-
+  folded
+  text
+
+  para
+`
+	want := config{
+		Name: "demo", Version: "1.0", Debug: false,
+		Created:  time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Tags:     []string{"a", "it's", "x#y"},
+		Limits:   limits{Rate: 0.5, Burst: 16},
+		Servers:  []server{{ServerName: "Shanghai_VPN", ServerIP: "127.0.0.1", Port: 80}, {ServerName: "Beijing_VPN", Port: 8080, Enabled: true}},
+		Labels:   map[string]string{"env": "prod", "key with: colon": "v"},
+		Matrix:   [][]int{{1, 2}, {}},
+		Note:     "line 1\n  indented\nline 3\n",
+		Embedded: Embedded{Owner: "folded text\npara"},
+	}
+	// yes 在 YAML 1.2 中是字符串，解码到 bool 时出错
+	var got config
+	if err := YAML.Unmarshal([]byte(src), &got); err == nil {
+		t.Fatalf("debug: yes decoded without error")
+	}
+	if err := YAML.Unmarshal([]byte(strings.Replace(src, "debug: yes", "debug: false", 1)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	// 块标量中缩进的 ---、... 和缩进后面的制表符都是内容
+	blocks := map[string]string{
+		"s: |\n  ---\n  x\n": "---\nx\n",
+		"s: |\n  ...\n  x\n": "...\nx\n",
+		"s: |\n  \tx\n  y\n": "\tx\ny\n",
+		"s: |\n  x\n...\n":   "x\n",
+	}
+	for src, want := range blocks {
+		var v map[string]string
+		if err := YAML.Unmarshal([]byte(src), &v); err != nil || v["s"] != want {
+			t.Errorf("Unmarshal(%q) = %q, %v, want %q", src, v["s"], err, want)
+		}
+	}
+
+	for _, bad := range []string{"a: 1\n\tb: 2", "a:\n  b: 1\n  \tc: 2", "- 1\n \t- 2", "a: 1\na: 2", "a: [1, 2", "a: &x 1", "a: 1\n---\nb: 2", "a:\n  - 1\n b: 2"} {
+		var v any
+		var ye *YAMLError
+		if err := YAML.Unmarshal([]byte(bad), &v); !errors.As(err, &ye) {
+			t.Errorf("Unmarshal(%q) = %v, want YAMLError", bad, err)
+		}
+	}
+}
+
+func Test_TOMLDecode(t *testing.T) {
+	src := `# 注释
+name = "demo"
+version = '1.0'
+debug = true
+created = 2024-05-01 12:30:00Z
+tags = [
+  "a",   # 数组可以跨行
+  """multi
+line""",
+]
+owner.name = "dotted"
+matrix = [[1, 2], [0x3]]
+
+[limits]
+rate = 5e-1
+burst = 1_000
+
+[[servers]]
+serverName = "Shanghai_VPN"
+port = 80
+
+[[servers]]
+serverName = 'C:\VPN'
+labels = { env = "prod", "x.y" = 1 }
+`
+	var got map[string]any
+	if err := TOML.Unmarshal([]byte(src), &got); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := JSON.Marshal(got)
+	want := `{
+  "created": "2024-05-01T12:30:00Z",
+  "debug": true,
+  "limits": {
+    "burst": 1000,
+    "rate": 0.5
+  },
+  "matrix": [
+    [
+      1,
+      2
+    ],
+    [
+      3
+    ]
+  ],
+  "name": "demo",
+  "owner": {
+    "name": "dotted"
+  },
+  "servers": [
+    {
+      "port": 80,
+      "serverName": "Shanghai_VPN"
+    },
+    {
+      "labels": {
+        "env": "prod",
+        "x.y": 1
+      },
+      "serverName": "C:\\VPN"
+    }
+  ],
+  "tags": [
+    "a",
+    "multi\nline"
+  ],
+  "version": "1.0"
+}
+`
+	if string(data) != want {
+		t.Errorf("got:\n%s", data)
+	}
+
+	for _, bad := range []string{"a = 1\na = 2", "[a]\n[a]", "a = ", "a = \"x", "a = inf", "a = 1 b = 2", "a = 1\n[a.b]",
+		"a = []\n[a.b]", "a = []\na.b = 1", "a = []\n[[a]]"} {
+		var v any
+		var te *TOMLError
+		if err := TOML.Unmarshal([]byte(bad), &v); !errors.As(err, &te) {
+			t.Errorf("Unmarshal(%q) = %v, want TOMLError", bad, err)
+		}
+	}
+	if _, err := TOML.Marshal([]int{1}); err == nil {
+		t.Error("TOML of an array succeeded")
+	}
+}
+
+func Test_XMLDecode(t *testing.T) {
+	src := `<?xml version="1.0"?>
+<serverslice>
+  <Servers enabled="true"><serverName>Shanghai_VPN</serverName><port> 80 </port></Servers>
+</serverslice>`
+	var got serverslice
+	if err := XML.Unmarshal([]byte(src), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := serverslice{[]server{{ServerName: "Shanghai_VPN", Port: 80, Enabled: true}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v", got)
+	}
+
+	// 只有解码到切片时才展开 item 元素
+	type wrapper struct {
+		Item string `json:"item"`
+	}
+	values := []any{wrapper{"x"}, []string{"a"}, [][]int{{1}}, [][]int{{1, 2}, {3}}}
+	for _, v := range values {
+		data, err := XML.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back := reflect.New(reflect.TypeOf(v))
+		if err := XML.Unmarshal(data, back.Interface()); err != nil || !reflect.DeepEqual(back.Elem().Interface(), v) {
+			t.Errorf("round trip of %v: %s -> %v, %v", v, data, back.Elem(), err)
+		}
+	}
+}
+
+func Test_Durations(t *testing.T) {
+	type timeouts struct {
+		Name  string          `json:"name"`
+		Read  time.Duration   `json:"read"`
+		Retry []time.Duration `json:"retry"`
+		Idle  *time.Duration  `json:"idle"`
+	}
+	idle := 5 * time.Minute
+	want := timeouts{Name: "10s", Read: 90 * time.Second, Retry: []time.Duration{time.Second, 2}, Idle: &idle}
+	cases := map[Codec]string{
+		JSON: `{"name": "10s", "read": "1m30s", "retry": ["1s", 2], "idle": "5m"}`,
+		YAML: "name: 10s\nread: 1m30s\nretry: [1s, 2]\nidle: 5m\n",
+		TOML: "name = \"10s\"\nread = \"1m30s\"\nretry = [\"1s\", 2]\nidle = \"5m\"\n",
+		XML:  `<timeouts><name>10s</name><read>1m30s</read><retry>1s</retry><retry>2</retry><idle>5m</idle></timeouts>`,
+	}
+	for c, src := range cases {
+		var got timeouts
+		if err := c.Unmarshal([]byte(src), &got); err != nil {
+			t.Errorf("%s: %v", c.Name(), err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v", c.Name(), got)
+		}
+	}
+
+	// JSON 中其他的值仍然和 encoding/json 一样严格
+	var v struct {
+		Read time.Duration `json:"read"`
+		Port int           `json:"port"`
+	}
+	if err := JSON.Unmarshal([]byte(`{"read": "1s", "port": "80"}`), &v); err == nil {
+		t.Error("JSON accepted a string for an int")
+	}
+	if err := JSON.Unmarshal([]byte(`{"read": "soon"}`), &v); err == nil {
+		t.Error("JSON accepted a bad duration")
+	}
+}
+
+func Test_XMLNames(t *testing.T) {
+	bad := []any{
+		struct {
+			X int `json:"weird key"`
+		}{},
+		map[string]int{"007": 1},
+		map[string]int{"a b": 1},
+		map[string]int{"ns:a": 1},
+	}
+	for _, v := range bad {
+		if data, err := XML.Marshal(v); err == nil || !strings.Contains(err.Error(), "not a valid element name") {
+			t.Errorf("Marshal(%v) = %s, %v", v, data, err)
+		}
+	}
+	if _, err := XML.Marshal(map[string]int{"_a-1.b": 1, "名字": 2}); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Lookup(t *testing.T) {
+	cases := map[string]Codec{
+		"config.YAML":                     YAML,
+		"a/b.yml":                         YAML,
+		"x.toml":                          TOML,
+		"servers.csv":                     CSV,
+		"application/json; charset=utf-8": JSON,
+		"application/problem+json":        JSON,
+		"application/atom+xml":            XML,
+		"text/yaml":                       YAML,
+		"application/toml":                TOML,
+		"text/csv; header=present":        CSV,
+	}
+	for in, want := range cases {
+		var c Codec
+		var err error
+		if strings.Contains(in, "/") && !strings.Contains(in, ".") || strings.Contains(in, "+") || strings.Contains(in, ";") {
+			c, err = ForMediaType(in)
+		} else {
+			c, err = ForFile(in)
+		}
+		if err != nil || c != want {
+			t.Errorf("%s: %v, %v", in, c, err)
+		}
+	}
+	if _, err := ForFile("a.ini"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ForFile(a.ini) = %v", err)
+	}
+	if _, err := ForMediaType("image/png"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ForMediaType(image/png) = %v", err)
+	}
+}
+
+func Test_Files(t *testing.T) {
+	dir := t.TempDir()
+	in := sampleConfig()
+	in.Password = ""
+	for _, name := range []string{"c.json", "c.xml", "c.yaml", "c.toml"} {
+		path := dir + "/" + name
+		if err := WriteFile(path, in); err != nil {
+			t.Fatal(err)
+		}
+		var got config
+		if err := ReadFile(path, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, in) {
+			t.Errorf("%s: got %+v", name, got)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+)
+
+// CSV 把对象的数组写成表格，第一行是字段名。嵌套对象的字段展开成 a.b 这样的列，数组写成 JSON，null 是空单元格。
+// 要编码的值可以是切片，也可以是只有一个切片字段的结构体（例如 Serverslice），解码时同样处理。
+// 解码时空单元格被省略，字段保持零值。
+var CSV Codec = &treeCodec{name: "csv", mediaType: "text/csv", encode: encodeCSV, decode: decodeCSV}
+
+func encodeCSV(tree any, _ any) ([]byte, error) {
+	if o, ok := tree.(*object); ok && len(o.keys) == 1 {
+		tree = o.vals[o.keys[0]]
+	}
+	rows, ok := tree.([]any)
+	if !ok {
+		return nil, treeError("csv", "value must be an array of objects")
+	}
+	var header []string
+	seen := map[string]bool{}
+	flat := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		o, ok := row.(*object)
+		if !ok {
+			return nil, treeError("csv", "row %d is not an object", i)
+		}
+		flat[i] = map[string]string{}
+		flatten(o, "", func(k, v string) {
+			if !seen[k] {
+				seen[k] = true
+				header = append(header, k)
+			}
+			flat[i][k] = v
+		})
+	}
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write(header)
+	for _, row := range flat {
+		rec := make([]string, len(header))
+		for j, k := range header {
+			rec[j] = row[k]
+		}
+		w.Write(rec)
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+// flatten 按顺序列出对象中的每个单元格，嵌套对象的键用 . 连接
+func flatten(o *object, prefix string, emit func(k, v string)) {
+	for _, k := range o.keys {
+		switch v := o.vals[k].(type) {
+		case *object:
+			if len(v.keys) > 0 {
+				flatten(v, prefix+k+".", emit)
+				continue
+			}
+			emit(prefix+k, "")
+		case []any:
+			b, _ := json.Marshal(v)
+			emit(prefix+k, string(b))
+		default:
+			emit(prefix+k, scalarText(v))
+		}
+	}
+}
+
+func decodeCSV(data []byte) (any, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := []any{}
+	if len(records) == 0 {
+		return rows, nil
+	}
+	header := records[0]
+	for _, rec := range records[1:] {
+		o := newObject()
+		for j, cell := range rec {
+			if cell == "" {
+				continue
+			}
+			// 按 a.b 还原嵌套的对象
+			t := o
+			path := strings.Split(header[j], ".")
+			for _, k := range path[:len(path)-1] {
+				sub, ok := t.vals[k].(*object)
+				if !ok {
+					sub = newObject()
+					t.set(k, sub)
+				}
+				t = sub
+			}
+			t.set(path[len(path)-1], cell)
+		}
+		rows = append(rows, o)
+	}
+	return rows, nil
+}
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+type jsonCodec struct{}
+
+// JSON 就是 encoding/json，输出缩进两个空格。解码时 time.Duration 还可以写成 "1m30s" 这样的字符串。
+var JSON Codec = jsonCodec{}
+
+func (jsonCodec) Name() string      { return "json" }
+func (jsonCodec) MediaType() string { return "application/json" }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || !hasDuration(rv.Type().Elem()) || !json.Valid(data) {
+		return json.Unmarshal(data, v)
+	}
+	tree, err := parseJSON(string(data))
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(durations(tree, rv.Type().Elem()))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
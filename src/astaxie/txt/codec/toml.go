@@ -0,0 +1,541 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TOML 支持 TOML 1.0 中除了 inf 和 nan 以外的所有值，日期和时间读成字符串（time.Time 可以直接解码带时区的）。
+// TOML 中没有 null，编码时值为 null 的字段被省略，数组中的 null 是错误。顶层必须是对象。
+var TOML Codec = &treeCodec{name: "toml", mediaType: "application/toml", encode: encodeTOML, decode: decodeTOML}
+
+func encodeTOML(tree any, _ any) ([]byte, error) {
+	o, ok := tree.(*object)
+	if !ok {
+		return nil, treeError("toml", "top-level value must be an object, not %T", tree)
+	}
+	var b bytes.Buffer
+	if err := tomlTable(&b, o, nil); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// isTableArray 表示 x 是要写成 [[name]] 的对象数组
+func isTableArray(x any) bool {
+	a, ok := x.([]any)
+	if !ok || len(a) == 0 {
+		return false
+	}
+	for _, e := range a {
+		if _, ok := e.(*object); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlTable 先写 key = value，再写子表和表数组，TOML 要求同一个表中的键写在子表之前
+func tomlTable(b *bytes.Buffer, o *object, path []string) error {
+	for _, k := range o.keys {
+		v := o.vals[k]
+		if _, isTable := v.(*object); isTable || v == nil || isTableArray(v) {
+			continue
+		}
+		s, err := tomlValue(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = %s\n", tomlKey(k), s)
+	}
+	for _, k := range o.keys {
+		p := append(path[:len(path):len(path)], k)
+		switch v := o.vals[k].(type) {
+		case *object:
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(b, "[%s]\n", tomlPath(p))
+			if err := tomlTable(b, v, p); err != nil {
+				return err
+			}
+		case []any:
+			if !isTableArray(v) {
+				continue
+			}
+			for _, e := range v {
+				if b.Len() > 0 {
+					b.WriteByte('\n')
+				}
+				fmt.Fprintf(b, "[[%s]]\n", tomlPath(p))
+				if err := tomlTable(b, e.(*object), p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(k string) string {
+	if bareKey.MatchString(k) {
+		return k
+	}
+	return quoteString(k)
+}
+
+func tomlPath(p []string) string {
+	keys := make([]string, len(p))
+	for i, k := range p {
+		keys[i] = tomlKey(k)
+	}
+	return strings.Join(keys, ".")
+}
+
+// tomlValue 写一行中的值，对象写成内联表
+func tomlValue(x any) (string, error) {
+	switch v := x.(type) {
+	case nil:
+		return "", treeError("toml", "null cannot be represented in an array")
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return string(v), nil
+	case string:
+		return quoteString(v), nil
+	case []any:
+		items := make([]string, len(v))
+		for i, e := range v {
+			s, err := tomlValue(e)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case *object:
+		var items []string
+		for _, k := range v.keys {
+			if v.vals[k] == nil {
+				continue
+			}
+			s, err := tomlValue(v.vals[k])
+			if err != nil {
+				return "", err
+			}
+			items = append(items, tomlKey(k)+" = "+s)
+		}
+		if len(items) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	}
+	return "", treeError("toml", "unsupported value %T", x)
+}
+
+// TOMLError 是 TOML 的语法错误
+type TOMLError struct {
+	Line int
+	Msg  string
+}
+
+func (e *TOMLError) Error() string {
+	return fmt.Sprintf("codec: toml: line %d: %s", e.Line, e.Msg)
+}
+
+type tomlParser struct {
+	s    string
+	pos  int
+	root *object
+	// defined 记录用 [a.b] 定义过的表，同一个表不能定义两次
+	defined map[*object]bool
+}
+
+func decodeTOML(data []byte) (any, error) {
+	p := &tomlParser{s: strings.ReplaceAll(string(data), "\r\n", "\n"), root: newObject(), defined: map[*object]bool{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.root, nil
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return &TOMLError{strings.Count(p.s[:min(p.pos, len(p.s))], "\n") + 1, fmt.Sprintf(format, args...)}
+}
+
+func (p *tomlParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *tomlParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank 跳过空白、换行和注释
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case '\n':
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parse() error {
+	cur := p.root
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.s) {
+			return nil
+		}
+		var err error
+		if p.peek() == '[' {
+			cur, err = p.header()
+		} else {
+			err = p.keyValue(cur)
+		}
+		if err != nil {
+			return err
+		}
+		p.skipSpace()
+		if p.peek() == '#' {
+			p.skipComment()
+		}
+		if p.pos < len(p.s) && p.s[p.pos] != '\n' {
+			return p.errorf("expected newline, found %q", p.s[p.pos])
+		}
+	}
+}
+
+// header 解析 [a.b] 或 [[a.b]]，返回后面的键值对所属的表
+func (p *tomlParser) header() (*object, error) {
+	p.pos++
+	array := p.peek() == '['
+	if array {
+		p.pos++
+	}
+	keys, err := p.keys()
+	if err != nil {
+		return nil, err
+	}
+	end := "]"
+	if array {
+		end = "]]"
+	}
+	if !strings.HasPrefix(p.s[p.pos:], end) {
+		return nil, p.errorf("expected %q", end)
+	}
+	p.pos += len(end)
+
+	parent, err := p.walk(p.root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	old, exists := parent.get(last)
+	if array {
+		a, ok := old.([]any)
+		if exists && (!ok || !isTableArray(a)) {
+			return nil, p.errorf("%s is not an array of tables", tomlPath(keys))
+		}
+		t := newObject()
+		parent.set(last, append(a, t))
+		return t, nil
+	}
+	t, ok := old.(*object)
+	if exists && !ok || p.defined[t] {
+		return nil, p.errorf("table %s is defined twice", tomlPath(keys))
+	}
+	if !exists {
+		t = newObject()
+		parent.set(last, t)
+	}
+	p.defined[t] = true
+	return t, nil
+}
+
+// walk 沿着 keys 找到或创建子表，遇到表数组时进入最后一个元素
+func (p *tomlParser) walk(t *object, keys []string) (*object, error) {
+	for _, k := range keys {
+		switch v := t.vals[k].(type) {
+		case nil:
+			nt := newObject()
+			t.set(k, nt)
+			t = nt
+		case *object:
+			t = v
+		case []any:
+			// 空数组和普通的数组一样不是表数组
+			if !isTableArray(v) {
+				return nil, p.errorf("key %s is not a table", k)
+			}
+			t = v[len(v)-1].(*object)
+		default:
+			return nil, p.errorf("key %s is not a table", k)
+		}
+	}
+	return t, nil
+}
+
+func (p *tomlParser) keyValue(t *object) error {
+	keys, err := p.keys()
+	if err != nil {
+		return err
+	}
+	if p.skipSpace(); p.peek() != '=' {
+		return p.errorf("expected '=' after key")
+	}
+	p.pos++
+	p.skipSpace()
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+	t, err = p.walk(t, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := t.get(last); exists {
+		return p.errorf("duplicate key %s", tomlPath(keys))
+	}
+	t.set(last, v)
+	return nil
+}
+
+// keys 解析 a.b."c d" 这样用点分隔的键
+func (p *tomlParser) keys() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		var k string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		default:
+			start := p.pos
+			for p.pos < len(p.s) && bareKey.MatchString(p.s[p.pos:p.pos+1]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("expected a key")
+			}
+			k = p.s[start:p.pos]
+		}
+		keys = append(keys, k)
+		if p.skipSpace(); p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) value() (any, error) {
+	switch c := p.peek(); c {
+	case '"', '\'':
+		return p.str()
+	case '[':
+		p.pos++
+		a := []any{}
+		for {
+			p.skipBlank()
+			if p.peek() == ']' {
+				p.pos++
+				return a, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+			p.skipBlank()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case ']':
+			default:
+				return nil, p.errorf("expected ',' or ']' in array")
+			}
+		}
+	case '{':
+		p.pos++
+		t := newObject()
+		for first := true; ; first = false {
+			p.skipSpace()
+			if p.peek() == '}' && first {
+				p.pos++
+				return t, nil
+			}
+			if err := p.keyValue(t); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case '}':
+				p.pos++
+				return t, nil
+			default:
+				return nil, p.errorf("expected ',' or '}' in inline table")
+			}
+		}
+	}
+	return p.atom()
+}
+
+var (
+	tomlDateTime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[-+]\d{2}:\d{2})?)?$|^\d{2}:\d{2}:\d{2}(\.\d+)?$`)
+	tomlInt      = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)$`)
+	tomlFloat    = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][-+]?[0-9](_?[0-9])*)?$`)
+)
+
+// atom 解析布尔值、数字和日期时间
+func (p *tomlParser) atom() (any, error) {
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(" \t\n,]}#", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	// 日期和时间之间可以是空格
+	if p.pos-start == 10 && p.pos+1 < len(p.s) && p.s[p.pos] == ' ' && p.s[p.pos+1] >= '0' && p.s[p.pos+1] <= '9' {
+		p.pos++
+		for p.pos < len(p.s) && !strings.ContainsRune(" \t\n,]}#", rune(p.s[p.pos])) {
+			p.pos++
+		}
+	}
+	tok := p.s[start:p.pos]
+	switch {
+	case tok == "true":
+		return true, nil
+	case tok == "false":
+		return false, nil
+	case tok == "":
+		return nil, p.errorf("expected a value")
+	case tomlDateTime.MatchString(tok):
+		if tok[0] >= '0' && len(tok) > 10 {
+			tok = tok[:10] + "T" + tok[11:]
+		}
+		return tok, nil
+	case len(tok) > 2 && tok[0] == '0' && strings.ContainsRune("xob", rune(tok[1])):
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[tok[1]]
+		u, err := strconv.ParseUint(strings.ReplaceAll(tok[2:], "_", ""), base, 64)
+		if err != nil || strings.HasPrefix(tok[2:], "_") {
+			return nil, p.errorf("bad integer %q", tok)
+		}
+		return json.Number(strconv.FormatUint(u, 10)), nil
+	case tomlInt.MatchString(tok):
+		i, err := strconv.ParseInt(strings.ReplaceAll(tok, "_", ""), 10, 64)
+		if err != nil {
+			return nil, p.errorf("integer %s out of range", tok)
+		}
+		return json.Number(strconv.FormatInt(i, 10)), nil
+	case tomlFloat.MatchString(tok):
+		f, err := strconv.ParseFloat(strings.ReplaceAll(tok, "_", ""), 64)
+		if err != nil {
+			return nil, p.errorf("bad float %q", tok)
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	case strings.TrimLeft(tok, "+-") == "inf" || strings.TrimLeft(tok, "+-") == "nan":
+		return nil, p.errorf("%s cannot be represented", tok)
+	}
+	return nil, p.errorf("unexpected %q", tok)
+}
+
+// str 解析基本字符串、字面字符串和它们的多行形式
+func (p *tomlParser) str() (string, error) {
+	q := p.s[p.pos]
+	multi := strings.HasPrefix(p.s[p.pos:], strings.Repeat(string(q), 3))
+	if multi {
+		p.pos += 3
+		// 紧跟在开头的引号后面的换行被忽略
+		if p.peek() == '\n' {
+			p.pos++
+		}
+	} else {
+		p.pos++
+	}
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case multi && strings.HasPrefix(p.s[p.pos:], strings.Repeat(string(q), 3)):
+			p.pos += 3
+			// """a"""" 中结尾最多可以再有两个引号
+			for i := 0; i < 2 && p.peek() == q; i++ {
+				b.WriteByte(q)
+				p.pos++
+			}
+			return b.String(), nil
+		case !multi && c == q:
+			p.pos++
+			return b.String(), nil
+		case !multi && c == '\n':
+			return "", p.errorf("newline in string")
+		case c == '\\' && q == '"':
+			p.pos++
+			if multi && strings.TrimLeft(strings.SplitN(p.s[p.pos:], "\n", 2)[0], " \t") == "" {
+				// 行尾的 \ 去掉换行和下一行开头的空白
+				for p.pos < len(p.s) && strings.ContainsRune(" \t\n", rune(p.s[p.pos])) {
+					p.pos++
+				}
+				continue
+			}
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+			continue
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *tomlParser) escape(b *strings.Builder) error {
+	c := p.peek()
+	p.pos++
+	simple := map[byte]string{'b': "\b", 't': "\t", 'n': "\n", 'f': "\f", 'r': "\r", 'e': "\x1b", '"': `"`, '\\': `\`}
+	if s, ok := simple[c]; ok {
+		b.WriteString(s)
+		return nil
+	}
+	n := map[byte]int{'u': 4, 'U': 8}[c]
+	if n == 0 || p.pos+n > len(p.s) {
+		return p.errorf("bad escape \\%c", c)
+	}
+	r, err := strconv.ParseUint(p.s[p.pos:p.pos+n], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(r)) {
+		return p.errorf("bad escape \\%c%s", c, p.s[p.pos:p.pos+n])
+	}
+	p.pos += n
+	b.WriteRune(rune(r))
+	return nil
+}
@@ -0,0 +1,417 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 树中的值是 nil、bool、json.Number、string、[]any 和 *object
+
+// object 是保留键的顺序的 JSON 对象
+type object struct {
+	keys []string
+	vals map[string]any
+}
+
+func newObject() *object {
+	return &object{vals: map[string]any{}}
+}
+
+func (o *object) get(k string) (any, bool) {
+	v, ok := o.vals[k]
+	return v, ok
+}
+
+func (o *object) set(k string, v any) {
+	if _, ok := o.vals[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.vals[k] = v
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		b.Write(kb)
+		b.WriteByte(':')
+		vb, err := json.Marshal(o.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(vb)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// toTree 用 encoding/json 编码 v，再解码成保留键的顺序的树
+func toTree(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return readTree(dec)
+}
+
+func readTree(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := newObject()
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.set(k.(string), v)
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		a := []any{}
+		for dec.More() {
+			v, err := readTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err := dec.Token()
+		return a, err
+	}
+	return tok, nil
+}
+
+// fromTree 按 v 的类型调整树，再用 encoding/json 解码到 v 中
+func fromTree(tree any, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("codec: Unmarshal needs a non-nil pointer")
+	}
+	data, err := json.Marshal(coerce(tree, rv.Type().Elem()))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// coerce 把只有字符串的格式（XML、CSV）和类型不精确的格式中读到的值调整成 t 需要的 JSON 类型：
+// 字符串形式的数字、布尔值和 time.Duration 转换过来，标量转换成字符串，单个值放进数组。
+// 无法转换的值原样返回，由 encoding/json 报告错误。
+func coerce(x any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if x == nil || reflect.PointerTo(t).Implements(jsonUnmarshaler) {
+		return x
+	}
+	s, isString := x.(string)
+	if reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return scalarString(x)
+	}
+	if t == durationType && isString {
+		if n, ok := parseDuration(s); ok {
+			return n
+		}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return scalarString(x)
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(strings.TrimSpace(s)); isString && err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if isString {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				return nil
+			}
+			if n := json.Number(s); json.Valid([]byte(s)) && isNumber(s) {
+				return n
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return x // []byte 是 base64 字符串
+		}
+		if isString {
+			if s = strings.TrimSpace(s); s == "" {
+				return []any{}
+			}
+			// CSV 中的数组写成 JSON
+			if s[0] == '[' {
+				if tree, err := parseJSON(s); err == nil {
+					x = tree
+				}
+			}
+		}
+		// XML 中根元素或数组中的数组，元素都叫 item
+		if o, ok := x.(*object); ok && len(o.keys) == 1 && o.keys[0] == "item" {
+			x = o.vals["item"]
+		}
+		a, ok := x.([]any)
+		if !ok {
+			// XML 中只有一个元素的数组
+			a = []any{x}
+		}
+		out := make([]any, len(a))
+		for i, e := range a {
+			out[i] = coerce(e, t.Elem())
+		}
+		return out
+	case reflect.Map:
+		if isString && strings.TrimSpace(s) == "" {
+			return nil
+		}
+		if o, ok := x.(*object); ok {
+			out := newObject()
+			for _, k := range o.keys {
+				out.set(k, coerce(o.vals[k], t.Elem()))
+			}
+			return out
+		}
+	case reflect.Struct:
+		if isString && strings.TrimSpace(s) == "" {
+			return nil
+		}
+		fields := structFields(t)
+		if a, ok := x.([]any); ok && len(fields.list) == 1 && fields.list[0].typ.Kind() == reflect.Slice {
+			// 只有一个切片字段的结构体，例如 CSV 中的 Serverslice
+			o := newObject()
+			o.set(fields.list[0].name, a)
+			x = o
+		}
+		if o, ok := x.(*object); ok {
+			out := newObject()
+			for _, k := range o.keys {
+				v := o.vals[k]
+				if f, ok := fields.lookup(k); ok {
+					v = coerce(v, f.typ)
+				}
+				out.set(k, v)
+			}
+			return out
+		}
+	}
+	return x
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// parseDuration 把 "1m30s" 这样的字符串转换成 time.Duration 在 JSON 中的纳秒数
+func parseDuration(s string) (json.Number, bool) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return "", false
+	}
+	return json.Number(strconv.FormatInt(int64(d), 10)), true
+}
+
+// durations 只转换树中 time.Duration 位置上的字符串，其他值不变，用于 JSON
+func durations(x any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if x == nil || reflect.PointerTo(t).Implements(jsonUnmarshaler) {
+		return x
+	}
+	switch v := x.(type) {
+	case string:
+		if n, ok := parseDuration(v); ok && t == durationType {
+			return n
+		}
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			out := make([]any, len(v))
+			for i, e := range v {
+				out[i] = durations(e, t.Elem())
+			}
+			return out
+		}
+	case *object:
+		out := newObject()
+		for _, k := range v.keys {
+			e := v.vals[k]
+			switch t.Kind() {
+			case reflect.Map:
+				e = durations(e, t.Elem())
+			case reflect.Struct:
+				if f, ok := structFields(t).lookup(k); ok {
+					e = durations(e, f.typ)
+				}
+			}
+			out.set(k, e)
+		}
+		return out
+	}
+	return x
+}
+
+var durationCache sync.Map // reflect.Type -> bool
+
+// hasDuration 表示 t 中有 time.Duration，只有这样的类型 JSON 才需要先读成树
+func hasDuration(t reflect.Type) bool {
+	if has, ok := durationCache.Load(t); ok {
+		return has.(bool)
+	}
+	seen := map[reflect.Type]bool{}
+	var walk func(t reflect.Type) bool
+	walk = func(t reflect.Type) bool {
+		if t == durationType {
+			return true
+		}
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			return walk(t.Elem())
+		case reflect.Struct:
+			for _, f := range structFields(t).list {
+				if walk(f.typ) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	has := walk(t)
+	durationCache.Store(t, has)
+	return has
+}
+
+func parseJSON(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	return readTree(dec)
+}
+
+// isNumber 表示 s 是一个 JSON 数字
+func isNumber(s string) bool {
+	return s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9')
+}
+
+func scalarString(x any) any {
+	switch v := x.(type) {
+	case json.Number:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return x
+}
+
+type field struct {
+	name string
+	typ  reflect.Type
+}
+
+type fieldList struct {
+	list   []field
+	byName map[string]field
+}
+
+// lookup 和 encoding/json 一样先精确匹配，再忽略大小写匹配
+func (fl *fieldList) lookup(name string) (field, bool) {
+	if f, ok := fl.byName[name]; ok {
+		return f, true
+	}
+	for _, f := range fl.list {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+var fieldCache sync.Map // reflect.Type -> *fieldList
+
+// structFields 按 encoding/json 的规则列出结构体的 JSON 字段：json 标签中的名字、"-"、嵌入的结构体
+func structFields(t reflect.Type) *fieldList {
+	if fl, ok := fieldCache.Load(t); ok {
+		return fl.(*fieldList)
+	}
+	fl := &fieldList{byName: map[string]field{}}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			ft := sf.Type
+			if sf.Anonymous && name == "" {
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = sf.Name
+			}
+			if _, dup := fl.byName[name]; !dup {
+				fl.list = append(fl.list, field{name, ft})
+				fl.byName[name] = field{name, ft}
+			}
+		}
+	}
+	walk(t)
+	fieldCache.Store(t, fl)
+	return fl
+}
+
+// typeName 返回 v 的类型名，用作 XML 的根元素
+func typeName(v any) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "root"
+	}
+	return t.Name()
+}
+
+// treeError 是树的结构不能用某种格式表示时的错误
+func treeError(format, msg string, args ...any) error {
+	return fmt.Errorf("codec: %s: %s", format, fmt.Sprintf(msg, args...))
+}
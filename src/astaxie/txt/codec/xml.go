@@ -0,0 +1,179 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// XML 把对象的每个字段写成一个子元素，数组写成重复的元素，根元素的名字是类型名。
+// 解码时属性和子元素一样作为字段，只有一个元素的数组和空元素按目标类型处理。
+var XML Codec = &treeCodec{name: "xml", mediaType: "application/xml", encode: encodeXML, decode: decodeXML}
+
+func encodeXML(tree any, v any) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	e := xml.NewEncoder(&b)
+	e.Indent("", "  ")
+	if err := writeXML(e, typeName(v), tree); err != nil {
+		return nil, err
+	}
+	if err := e.Flush(); err != nil {
+		return nil, err
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func writeXML(e *xml.Encoder, name string, x any) error {
+	if !isXMLName(name) {
+		return treeError("xml", "%q is not a valid element name", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch v := x.(type) {
+	case *object:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, k := range v.keys {
+			if err := writeField(e, k, v.vals[k]); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case []any:
+		// 根元素或数组中的数组，元素用 item 包起来
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := writeXML(e, "item", item); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	}
+	return e.EncodeElement(scalarText(x), start)
+}
+
+// writeField 写对象中的一个字段，数组写成多个同名的元素
+func writeField(e *xml.Encoder, name string, x any) error {
+	a, ok := x.([]any)
+	if !ok {
+		return writeXML(e, name, x)
+	}
+	for _, item := range a {
+		if err := writeXML(e, name, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isXMLName 按 XML 1.0 的 Name 规则检查元素名，不允许冒号，因为它表示命名空间前缀
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		ok := r == '_' || 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z' ||
+			0xC0 <= r && r <= 0xD6 || 0xD8 <= r && r <= 0xF6 || 0xF8 <= r && r <= 0x2FF ||
+			0x370 <= r && r <= 0x37D || 0x37F <= r && r <= 0x1FFF || 0x200C <= r && r <= 0x200D ||
+			0x2070 <= r && r <= 0x218F || 0x2C00 <= r && r <= 0x2FEF || 0x3001 <= r && r <= 0xD7FF ||
+			0xF900 <= r && r <= 0xFDCF || 0xFDF0 <= r && r <= 0xFFFD || 0x10000 <= r && r <= 0xEFFFF
+		if i > 0 && !ok {
+			ok = r == '-' || r == '.' || '0' <= r && r <= '9' || r == 0xB7 ||
+				0x300 <= r && r <= 0x36F || 0x203F <= r && r <= 0x2040
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func scalarText(x any) string {
+	switch v := x.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return string(v)
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	}
+	return ""
+}
+
+func decodeXML(data []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("codec: xml: no root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return readElement(d, start)
+		}
+	}
+}
+
+// readElement 读取一个元素：有子元素或属性时是对象，重复的子元素是数组，否则是文本
+func readElement(d *xml.Decoder, start xml.StartElement) (any, error) {
+	var o *object
+	if len(start.Attr) > 0 {
+		o = newObject()
+		for _, a := range start.Attr {
+			o.set(a.Name.Local, a.Value)
+		}
+	}
+	// lists 记录哪些字段是由重复的元素组成的数组
+	lists := map[string]bool{}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			v, err := readElement(d, t)
+			if err != nil {
+				return nil, err
+			}
+			if o == nil {
+				o = newObject()
+			}
+			name := t.Name.Local
+			if old, ok := o.get(name); ok {
+				if lists[name] {
+					o.set(name, append(old.([]any), v))
+				} else {
+					o.set(name, []any{old, v})
+					lists[name] = true
+				}
+			} else {
+				o.set(name, v)
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if o != nil {
+				// 根元素或数组中的数组的子元素都叫 item，只有目标是切片时才能确定，所以由 coerce 展开
+				return o, nil
+			}
+			// 子元素之间的空白被丢弃，只有文本的元素原样保留，空白也是字符串的一部分
+			return text.String(), nil
+		}
+	}
+}
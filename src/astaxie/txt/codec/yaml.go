@@ -0,0 +1,637 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// YAML 支持配置文件中常用的部分：块形式的映射和序列、流形式的 [a, b] 和 {a: 1}、
+// 单双引号的字符串、| 和 > 块标量、注释和开头的 ---。
+// 不支持锚点和别名（& *）、标签（!!）、多个文档和复杂的键。
+var YAML Codec = &treeCodec{name: "yaml", mediaType: "application/yaml", encode: encodeYAML, decode: decodeYAML}
+
+func encodeYAML(tree any, _ any) ([]byte, error) {
+	var b bytes.Buffer
+	switch v := tree.(type) {
+	case *object:
+		if len(v.keys) > 0 {
+			yamlBlock(&b, v, 0)
+			return b.Bytes(), nil
+		}
+	case []any:
+		if len(v) > 0 {
+			yamlBlock(&b, v, 0)
+			return b.Bytes(), nil
+		}
+	}
+	b.WriteString(yamlScalar(tree))
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// nonEmpty 表示 x 是要写成多行的对象或数组
+func nonEmpty(x any) bool {
+	switch v := x.(type) {
+	case *object:
+		return len(v.keys) > 0
+	case []any:
+		return len(v) > 0
+	}
+	return false
+}
+
+// yamlBlock 把非空的对象或数组写成缩进 indent 的多行
+func yamlBlock(b *bytes.Buffer, x any, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch v := x.(type) {
+	case *object:
+		for _, k := range v.keys {
+			b.WriteString(pad)
+			b.WriteString(yamlKey(k))
+			b.WriteByte(':')
+			if val := v.vals[k]; nonEmpty(val) {
+				b.WriteByte('\n')
+				yamlBlock(b, val, indent+2)
+			} else {
+				b.WriteByte(' ')
+				b.WriteString(yamlScalar(val))
+				b.WriteByte('\n')
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if !nonEmpty(item) {
+				b.WriteString(pad + "- " + yamlScalar(item) + "\n")
+				continue
+			}
+			// 对象或数组的第一行写在 "- " 后面
+			var sub bytes.Buffer
+			yamlBlock(&sub, item, indent+2)
+			b.WriteString(pad + "- ")
+			b.Write(sub.Bytes()[indent+2:])
+		}
+	}
+}
+
+func yamlKey(k string) string {
+	if k != "" && !needsQuote(k) {
+		return k
+	}
+	return quoteString(k)
+}
+
+// yamlScalar 写标量、空对象和空数组
+func yamlScalar(x any) string {
+	switch v := x.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return string(v)
+	case string:
+		if v == "" || needsQuote(v) {
+			return quoteString(v)
+		}
+		if _, isString := resolvePlain(v).(string); !isString {
+			// "true"、"1.0" 这样的字符串加上引号，否则会被读成其他类型
+			return quoteString(v)
+		}
+		return v
+	case *object:
+		return "{}"
+	case []any:
+		return "[]"
+	}
+	return fmt.Sprint(x)
+}
+
+// needsQuote 表示 s 不能写成不加引号的 YAML 标量
+func needsQuote(s string) bool {
+	if s[0] == ' ' || s[len(s)-1] == ' ' || strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(s[0])) {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteString 写双引号字符串，转义的写法在 YAML 和 TOML 中都有效
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+var (
+	yamlInt   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloat = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// resolvePlain 按 YAML 1.2 的 core schema 解析不加引号的标量
+func resolvePlain(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	switch {
+	case yamlInt.MatchString(s):
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return json.Number(strconv.FormatInt(i, 10))
+		}
+		if u, err := strconv.ParseUint(strings.TrimPrefix(s, "+"), 10, 64); err == nil {
+			return json.Number(strconv.FormatUint(u, 10))
+		}
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o"):
+		base := map[byte]int{'x': 16, 'o': 8}[s[1]]
+		if u, err := strconv.ParseUint(s[2:], base, 64); err == nil {
+			return json.Number(strconv.FormatUint(u, 10))
+		}
+		return s
+	}
+	if yamlFloat.MatchString(s) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return s
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return s
+}
+
+// YAMLError 是 YAML 的语法错误
+type YAMLError struct {
+	Line int
+	Msg  string
+}
+
+func (e *YAMLError) Error() string {
+	return fmt.Sprintf("codec: yaml: line %d: %s", e.Line, e.Msg)
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	// text 是去掉缩进和注释的内容，空行和只有注释的行为空
+	text string
+	raw  string
+	// tab 表示缩进后面是制表符，块标量的内容可以这样，其他的行不可以
+	tab bool
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+func decodeYAML(data []byte) (any, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(trimmed)
+		text := stripComment(trimmed)
+		tab := strings.HasPrefix(text, "\t")
+		if tab && strings.TrimSpace(text) == "" {
+			text, tab = "", false
+		}
+		// 只有第一列的 --- 和 ... 是文档标记，缩进的可能是块标量的内容
+		if indent == 0 && (text == "---" && len(p.lines) == 0 || text == "...") {
+			continue
+		}
+		if indent == 0 && text == "---" {
+			return nil, &YAMLError{i + 1, "multiple documents are not supported"}
+		}
+		p.lines = append(p.lines, yamlLine{i + 1, indent, text, raw, tab})
+	}
+	v, err := p.node(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.skipBlank(); p.i < len(p.lines) {
+		if err := p.checkTab(); err != nil {
+			return nil, err
+		}
+		return nil, p.errorf("unexpected indentation")
+	}
+	return v, nil
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	line := 0
+	if p.i < len(p.lines) {
+		line = p.lines[p.i].num
+	} else if len(p.lines) > 0 {
+		line = p.lines[len(p.lines)-1].num
+	}
+	return &YAMLError{line, fmt.Sprintf(format, args...)}
+}
+
+func (p *yamlParser) skipBlank() {
+	for p.i < len(p.lines) && p.lines[p.i].text == "" {
+		p.i++
+	}
+}
+
+// checkTab 在当前行的缩进后面有制表符时返回错误。块标量直接读取原始的行，不经过这里。
+func (p *yamlParser) checkTab() error {
+	if p.i < len(p.lines) && p.lines[p.i].tab {
+		return p.errorf("tabs are not allowed in indentation")
+	}
+	return nil
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// node 解析从当前行开始、缩进至少为 minIndent 的值
+func (p *yamlParser) node(minIndent int) (any, error) {
+	p.skipBlank()
+	if err := p.checkTab(); err != nil {
+		return nil, err
+	}
+	if p.i >= len(p.lines) || p.lines[p.i].indent < minIndent {
+		return nil, nil
+	}
+	l := p.lines[p.i]
+	if isSeqItem(l.text) {
+		return p.sequence(l.indent)
+	}
+	if _, _, ok, err := splitKey(l.text); err != nil {
+		return nil, p.errorf("%v", err)
+	} else if ok {
+		return p.mapping(l.indent)
+	}
+	p.i++
+	return p.value(l.text, minIndent-1)
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	a := []any{}
+	for p.skipBlank(); p.i < len(p.lines); p.skipBlank() {
+		if err := p.checkTab(); err != nil {
+			return nil, err
+		}
+		l := p.lines[p.i]
+		if l.indent != indent || !isSeqItem(l.text) {
+			break
+		}
+		rest := strings.TrimLeft(l.text[1:], " ")
+		var v any
+		var err error
+		if rest == "" {
+			p.i++
+			v, err = p.node(indent + 1)
+		} else {
+			// 把 "- " 后面的内容当作缩进更深的一行，这样 "- a: 1" 后面可以接着写 "  b: 2"
+			col := indent + len(l.text) - len(rest)
+			p.lines[p.i] = yamlLine{l.num, col, rest, l.raw, false}
+			v, err = p.node(col)
+		}
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	o := newObject()
+	for p.skipBlank(); p.i < len(p.lines); p.skipBlank() {
+		if err := p.checkTab(); err != nil {
+			return nil, err
+		}
+		l := p.lines[p.i]
+		if l.indent != indent {
+			break
+		}
+		key, rest, ok, err := splitKey(l.text)
+		if err != nil || !ok {
+			return nil, p.errorf("expected 'key: value'")
+		}
+		if _, dup := o.get(key); dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.i++
+		var v any
+		if rest == "" {
+			p.skipBlank()
+			// 序列可以和它的键缩进相同
+			if p.i < len(p.lines) && (p.lines[p.i].indent > indent || p.lines[p.i].indent == indent && isSeqItem(p.lines[p.i].text)) {
+				v, err = p.node(p.lines[p.i].indent)
+			}
+		} else {
+			v, err = p.value(rest, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		o.set(key, v)
+	}
+	return o, nil
+}
+
+// value 解析一行中的值：块标量、流形式的集合、引号字符串或普通标量，parent 是所属结构的缩进
+func (p *yamlParser) value(s string, parent int) (any, error) {
+	switch s[0] {
+	case '|', '>':
+		return p.blockScalar(s, parent)
+	case '&', '*', '!':
+		return nil, p.errorf("anchors, aliases and tags are not supported")
+	}
+	f := &flowParser{s: s}
+	v, err := f.value(false)
+	if err == nil {
+		if f.skipSpace(); f.pos < len(s) {
+			err = fmt.Errorf("unexpected %q", s[f.pos:])
+		}
+	}
+	if err != nil {
+		// 这一行已经读过了
+		return nil, &YAMLError{p.lines[p.i-1].num, err.Error()}
+	}
+	return v, nil
+}
+
+// blockScalar 读取 | 或 > 开头的多行字符串，内容是后面缩进大于 parent 的所有行
+func (p *yamlParser) blockScalar(header string, parent int) (any, error) {
+	folded := header[0] == '>'
+	chomp := strings.TrimLeft(header[1:], "123456789")
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, p.errorf("bad block scalar header %q", header)
+	}
+	var lines []string
+	indent := -1
+	for ; p.i < len(p.lines); p.i++ {
+		raw := p.lines[p.i].raw
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		n := len(raw) - len(strings.TrimLeft(raw, " "))
+		if n <= parent {
+			break
+		}
+		if indent < 0 {
+			indent = n
+		}
+		if n < indent {
+			return nil, p.errorf("bad indentation in block scalar")
+		}
+		lines = append(lines, raw[indent:])
+	}
+	var b strings.Builder
+	for i, l := range lines {
+		// 折叠时相邻的两行用空格连接，空行变成换行，缩进更深的行保持原样
+		switch {
+		case i == 0:
+		case folded && l != "" && lines[i-1] == "":
+		case !folded || l == "" || l[0] == ' ' || lines[i-1][0] == ' ':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(' ')
+		}
+		b.WriteString(l)
+	}
+	s := b.String()
+	switch trimmed := strings.TrimRight(s, "\n"); chomp {
+	case "-":
+		s = trimmed
+	case "":
+		if trimmed != "" {
+			s = trimmed + "\n"
+		} else {
+			s = ""
+		}
+	default:
+		if len(lines) > 0 {
+			s += "\n"
+		}
+	}
+	return s, nil
+}
+
+// stripComment 去掉 # 开始的注释，引号中的 # 和没有空格在前面的 # 不算
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" :[{,-", s[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return strings.TrimRight(s[:i], " ")
+		}
+	}
+	return strings.TrimRight(s, " ")
+}
+
+// splitKey 把 "key: value" 分成键和值，text 不是映射中的一项时 ok 为 false
+func splitKey(text string) (key, rest string, ok bool, err error) {
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", false, nil
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		f := &flowParser{s: text}
+		k, err := f.quoted()
+		if err != nil {
+			return "", "", false, err
+		}
+		f.skipSpace()
+		if f.pos < len(text) && text[f.pos] == ':' && (f.pos+1 == len(text) || text[f.pos+1] == ' ') {
+			return k, strings.TrimSpace(text[f.pos+1:]), true, nil
+		}
+		return "", "", false, nil
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// flowParser 解析一行中的值，包括 [a, b] 和 {a: 1} 这样的流形式的集合
+type flowParser struct {
+	s   string
+	pos int
+}
+
+func (f *flowParser) skipSpace() {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// value 读取一个值，inFlow 表示在 [] 或 {} 中，此时 , ] } 结束普通标量
+func (f *flowParser) value(inFlow bool) (any, error) {
+	f.skipSpace()
+	if f.pos >= len(f.s) {
+		return nil, nil
+	}
+	switch f.s[f.pos] {
+	case '[':
+		f.pos++
+		a := []any{}
+		for {
+			f.skipSpace()
+			if f.pos < len(f.s) && f.s[f.pos] == ']' {
+				f.pos++
+				return a, nil
+			}
+			v, err := f.value(true)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		o := newObject()
+		for {
+			f.skipSpace()
+			if f.pos < len(f.s) && f.s[f.pos] == '}' {
+				f.pos++
+				return o, nil
+			}
+			k, err := f.value(true)
+			if err != nil {
+				return nil, err
+			}
+			f.skipSpace()
+			if f.pos >= len(f.s) || f.s[f.pos] != ':' {
+				return nil, fmt.Errorf("expected ':' in flow mapping")
+			}
+			f.pos++
+			v, err := f.value(true)
+			if err != nil {
+				return nil, err
+			}
+			o.set(scalarText(k), v)
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		return f.quoted()
+	}
+	start := f.pos
+	for f.pos < len(f.s) {
+		c := f.s[f.pos]
+		if inFlow && (c == ',' || c == ']' || c == '}' || c == ':' && (f.pos+1 == len(f.s) || f.s[f.pos+1] == ' ')) {
+			break
+		}
+		f.pos++
+	}
+	return resolvePlain(strings.TrimSpace(f.s[start:f.pos])), nil
+}
+
+func (f *flowParser) separator(end byte) error {
+	f.skipSpace()
+	if f.pos < len(f.s) {
+		switch f.s[f.pos] {
+		case ',':
+			f.pos++
+			return nil
+		case end:
+			return nil
+		}
+	}
+	return fmt.Errorf("expected ',' or '%c'", end)
+}
+
+// quoted 读取单引号或双引号字符串
+func (f *flowParser) quoted() (string, error) {
+	q := f.s[f.pos]
+	f.pos++
+	var b strings.Builder
+	for f.pos < len(f.s) {
+		c := f.s[f.pos]
+		f.pos++
+		switch {
+		case c == q && q == '\'' && f.pos < len(f.s) && f.s[f.pos] == '\'':
+			b.WriteByte('\'')
+			f.pos++
+		case c == q:
+			return b.String(), nil
+		case c == '\\' && q == '"':
+			if err := f.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (f *flowParser) escape(b *strings.Builder) error {
+	if f.pos >= len(f.s) {
+		return fmt.Errorf("unterminated escape")
+	}
+	c := f.s[f.pos]
+	f.pos++
+	simple := map[byte]string{'n': "\n", 't': "\t", 'r': "\r", '"': `"`, '\\': `\`, '/': "/",
+		'0': "\x00", 'a': "\a", 'b': "\b", 'e': "\x1b", 'f': "\f", 'v': "\v", ' ': " "}
+	if s, ok := simple[c]; ok {
+		b.WriteString(s)
+		return nil
+	}
+	n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if n == 0 || f.pos+n > len(f.s) {
+		return fmt.Errorf("bad escape \\%c", c)
+	}
+	r, err := strconv.ParseUint(f.s[f.pos:f.pos+n], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(r)) {
+		return fmt.Errorf("bad escape \\%c%s", c, f.s[f.pos:f.pos+n])
+	}
+	f.pos += n
+	b.WriteRune(rune(r))
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package config 把默认值、配置文件、环境变量和命令行参数按顺序合并到一个结构体中，后面的覆盖前面的：
+//
+//  1. 结构体标签 default:"..." 给出的默认值（只用于零值字段）
+//  2. 配置文件，格式按扩展名选择（JSON、YAML、TOML 等，见 astaxie/txt/codec），字段名和 json 标签相同
+//  3. 环境变量，名字是前缀加上字段路径的大写蛇形，例如 Server.ReadTimeout 是 APP_SERVER_READ_TIMEOUT
+//  4. 命令行参数，名字是字段路径的小写短横线形式，例如 -server.read-timeout 10s
+//
+// 合并后按 validate 标签和 Validate 方法校验。env:"NAME" 和 flag:"name" 标签可以指定名字，"-" 表示不使用这个来源，
+// usage 标签是命令行参数的说明。另外总有一个 -config 参数可以指定配置文件。
+//
+// 字段可以是字符串、布尔值、数字、time.Duration、实现了 encoding.TextUnmarshaler 的类型、
+// 这些类型的切片（环境变量和命令行中用逗号分隔）以及嵌套的结构体。time.Duration 在配置文件中也写成 "10s" 这样的字符串。
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+
+	"astaxie/txt/codec"
+)
+
+// Options 是配置的来源
+type Options struct {
+	// Name 是程序名，用于命令行的帮助信息
+	Name string
+	// File 是配置文件，为空时不读文件，可以被 -config 参数覆盖
+	File string
+	// FileOptional 为 true 时配置文件不存在不算错误
+	FileOptional bool
+	// EnvPrefix 是环境变量名的前缀，例如 APP_，为空时不读环境变量
+	EnvPrefix string
+	// Args 是命令行参数，不包括程序名，通常是 os.Args[1:]，为 nil 时不解析命令行
+	Args []string
+	// Output 是命令行帮助和错误信息的输出，默认是 os.Stderr
+	Output io.Writer
+	// ErrorLog 记录 Watch 中重新加载失败的错误，默认是 log.Default()
+	ErrorLog *log.Logger
+}
+
+// Validator 是可以校验自身的配置，在标签的规则之后调用
+type Validator interface {
+	Validate() error
+}
+
+// Load 按 Options 中的来源填充 cfg，cfg 必须是结构体指针，其中已有的值也作为默认值。
+// 命令行中有 -h 时返回 flag.ErrHelp。
+func Load(cfg any, opt Options) error {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config: Load needs a pointer to a struct")
+	}
+	fields := fieldsOf(rv.Elem(), true)
+
+	for _, f := range fields {
+		if def, ok := f.tag.Lookup("default"); ok && f.v.IsZero() {
+			if err := setString(f.v, def); err != nil {
+				return fmt.Errorf("config: default of %s: %w", f.name(), err)
+			}
+		}
+	}
+
+	// 先找出 -config，文件要在其他命令行参数之前合并
+	file := opt.File
+	if p, ok := configFlag(opt.Args); ok {
+		file = p
+	}
+	if file != "" {
+		err := codec.ReadFile(file, cfg)
+		if errors.Is(err, fs.ErrNotExist) && opt.FileOptional && file == opt.File {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+
+	if opt.EnvPrefix != "" {
+		for _, f := range fields {
+			name := f.envName(opt.EnvPrefix)
+			if name == "" {
+				continue
+			}
+			if s, ok := os.LookupEnv(name); ok {
+				if err := setString(f.v, s); err != nil {
+					return fmt.Errorf("config: $%s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	if opt.Args != nil {
+		fs := flagSet(fields, opt)
+		if err := fs.Parse(opt.Args); err != nil {
+			return err
+		}
+		if fs.NArg() > 0 {
+			return fmt.Errorf("config: unexpected argument %q", fs.Arg(0))
+		}
+	}
+
+	return validate(cfg, fields)
+}
+
+// configFlag 找出命令行中的 -config path、-config=path 或 --config 形式
+func configFlag(args []string) (string, bool) {
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		name, val, hasVal := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || name != "config" {
+			continue
+		}
+		if hasVal {
+			return val, true
+		}
+		if i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+func flagSet(fields []field, opt Options) *flag.FlagSet {
+	name := opt.Name
+	if name == "" {
+		name = os.Args[0]
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if opt.Output != nil {
+		fs.SetOutput(opt.Output)
+	}
+	file := opt.File
+	fs.StringVar(&file, "config", file, "配置文件")
+	for _, f := range fields {
+		if n := f.flagName(); n != "" {
+			usage := f.tag.Get("usage")
+			if env := f.envName(opt.EnvPrefix); opt.EnvPrefix != "" && env != "" {
+				usage = strings.TrimSpace(usage + " (环境变量 $" + env + ")")
+			}
+			fs.Var(flagValue{f.v}, n, usage)
+		}
+	}
+	return fs
+}
+
+// flagValue 让结构体字段可以作为 flag.Value
+type flagValue struct{ v reflect.Value }
+
+func (fv flagValue) String() string {
+	if !fv.v.IsValid() {
+		return ""
+	}
+	return formatValue(fv.v)
+}
+
+func (fv flagValue) Set(s string) error { return setString(fv.v, s) }
+
+// IsBoolFlag 让布尔字段可以写成 -debug 而不是 -debug=true
+func (fv flagValue) IsBoolFlag() bool {
+	return fv.v.IsValid() && fv.v.Kind() == reflect.Bool
+}
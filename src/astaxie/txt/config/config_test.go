@@ -0,0 +1,289 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type serverConfig struct {
+	Addr        string        `json:"addr" default:":9090" validate:"required" usage:"监听地址"`
+	ReadTimeout time.Duration `json:"readTimeout" default:"5s" validate:"min=1s,max=1m"`
+	Allow       []netip.Addr  `json:"allow"`
+}
+
+type dbConfig struct {
+	DSN      string `json:"dsn" env:"DATABASE_URL" validate:"required"`
+	MaxConns int    `json:"maxConns" default:"10" validate:"min=1,max=100"`
+}
+
+type appConfig struct {
+	Server   serverConfig `json:"server"`
+	DB       *dbConfig    `json:"db"`
+	LogLevel string       `json:"logLevel" default:"info" validate:"oneof=debug info warn error"`
+	Debug    bool         `json:"debug"`
+	Tags     []string     `json:"tags"`
+	Secret   string       `json:"-"`
+	HTTPPort uint16       `json:"httpPort" flag:"port" env:"-"`
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_Layers(t *testing.T) {
+	file := writeFile(t, "app.yaml", `
+server:
+  addr: ":8080"
+  allow: [127.0.0.1, "::1"]
+db:
+  dsn: root:123456@/test
+  maxConns: 20
+logLevel: warn
+tags: [a, b]
+`)
+	t.Setenv("APP_SERVER_READ_TIMEOUT", "30s")
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	t.Setenv("DATABASE_URL", "env@/db")
+	t.Setenv("APP_HTTP_PORT", "1") // env:"-"
+
+	var cfg appConfig
+	err := Load(&cfg, Options{
+		File:      file,
+		EnvPrefix: "APP_",
+		Args:      []string{"-db.max-conns", "50", "-debug", "-tags=x,y", "-port", "8443"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := appConfig{
+		Server: serverConfig{
+			Addr:        ":8080",          // 文件
+			ReadTimeout: 30 * time.Second, // 环境变量
+			Allow:       []netip.Addr{netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("::1")},
+		},
+		DB:       &dbConfig{DSN: "env@/db", MaxConns: 50}, // 环境变量、命令行
+		LogLevel: "debug",
+		Debug:    true,
+		Tags:     []string{"x", "y"},
+		HTTPPort: 8443,
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got  %+v %+v\nwant %+v %+v", cfg, cfg.DB, want, want.DB)
+	}
+}
+
+func Test_FileDurations(t *testing.T) {
+	files := map[string]string{
+		"app.yaml": "server:\n  readTimeout: 10s\ndb:\n  dsn: x\n",
+		"app.json": `{"server": {"readTimeout": "10s"}, "db": {"dsn": "x"}}`,
+		"app.toml": "[server]\nreadTimeout = \"10s\"\n[db]\ndsn = \"x\"\n",
+	}
+	for name, content := range files {
+		var cfg appConfig
+		if err := Load(&cfg, Options{File: writeFile(t, name, content)}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if cfg.Server.ReadTimeout != 10*time.Second {
+			t.Errorf("%s: readTimeout = %v", name, cfg.Server.ReadTimeout)
+		}
+	}
+}
+
+func Test_Defaults(t *testing.T) {
+	cfg := appConfig{LogLevel: "warn"} // 已有的值优先于 default 标签
+	err := Load(&cfg, Options{})
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Errors) != 1 || !strings.Contains(err.Error(), "db.dsn: is required") {
+		t.Fatalf("Load = %v", err)
+	}
+	if cfg.Server.Addr != ":9090" || cfg.Server.ReadTimeout != 5*time.Second || cfg.DB.MaxConns != 10 || cfg.LogLevel != "warn" {
+		t.Errorf("got %+v %+v", cfg, cfg.DB)
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	var cfg appConfig
+	err := Load(&cfg, Options{Args: []string{
+		"-db.dsn", "x", "-db.max-conns", "0", "-log-level", "trace", "-server.read-timeout", "2m", "-server.addr", "",
+	}})
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		t.Fatalf("Load = %v", err)
+	}
+	for _, want := range []string{
+		"server.addr: is required",
+		"server.readTimeout: must be at most 1m",
+		"db.maxConns: must be at least 1",
+		`logLevel: must be one of debug, info, warn, error, got "trace"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%v\nmissing %q", err, want)
+		}
+	}
+
+	var v validated
+	if err := Load(&v, Options{}); err == nil || !strings.Contains(err.Error(), "min > max") {
+		t.Errorf("Validate method: %v", err)
+	}
+}
+
+type validated struct {
+	Min int `json:"min" default:"5"`
+	Max int `json:"max" default:"1"`
+}
+
+func (v *validated) Validate() error {
+	if v.Min > v.Max {
+		return errors.New("min > max")
+	}
+	return nil
+}
+
+func Test_Errors(t *testing.T) {
+	var cfg appConfig
+	if err := Load(&cfg, Options{File: "/nonexistent/app.json"}); err == nil {
+		t.Error("missing file accepted")
+	}
+	if err := Load(&cfg, Options{File: "/nonexistent/app.json", FileOptional: true, Args: []string{"-db.dsn=x"}}); err != nil {
+		t.Errorf("optional file: %v", err)
+	}
+	// -config 指定的文件必须存在
+	if err := Load(&cfg, Options{File: "app.json", FileOptional: true, Args: []string{"-config", "/nonexistent/x.toml"}}); err == nil {
+		t.Error("missing -config file accepted")
+	}
+	if err := Load(&cfg, Options{Args: []string{"extra"}}); err == nil {
+		t.Error("positional argument accepted")
+	}
+	if err := Load(cfg, Options{}); err == nil {
+		t.Error("non-pointer accepted")
+	}
+
+	var out bytes.Buffer
+	err := Load(&appConfig{}, Options{Name: "app", EnvPrefix: "APP_", Args: []string{"-h"}, Output: &out})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("-h = %v", err)
+	}
+	for _, want := range []string{"-server.addr value", "监听地址 (环境变量 $APP_SERVER_ADDR)", "-port value", "-config string"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("usage missing %q:\n%s", want, out.String())
+		}
+	}
+
+	t.Setenv("APP_SERVER_READ_TIMEOUT", "soon")
+	if err := Load(&cfg, Options{EnvPrefix: "APP_"}); err == nil || !strings.Contains(err.Error(), "$APP_SERVER_READ_TIMEOUT") {
+		t.Errorf("bad env: %v", err)
+	}
+}
+
+func Test_ConfigFlag(t *testing.T) {
+	file := writeFile(t, "app.toml", "logLevel = \"error\"\n[db]\ndsn = \"toml\"\n")
+	for _, args := range [][]string{{"-config", file}, {"--config=" + file}} {
+		var cfg appConfig
+		if err := Load(&cfg, Options{File: "default.json", Args: args}); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.LogLevel != "error" || cfg.DB.DSN != "toml" {
+			t.Errorf("%v: got %+v", args, cfg)
+		}
+	}
+}
+
+func Test_Words(t *testing.T) {
+	cases := map[string]string{
+		"readTimeout":  "read timeout",
+		"read_timeout": "read timeout",
+		"ReadTimeout":  "read timeout",
+		"HTTPPort":     "http port",
+		"dsn":          "dsn",
+		"maxConns2":    "max conns2",
+	}
+	for in, want := range cases {
+		if got := strings.Join(words(in), " "); got != want {
+			t.Errorf("words(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	path := writeFile(t, "app.json", `{"db": {"dsn": "a"}, "logLevel": "info"}`)
+	logs := make(logChan, 10)
+	c, err := New[appConfig](Options{File: path, ErrorLog: log.New(logs, "", 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloads := make(chan []string, 10)
+	c.OnReload(func(old, new *appConfig) {
+		reloads <- Changed(old, new)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		c.Watch(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+
+	// 修改时间的精度可能不够，所以同时改变文件大小
+	write := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"db": {"dsn": "b"}, "logLevel": "debug", "debug": true}`)
+	select {
+	case changed := <-reloads:
+		if want := []string{"db.dsn", "logLevel", "debug"}; !reflect.DeepEqual(changed, want) {
+			t.Errorf("Changed = %v, want %v", changed, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload")
+	}
+	if c.Get().DB.DSN != "b" {
+		t.Errorf("Get = %+v", c.Get().DB)
+	}
+
+	// 无效的配置不替换当前的配置
+	write(`{"logLevel": "verbose"}`)
+	// 写入到一半时读到的文件可能先报告语法错误
+	timeout := time.After(5 * time.Second)
+	for msg := ""; !strings.Contains(msg, "logLevel: must be one of"); {
+		select {
+		case msg = <-logs:
+		case <-timeout:
+			t.Fatal("no reload error")
+		}
+	}
+	cancel()
+	<-done
+	if c.Get().LogLevel != "debug" {
+		t.Errorf("level %s", c.Get().LogLevel)
+	}
+	if len(reloads) != 0 {
+		t.Errorf("unexpected reload %v", <-reloads)
+	}
+}
+
+// logChan 把每条日志发送到 channel
+type logChan chan string
+
+func (c logChan) Write(p []byte) (int, error) {
+	select {
+	case c <- string(p):
+	default:
+	}
+	return len(p), nil
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// field 是配置结构体中的一个叶子字段
+type field struct {
+	path []string
+	v    reflect.Value
+	tag  reflect.StructTag
+}
+
+var textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// isLeaf 表示 t 是直接从字符串设置的类型，而不是要展开的结构体
+func isLeaf(t reflect.Type) bool {
+	return t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(textUnmarshaler)
+}
+
+// fieldsOf 列出结构体中所有的叶子字段，名字和 json 标签相同，嵌入的结构体展开到外层。
+// alloc 为 true 时为 nil 的结构体指针分配内存，否则把它当作一个叶子字段。
+func fieldsOf(v reflect.Value, alloc bool) []field {
+	var out []field
+	var walk func(v reflect.Value, path []string)
+	walk = func(v reflect.Value, path []string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" || !sf.IsExported() && !sf.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct && !isLeaf(fv.Type().Elem()) {
+				if fv.IsNil() && alloc {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				if !fv.IsNil() {
+					fv = fv.Elem()
+				}
+			}
+			switch {
+			case sf.Anonymous && name == "" && fv.Kind() == reflect.Struct:
+				walk(fv, path)
+			case !sf.IsExported():
+			case fv.Kind() == reflect.Struct && !isLeaf(fv.Type()):
+				walk(fv, append(path[:len(path):len(path)], nameOr(name, sf.Name)))
+			default:
+				out = append(out, field{append(path[:len(path):len(path)], nameOr(name, sf.Name)), fv, sf.Tag})
+			}
+		}
+	}
+	walk(v, nil)
+	return out
+}
+
+func nameOr(name, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
+
+// name 是字段在配置文件中的路径，例如 server.readTimeout
+func (f field) name() string {
+	return strings.Join(f.path, ".")
+}
+
+// words 把 readTimeout、read_timeout、ReadTimeout、HTTPPort 拆成小写的单词
+func words(s string) []string {
+	var out []string
+	var cur []rune
+	rs := []rune(s)
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(cur) > 0 {
+				out, cur = append(out, string(cur)), nil
+			}
+			continue
+		case unicode.IsUpper(r) && len(cur) > 0:
+			// 小写后面的大写，或者缩写后面的单词（HTTPPort 中的 P）开始一个新单词
+			prevLower := unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if prevLower || nextLower && unicode.IsUpper(rs[i-1]) {
+				out, cur = append(out, string(cur)), nil
+			}
+		}
+		cur = append(cur, unicode.ToLower(r))
+	}
+	if len(cur) > 0 {
+		out = append(out, string(cur))
+	}
+	return out
+}
+
+// join 用 wordSep 连接每一级名字中的单词，用 partSep 连接各级
+func (f field) join(wordSep, partSep string, upper bool) string {
+	var parts []string
+	for _, p := range f.path {
+		parts = append(parts, strings.Join(words(p), wordSep))
+	}
+	s := strings.Join(parts, partSep)
+	if upper {
+		s = strings.ToUpper(s)
+	}
+	return s
+}
+
+// envName 返回环境变量名，env:"-" 时返回空字符串
+func (f field) envName(prefix string) string {
+	if n, ok := f.tag.Lookup("env"); ok {
+		if n == "-" {
+			return ""
+		}
+		return n
+	}
+	return prefix + f.join("_", "_", true)
+}
+
+// flagName 返回命令行参数名，flag:"-" 时返回空字符串
+func (f field) flagName() string {
+	if n, ok := f.tag.Lookup("flag"); ok {
+		if n == "-" {
+			return ""
+		}
+		return n
+	}
+	return f.join("-", ".", false)
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// setString 从字符串设置字段，切片用逗号分隔
+func setString(v reflect.Value, s string) error {
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var parts []string
+		if s = strings.TrimSpace(s); s != "" {
+			parts = strings.Split(s, ",")
+		}
+		sl := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setString(sl.Index(i), strings.TrimSpace(p)); err != nil {
+				return err
+			}
+		}
+		v.Set(sl)
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := setString(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// formatValue 是 setString 的反向操作，用于命令行帮助中的默认值
+func formatValue(v reflect.Value) string {
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			b, _ := m.MarshalText()
+			return string(b)
+		}
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	}
+	return fmt.Sprint(v.Interface())
+}
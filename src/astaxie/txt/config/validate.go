@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError 是一个字段没有通过校验
+type FieldError struct {
+	// Field 是字段在配置文件中的路径，例如 server.port
+	Field string
+	Msg   string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Msg
+}
+
+// ValidationError 包含所有没有通过校验的字段
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "config: invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// validate 检查 validate 标签中用逗号分隔的规则：
+//
+//	required     不能是零值
+//	min=N max=N  数字的范围，字符串和切片的长度，time.Duration 写成 1s 这样的形式
+//	oneof=a b c  取值只能是其中之一
+//
+// 然后调用 cfg 的 Validate 方法
+func validate(cfg any, fields []field) error {
+	var errs []error
+	for _, f := range fields {
+		rules := f.tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		for _, rule := range strings.Split(rules, ",") {
+			if msg := check(f.v, rule); msg != "" {
+				errs = append(errs, &FieldError{f.name(), msg})
+			}
+		}
+	}
+	if v, ok := cfg.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{errs}
+	}
+	return nil
+}
+
+// check 检查一条规则，通过时返回空字符串
+func check(v reflect.Value, rule string) string {
+	name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+		return ""
+	case "oneof":
+		s := formatValue(v)
+		options := strings.Fields(arg)
+		if !slices.Contains(options, s) {
+			return fmt.Sprintf("must be one of %s, got %q", strings.Join(options, ", "), s)
+		}
+		return ""
+	case "min", "max":
+		n, limit, err := measure(v, arg)
+		if err != nil {
+			return fmt.Sprintf("bad rule %q: %v", rule, err)
+		}
+		what := "must be"
+		if k := v.Kind(); k == reflect.String || k == reflect.Slice || k == reflect.Map {
+			what = "length must be"
+		}
+		if name == "min" && n < limit {
+			return fmt.Sprintf("%s at least %s", what, arg)
+		}
+		if name == "max" && n > limit {
+			return fmt.Sprintf("%s at most %s", what, arg)
+		}
+		return ""
+	}
+	return fmt.Sprintf("unknown rule %q", rule)
+}
+
+// measure 返回 min、max 比较的值和限制
+func measure(v reflect.Value, arg string) (n, limit float64, err error) {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(arg)
+		return float64(v.Int()), float64(d), err
+	}
+	limit, err = strconv.ParseFloat(arg, 64)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.String, reflect.Slice, reflect.Map:
+		n = float64(v.Len())
+	default:
+		err = errors.New("min and max need a number, string, slice or duration")
+	}
+	return n, limit, err
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config 保存当前的配置，可以在配置文件改变时重新加载。Get 可以被多个 goroutine 同时调用。
+type Config[T any] struct {
+	opt Options
+	cur atomic.Pointer[T]
+
+	mu       sync.Mutex
+	onReload []func(old, new *T)
+	stamp    fileStamp
+}
+
+// fileStamp 用修改时间和大小判断文件是否改变
+type fileStamp struct {
+	mod  time.Time
+	size int64
+	ok   bool
+}
+
+// New 加载第一份配置，失败时返回错误
+func New[T any](opt Options) (*Config[T], error) {
+	c := &Config[T]{opt: opt}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get 返回当前的配置，调用者不应该修改它
+func (c *Config[T]) Get() *T {
+	return c.cur.Load()
+}
+
+// OnReload 注册一个回调，每次重新加载成功后用旧的和新的配置调用
+func (c *Config[T]) OnReload(f func(old, new *T)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReload = append(c.onReload, f)
+}
+
+// file 是实际使用的配置文件，-config 参数优先
+func (c *Config[T]) file() string {
+	if p, ok := configFlag(c.opt.Args); ok {
+		return p
+	}
+	return c.opt.File
+}
+
+func stat(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{fi.ModTime(), fi.Size(), true}
+}
+
+// Reload 重新读取所有的来源，新的配置通过校验后才替换当前的配置，然后调用 OnReload 注册的回调
+func (c *Config[T]) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reload()
+}
+
+func (c *Config[T]) reload() error {
+	stamp := stat(c.file())
+	cfg := new(T)
+	if err := Load(cfg, c.opt); err != nil {
+		return err
+	}
+	c.stamp = stamp
+	old := c.cur.Swap(cfg)
+	if old != nil {
+		for _, f := range c.onReload {
+			f(old, cfg)
+		}
+	}
+	return nil
+}
+
+// Watch 每隔 interval 检查一次配置文件，改变后重新加载，直到 ctx 结束。
+// 加载失败时记录到 ErrorLog 并继续使用原来的配置，文件再次改变时重试。
+func (c *Config[T]) Watch(ctx context.Context, interval time.Duration) {
+	logger := c.opt.ErrorLog
+	if logger == nil {
+		logger = log.Default()
+	}
+	path := c.file()
+	if path == "" {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		c.mu.Lock()
+		if s := stat(path); s != c.stamp {
+			if err := c.reload(); err != nil {
+				// 记住这次的状态，文件没有再改变时不重复报告
+				c.stamp = s
+				logger.Printf("config: reload %s: %v", path, err)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Changed 返回两份配置中值不同的字段，例如 [server.port log.level]，用于记录重新加载改变了什么
+func Changed[T any](old, new *T) []string {
+	values := func(cfg *T) map[string]any {
+		m := map[string]any{}
+		for _, f := range fieldsOf(reflect.ValueOf(cfg).Elem(), false) {
+			m[f.name()] = f.v.Interface()
+		}
+		return m
+	}
+	a, b := values(old), values(new)
+	var out []string
+	for _, f := range fieldsOf(reflect.ValueOf(new).Elem(), false) {
+		if name := f.name(); !reflect.DeepEqual(a[name], b[name]) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,224 @@
+package jsoninfer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Options 控制生成的 Go 代码
+type Options struct {
+	// Package 非空时在开头输出 package 子句
+	Package string
+	// Name 是顶层类型的名字，默认为 Root
+	Name string
+	// Pointers 为 true 时见过 null 的字段用指针类型，否则 null 会被解码为零值
+	Pointers bool
+}
+
+// Go 生成 s 对应的 Go 类型定义。嵌套的对象生成单独的结构体，名字来自字段名，数组元素的名字去掉复数；
+// 不在每个对象中都出现的字段加上 omitempty；混合了多种类型的值和只见过 null 的值用 any。
+func (s *Schema) Go(opt Options) ([]byte, error) {
+	if opt.Name == "" {
+		opt.Name = "Root"
+	}
+	g := &generator{opt: opt, used: map[string]bool{}}
+	var buf bytes.Buffer
+	if opt.Package != "" {
+		fmt.Fprintf(&buf, "package %s\n\n", opt.Package)
+	}
+	name := exportName(opt.Name)
+	if s.Kinds&^Null == Object {
+		g.object(s, name)
+	} else {
+		// 顶层不是对象时定义一个具名类型，例如 type Root []RootItem
+		name = g.unique(name)
+		t := g.typeOf(s, name)
+		g.decls = append([]string{fmt.Sprintf("type %s %s\n", name, t)}, g.decls...)
+	}
+	buf.WriteString(strings.Join(g.decls, "\n"))
+	return format.Source(buf.Bytes())
+}
+
+type generator struct {
+	opt   Options
+	used  map[string]bool
+	decls []string
+}
+
+// unique 返回一个还没有用过的类型名
+func (g *generator) unique(name string) string {
+	n := name
+	for i := 2; g.used[n]; i++ {
+		n = name + strconv.Itoa(i)
+	}
+	g.used[n] = true
+	return n
+}
+
+// typeOf 返回 s 对应的 Go 类型，对象会生成名字为 hint 的结构体
+func (g *generator) typeOf(s *Schema, hint string) string {
+	if s.Mixed() || s.Kinds&^Null == 0 {
+		return "any"
+	}
+	var t string
+	switch k := s.Kinds &^ Null; {
+	case k == Object:
+		t = g.object(s, hint)
+	case k == Array:
+		if s.Elem == nil {
+			return "[]any"
+		}
+		return "[]" + g.typeOf(s.Elem, singular(hint))
+	case k&Float != 0:
+		t = "float64"
+	case k == Int:
+		t = "int64"
+	case k == String:
+		t = "string"
+	case k == Bool:
+		t = "bool"
+	}
+	if g.opt.Pointers && s.Nullable() {
+		t = "*" + t
+	}
+	return t
+}
+
+func (g *generator) object(s *Schema, hint string) string {
+	name := g.unique(hint)
+	// 先占住 decls 中的位置，保证外层的结构体在内层之前
+	i := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	fields := map[string]bool{}
+	for _, f := range s.Fields {
+		fname := exportName(f.Name)
+		for n := 2; fields[fname]; n++ {
+			fname = exportName(f.Name) + strconv.Itoa(n)
+		}
+		fields[fname] = true
+		t := g.typeOf(f.Schema, exportName(f.Name))
+		tag := f.Name
+		if s.Optional(f) {
+			tag += ",omitempty"
+			// omitempty 对结构体不起作用，可选的对象用指针
+			if f.Schema.Kinds&^Null == Object && !strings.HasPrefix(t, "*") {
+				t = "*" + t
+			}
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%s`\n", fname, t, strconv.Quote(tag))
+	}
+	b.WriteString("}\n")
+	g.decls[i] = b.String()
+	return name
+}
+
+// initialisms 是按 Go 的习惯全部大写的缩写
+var initialisms = map[string]bool{
+	"ID": true, "IP": true, "URL": true, "URI": true, "API": true, "HTTP": true, "HTTPS": true,
+	"JSON": true, "XML": true, "HTML": true, "SQL": true, "UUID": true, "CPU": true, "TTL": true,
+}
+
+// exportName 把 JSON 的键转换成导出的 Go 标识符：server_name、server-name、serverName 都得到 ServerName
+func exportName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if u := strings.ToUpper(w); initialisms[u] {
+			b.WriteString(u)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		// 驼峰中的缩写，例如 serverIp 的 Ip
+		for j := 1; j < len(r)-1; j++ {
+			if unicode.IsUpper(r[j]) {
+				if u := strings.ToUpper(string(r[j:])); initialisms[u] {
+					copy(r[j:], []rune(u))
+				}
+			}
+		}
+		b.WriteString(string(r))
+	}
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// singular 粗略地把复数的名字变成单数，用于数组元素的类型名
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ses"), strings.HasSuffix(name, "xes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name + "Item"
+}
+
+// String 以缩进的文本描述结构，可选的字段后面有 ?
+func (s *Schema) String() string {
+	var b strings.Builder
+	s.describe(&b, 0)
+	return b.String()
+}
+
+func (s *Schema) describe(b *strings.Builder, depth int) {
+	b.WriteString(s.typeName())
+	b.WriteByte('\n')
+	s.children(b, depth+1)
+}
+
+func (s *Schema) typeName() string {
+	k := s.Kinds
+	name := (k &^ (Array | Object)).String()
+	var parts []string
+	if k&^(Array|Object) != 0 {
+		parts = append(parts, name)
+	}
+	if k&Array != 0 {
+		if s.Elem == nil {
+			parts = append(parts, "[]")
+		} else {
+			parts = append(parts, "[]"+s.Elem.typeName())
+		}
+	}
+	if k&Object != 0 {
+		parts = append(parts, "object")
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, "|")
+}
+
+// children 输出对象的字段，数组元素是对象时输出元素的字段
+func (s *Schema) children(b *strings.Builder, depth int) {
+	if s.Kinds&Array != 0 && s.Elem != nil {
+		s.Elem.children(b, depth)
+	}
+	for _, f := range s.Fields {
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString(f.Name)
+		if s.Optional(f) {
+			b.WriteByte('?')
+		}
+		b.WriteString(": ")
+		f.Schema.describe(b, depth)
+	}
+}
@@ -0,0 +1,120 @@
+package jsoninfer
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Infer(t *testing.T) {
+	in := `{"id": 1, "name": "a", "tags": ["x"], "score": 1, "owner": {"user_id": 7}}
+{"id": 2, "name": "b", "tags": [], "score": 2.5, "extra": null}
+{"id": 3, "name": null, "tags": ["y", 1]}`
+	s, err := Read(strings.NewReader(in), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Count != 3 || s.Objects != 3 {
+		t.Fatalf("Count = %d, Objects = %d", s.Count, s.Objects)
+	}
+	cases := []struct {
+		field    string
+		kinds    Kind
+		optional bool
+	}{
+		{"id", Int, false},
+		{"name", String | Null, false},
+		{"tags", Array, false},
+		{"score", Int | Float, true},
+		{"owner", Object, true},
+		{"extra", Null, true},
+	}
+	for _, c := range cases {
+		f := s.Field(c.field)
+		if f == nil {
+			t.Errorf("missing field %s", c.field)
+			continue
+		}
+		if f.Schema.Kinds != c.kinds || s.Optional(f) != c.optional {
+			t.Errorf("%s: %v optional=%v, want %v %v", c.field, f.Schema.Kinds, s.Optional(f), c.kinds, c.optional)
+		}
+	}
+	if tags := s.Field("tags").Schema; tags.Elem == nil || tags.Elem.Kinds != String|Int || !tags.Elem.Mixed() {
+		t.Errorf("tags elem = %+v", tags.Elem)
+	}
+	if s.Field("score").Schema.Mixed() {
+		t.Error("int|float should not be mixed")
+	}
+}
+
+func Test_ReadEach(t *testing.T) {
+	s, err := Read(strings.NewReader(`[{"a": 1}, {"b": true}]`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Objects != 2 || len(s.Fields) != 2 || !s.Optional(s.Fields[0]) {
+		t.Errorf("got %s", s)
+	}
+	if _, err := Read(strings.NewReader(" "), false); err == nil {
+		t.Error("empty input succeeded")
+	}
+	if _, err := Read(strings.NewReader(`{"a": `), false); err == nil {
+		t.Error("bad JSON succeeded")
+	}
+}
+
+func Test_Go(t *testing.T) {
+	in := `{"servers": [
+		{"serverName": "Shanghai_VPN", "server_ip": "127.0.0.1", "port": 80, "meta": {"api-url": "x"}},
+		{"serverName": "Beijing_VPN", "server_ip": "127.0.0.2", "port": 8080.5, "note": null}
+	], "values": [1, "a"], "empty": [], "2fa": true}`
+	s, err := Read(strings.NewReader(in), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := s.Go(Options{Package: "model", Name: "config", Pointers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package model\n\n" + "type Config struct {\n" +
+		"\tX2fa    bool     `json:\"2fa\"`\n" +
+		"\tEmpty   []any    `json:\"empty\"`\n" +
+		"\tServers []Server `json:\"servers\"`\n" +
+		"\tValues  []any    `json:\"values\"`\n" +
+		"}\n\n" + "type Server struct {\n" +
+		"\tMeta       *Meta   `json:\"meta,omitempty\"`\n" +
+		"\tPort       float64 `json:\"port\"`\n" +
+		"\tServerName string  `json:\"serverName\"`\n" +
+		"\tServerIP   string  `json:\"server_ip\"`\n" +
+		"\tNote       any     `json:\"note,omitempty\"`\n" +
+		"}\n\n" + "type Meta struct {\n" +
+		"\tAPIURL string `json:\"api-url\"`\n" +
+		"}\n"
+	if string(code) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", code, want)
+	}
+
+	code, err = Infer([]any{"a", nil}).Go(Options{Pointers: true})
+	if err != nil || string(code) != "type Root []*string\n" {
+		t.Errorf("array root = %q, %v", code, err)
+	}
+}
+
+func Test_ExportName(t *testing.T) {
+	cases := map[string]string{
+		"server_name": "ServerName",
+		"serverIp":    "ServerIP",
+		"user-id":     "UserID",
+		"url":         "URL",
+		"":            "Field",
+		"1st":         "X1st",
+		"já":          "Já",
+	}
+	for in, want := range cases {
+		if got := exportName(in); got != want {
+			t.Errorf("exportName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if singular("Servers") != "Server" || singular("Entries") != "Entry" || singular("Data") != "DataItem" {
+		t.Error("singular")
+	}
+}
@@ -0,0 +1,187 @@
+// Package jsoninfer 从 JSON 样本推断结构：每个值可能的类型、对象中哪些字段是可选的、数组元素的类型，
+// 并生成带 json 标签的 Go 结构体定义。
+//
+// 多个样本可以合并到同一个 Schema：某个字段只在一部分对象中出现时它是可选的，
+// 数组中不同的元素也会合并，所以对象数组得到的是所有元素字段的并集。
+package jsoninfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Kind 是 JSON 值的类型，Schema 中用位集合记录见过的所有类型
+type Kind uint8
+
+const (
+	Null Kind = 1 << iota
+	Bool
+	Int
+	Float
+	String
+	Array
+	Object
+)
+
+var kindNames = []string{"null", "bool", "int", "float", "string", "array", "object"}
+
+func (k Kind) String() string {
+	var names []string
+	for i, name := range kindNames {
+		if k&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "unknown"
+	}
+	return strings.Join(names, "|")
+}
+
+// Schema 是合并了若干个样本的值的结构
+type Schema struct {
+	// Kinds 是见过的所有类型
+	Kinds Kind
+	// Count 是合并的样本数
+	Count int
+	// Objects 是其中对象的个数
+	Objects int
+	// Fields 是对象的字段，按第一次出现的顺序
+	Fields []*Field
+	// Elem 是数组元素合并后的结构，只有空数组时为 nil
+	Elem *Schema
+}
+
+// Field 是对象的一个字段
+type Field struct {
+	Name   string
+	Schema *Schema
+	// Count 是包含这个字段的对象数
+	Count int
+}
+
+// Infer 推断一个值的结构，v 是 json.Unmarshal 到 any 得到的值
+func Infer(v any) *Schema {
+	s := &Schema{}
+	s.Add(v)
+	return s
+}
+
+// Add 把一个样本合并到 s 中。数字是 json.Number 时可以区分整数和小数，float64 只有在有小数部分时算作小数。
+func (s *Schema) Add(v any) {
+	s.Count++
+	switch x := v.(type) {
+	case nil:
+		s.Kinds |= Null
+	case bool:
+		s.Kinds |= Bool
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			s.Kinds |= Int
+		} else {
+			s.Kinds |= Float
+		}
+	case float64:
+		if x == float64(int64(x)) {
+			s.Kinds |= Int
+		} else {
+			s.Kinds |= Float
+		}
+	case string:
+		s.Kinds |= String
+	case []any:
+		s.Kinds |= Array
+		for _, e := range x {
+			if s.Elem == nil {
+				s.Elem = &Schema{}
+			}
+			s.Elem.Add(e)
+		}
+	case map[string]any:
+		s.Kinds |= Object
+		s.Objects++
+		// map 的遍历是无序的，新字段按键排序后追加，保证结果稳定
+		for _, k := range sortedKeys(x) {
+			f := s.Field(k)
+			if f == nil {
+				f = &Field{Name: k, Schema: &Schema{}}
+				s.Fields = append(s.Fields, f)
+			}
+			f.Count++
+			f.Schema.Add(x[k])
+		}
+	default:
+		panic(fmt.Sprintf("jsoninfer: unsupported type %T", v))
+	}
+}
+
+// Field 返回名字为 name 的字段
+func (s *Schema) Field(name string) *Field {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Optional 表示字段 f 不是在每个对象中都出现
+func (s *Schema) Optional(f *Field) bool {
+	return f.Count < s.Objects
+}
+
+// Nullable 表示见过 null
+func (s *Schema) Nullable() bool {
+	return s.Kinds&Null != 0
+}
+
+// Mixed 表示除了 null 以外见过不止一种类型，整数和小数算作同一种
+func (s *Schema) Mixed() bool {
+	k := s.Kinds &^ Null
+	if k&Float != 0 {
+		k &^= Int
+	}
+	return k&(k-1) != 0
+}
+
+// Read 从 r 中读取一个或多个 JSON 值（例如 NDJSON）并合并为一个 Schema。
+// each 为 true 时顶层的数组不作为一个样本，而是把其中每个元素作为一个样本。
+func Read(r io.Reader, each bool) (*Schema, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	s := &Schema{}
+	for {
+		var v any
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if a, ok := v.([]any); ok && each {
+			for _, e := range a {
+				s.Add(e)
+			}
+			continue
+		}
+		s.Add(v)
+	}
+	if s.Count == 0 {
+		return nil, errors.New("jsoninfer: no JSON values in input")
+	}
+	return s, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,325 @@
+package jsonpath
+
+import (
+	"slices"
+	"sort"
+)
+
+// match 是路径选中的一个值，以及它在容器中的位置，用于 Set 和 Delete
+type match struct {
+	val any
+	// set 替换这个值
+	set func(any)
+	// parent 是包含它的 map[string]any 或 []any，根节点为 nil
+	parent any
+	key    string
+	index  int
+	// depth 是在文档中的层数，根节点为 0
+	depth int
+	// setParent 替换 parent，删除数组元素后数组变短，需要放回上一层
+	setParent func(any)
+}
+
+// eval 依次应用每一段
+func eval(start, root any, segs []segment) []match {
+	cur := []match{{val: start, set: func(any) {}}}
+	return evalFrom(cur, root, segs, nil)
+}
+
+// changes 记录求值时对文档的修改，用来在没有选中任何位置时撤销
+type changes []func()
+
+func (c *changes) add(undo func()) {
+	*c = append(*c, undo)
+}
+
+// revert 按相反的顺序撤销所有修改
+func (c changes) revert() {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i]()
+	}
+}
+
+// evalFrom 从 cur 开始依次应用每一段。create 不为 nil 时创建不存在的键，为数组末尾的下一个下标追加元素，
+// 并把路径上的 null 变成对象或数组，这些修改记录在 create 中
+func evalFrom(cur []match, root any, segs []segment, create *changes) []match {
+	for _, seg := range segs {
+		var next []match
+		emit := func(m match) { next = append(next, m) }
+		for _, m := range cur {
+			if seg.descendant {
+				descend(m, func(d match) {
+					for _, sel := range seg.sels {
+						apply(d, sel, root, nil, emit)
+					}
+				})
+				continue
+			}
+			for _, sel := range seg.sels {
+				apply(m, sel, root, create, emit)
+			}
+		}
+		cur = next
+	}
+	return cur
+}
+
+// descend 先序遍历 m 和它所有的后代
+func descend(m match, f func(match)) {
+	f(m)
+	children(m, func(c match) bool {
+		descend(c, f)
+		return true
+	})
+}
+
+// children 按顺序遍历对象（按键排序）或数组的元素
+func children(m match, f func(match) bool) {
+	switch x := m.val.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !f(mapChild(m, x, k)) {
+				return
+			}
+		}
+	case []any:
+		for i := range x {
+			if !f(sliceChild(m, x, i)) {
+				return
+			}
+		}
+	}
+}
+
+func mapChild(m match, x map[string]any, k string) match {
+	return match{val: x[k], set: func(v any) { x[k] = v }, parent: x, key: k, depth: m.depth + 1, setParent: m.set}
+}
+
+func sliceChild(m match, x []any, i int) match {
+	return match{val: x[i], set: func(v any) { x[i] = v }, parent: x, index: i, depth: m.depth + 1, setParent: m.set}
+}
+
+func apply(m match, sel selector, root any, create *changes, emit func(match)) {
+	switch sel.kind {
+	case selName:
+		x, ok := m.val.(map[string]any)
+		if !ok && create != nil && m.val == nil {
+			x, ok = map[string]any{}, true
+			m.set(x)
+			create.add(func() { m.set(nil) })
+			m.val = x
+		}
+		if !ok {
+			return
+		}
+		if _, has := x[sel.name]; !has {
+			if create == nil {
+				return
+			}
+			// 先放一个 null，下一段会把它变成对象或数组，最后一段会用 Set 的值替换它
+			x[sel.name] = nil
+			create.add(func() { delete(x, sel.name) })
+		}
+		emit(mapChild(m, x, sel.name))
+	case selIndex:
+		x, ok := m.val.([]any)
+		if !ok && create != nil && m.val == nil {
+			x, ok = []any{}, true
+			m.set(x)
+			create.add(func() { m.set(nil) })
+		}
+		if !ok {
+			return
+		}
+		i := sel.index
+		if i < 0 {
+			i += len(x)
+		}
+		if create != nil && i == len(x) {
+			old := x
+			x = append(x, nil)
+			m.set(x)
+			create.add(func() { m.set(old) })
+		}
+		if i >= 0 && i < len(x) {
+			m.val = x
+			emit(sliceChild(m, x, i))
+		}
+	case selSlice:
+		x, ok := m.val.([]any)
+		if !ok {
+			return
+		}
+		for _, i := range sliceIndexes(len(x), sel) {
+			emit(sliceChild(m, x, i))
+		}
+	case selWildcard:
+		children(m, func(c match) bool {
+			emit(c)
+			return true
+		})
+	case selFilter:
+		children(m, func(c match) bool {
+			if sel.filter.test(c.val, root) {
+				emit(c)
+			}
+			return true
+		})
+	}
+}
+
+// sliceIndexes 返回 [start:end:step] 选中的下标，规则和 Python 相同
+func sliceIndexes(n int, sel selector) []int {
+	step := 1
+	if sel.step != nil {
+		step = *sel.step
+	}
+	norm := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		if step > 0 {
+			return min(max(i, 0), n)
+		}
+		return min(max(i, -1), n-1)
+	}
+	var idx []int
+	if step > 0 {
+		for i := norm(sel.start, 0); i < norm(sel.end, n); i += step {
+			idx = append(idx, i)
+		}
+	} else {
+		for i := norm(sel.start, n-1); i > norm(sel.end, -1); i += step {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// Query 返回路径选中的所有值，顺序是文档中的顺序，对象的键按字典序
+func (v *Value) Query(path string) ([]*Value, error) {
+	p, err := Compile(path)
+	if err != nil {
+		return nil, err
+	}
+	return v.QueryPath(p), nil
+}
+
+// QueryPath 和 Query 相同，使用编译好的路径
+func (v *Value) QueryPath(p *Path) []*Value {
+	if !v.exists {
+		return nil
+	}
+	ms := eval(v.v, v.v, p.segs)
+	out := make([]*Value, len(ms))
+	for i, m := range ms {
+		out[i] = New(m.val)
+	}
+	return out
+}
+
+// First 返回路径选中的第一个值，没有时返回不存在的 Value，路径有语法错误时也一样
+func (v *Value) First(path string) *Value {
+	vs, err := v.Query(path)
+	if err != nil || len(vs) == 0 {
+		return missing
+	}
+	return vs[0]
+}
+
+// Set 把路径选中的每个位置设置为 x，x 可以是 *Value。
+// 路径中不存在的键会被创建为对象，数组末尾的下一个下标会追加元素，
+// 所以 Set("$.a.b[0]", 1) 在空对象上得到 {"a":{"b":[1]}}。没有选中任何位置时返回 ErrNotFound，文档不变。
+func (v *Value) Set(path string, x any) error {
+	p, err := Compile(path)
+	if err != nil {
+		return err
+	}
+	if xv, ok := x.(*Value); ok {
+		x = xv.v
+	}
+	exists := v.exists
+	if !v.exists {
+		v.v, v.exists = nil, true
+	}
+	root := match{val: v.v, set: func(nv any) { v.v = nv }}
+	var created changes
+	ms := evalFrom([]match{root}, v.v, p.segs, &created)
+	if len(ms) == 0 {
+		created.revert()
+		v.exists = exists
+		return ErrNotFound
+	}
+	for _, m := range ms {
+		m.set(x)
+	}
+	return nil
+}
+
+// Delete 删除路径选中的值，返回删除的个数。删除数组元素后后面的元素前移，删除根节点得到 null。
+func (v *Value) Delete(path string) (int, error) {
+	p, err := Compile(path)
+	if err != nil {
+		return 0, err
+	}
+	if !v.exists {
+		return 0, nil
+	}
+	root := match{val: v.v, set: func(nv any) { v.v = nv }}
+	ms := evalFrom([]match{root}, v.v, p.segs, nil)
+
+	// 同一个数组中的元素要一起删除，否则前面的删除会改变后面的下标
+	type array struct {
+		x     []any
+		set   func(any)
+		depth int
+		index []int
+	}
+	var arrays []*array
+	n := 0
+	for _, m := range ms {
+		switch x := m.parent.(type) {
+		case nil:
+			v.v = nil
+			n++
+		case map[string]any:
+			if _, ok := x[m.key]; ok {
+				delete(x, m.key)
+				n++
+			}
+		case []any:
+			i := slices.IndexFunc(arrays, func(a *array) bool { return &a.x[0] == &x[0] })
+			if i < 0 {
+				arrays = append(arrays, &array{x: x, set: m.setParent, depth: m.depth})
+				i = len(arrays) - 1
+			}
+			if !slices.Contains(arrays[i].index, m.index) {
+				arrays[i].index = append(arrays[i].index, m.index)
+			}
+		}
+	}
+	// 先删除内层数组中的元素：内层数组变短后通过 setParent 放回外层数组原来的下标，
+	// 外层数组如果已经移动过元素，这个下标就不对了
+	slices.SortStableFunc(arrays, func(a, b *array) int { return b.depth - a.depth })
+	for _, a := range arrays {
+		kept := a.x[:0]
+		for i, e := range a.x {
+			if !slices.Contains(a.index, i) {
+				kept = append(kept, e)
+			}
+		}
+		clear(a.x[len(kept):])
+		a.set(kept)
+		n += len(a.index)
+	}
+	return n, nil
+}
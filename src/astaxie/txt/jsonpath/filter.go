@@ -0,0 +1,242 @@
+package jsonpath
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// cond 是过滤表达式中的条件，cur 是 @ 指向的值
+type cond interface {
+	test(cur, root any) bool
+}
+
+// operand 是比较的一边，ok 为 false 表示没有值（路径没有选中恰好一个值）
+type operand interface {
+	value(cur, root any) (v any, ok bool)
+}
+
+// pathOperand 是 @... 或 $...，作为条件时表示选中了至少一个值
+type pathOperand struct {
+	relative bool
+	segs     []segment
+}
+
+func (o *pathOperand) nodes(cur, root any) []match {
+	start := root
+	if o.relative {
+		start = cur
+	}
+	return eval(start, root, o.segs)
+}
+
+func (o *pathOperand) value(cur, root any) (any, bool) {
+	ms := o.nodes(cur, root)
+	if len(ms) != 1 {
+		return nil, false
+	}
+	return ms[0].val, true
+}
+
+func (o *pathOperand) test(cur, root any) bool {
+	return len(o.nodes(cur, root)) > 0
+}
+
+type literal struct{ v any }
+
+func (l literal) value(any, any) (any, bool) { return l.v, true }
+
+func (l literal) test(any, any) bool { return l.v == true }
+
+type compare struct {
+	op   string
+	l, r operand
+}
+
+func (c *compare) test(cur, root any) bool {
+	l, lok := c.l.value(cur, root)
+	r, rok := c.r.value(cur, root)
+	switch c.op {
+	case "==":
+		return equal(l, lok, r, rok)
+	case "!=":
+		return !equal(l, lok, r, rok)
+	}
+	if !lok || !rok {
+		return false
+	}
+	var n int
+	if lf, ok := toFloat(l); ok {
+		rf, ok := toFloat(r)
+		if !ok {
+			return false
+		}
+		switch {
+		case lf < rf:
+			n = -1
+		case lf > rf:
+			n = 1
+		}
+	} else if ls, ok := l.(string); ok {
+		rs, ok := r.(string)
+		if !ok {
+			return false
+		}
+		n = strings.Compare(ls, rs)
+	} else {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	default:
+		return n >= 0
+	}
+}
+
+// equal 比较两个值，数字按数值比较，两边都没有值时相等
+func equal(l any, lok bool, r any, rok bool) bool {
+	if !lok || !rok {
+		return lok == rok
+	}
+	if lf, ok := toFloat(l); ok {
+		rf, ok := toFloat(r)
+		return ok && lf == rf
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+type and struct{ l, r cond }
+
+func (c and) test(cur, root any) bool { return c.l.test(cur, root) && c.r.test(cur, root) }
+
+type or struct{ l, r cond }
+
+func (c or) test(cur, root any) bool { return c.l.test(cur, root) || c.r.test(cur, root) }
+
+type not struct{ c cond }
+
+func (c not) test(cur, root any) bool { return !c.c.test(cur, root) }
+
+// 过滤表达式的语法，优先级从低到高：
+//
+//	or    = and { "||" and }
+//	and   = unary { "&&" unary }
+//	unary = "!" unary | "(" or ")" | operand [ op operand ]
+
+func (p *parser) orExpr() (cond, error) {
+	l, err := p.andExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); strings.HasPrefix(p.s[p.pos:], "||"); p.skipSpace() {
+		p.pos += 2
+		r, err := p.andExpr()
+		if err != nil {
+			return nil, err
+		}
+		l = or{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) andExpr() (cond, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); strings.HasPrefix(p.s[p.pos:], "&&"); p.skipSpace() {
+		p.pos += 2
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = and{l, r}
+	}
+	return l, nil
+}
+
+var compareOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *parser) unary() (cond, error) {
+	p.skipSpace()
+	switch p.peek() {
+	case '!':
+		if !strings.HasPrefix(p.s[p.pos:], "!=") {
+			p.pos++
+			c, err := p.unary()
+			return not{c}, err
+		}
+	case '(':
+		p.pos++
+		c, err := p.orExpr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return c, nil
+	}
+	l, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range compareOps {
+		if strings.HasPrefix(p.s[p.pos:], op) {
+			p.pos += len(op)
+			p.skipSpace()
+			r, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return &compare{op, l, r}, nil
+		}
+	}
+	c, ok := l.(cond)
+	if !ok {
+		return nil, p.errorf("expected comparison")
+	}
+	return c, nil
+}
+
+func (p *parser) operand() (operand, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segs, err := p.segments(nil)
+		if err != nil {
+			return nil, err
+		}
+		return &pathOperand{relative: c == '@', segs: segs}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		return literal{s}, err
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.s) && strings.IndexByte("0123456789.eE+-", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("bad number")
+		}
+		return literal{f}, nil
+	}
+	for word, v := range map[string]any{"true": true, "false": false, "null": nil} {
+		if strings.HasPrefix(p.s[p.pos:], word) {
+			p.pos += len(word)
+			return literal{v}, nil
+		}
+	}
+	return nil, p.errorf("unexpected %q in filter", p.peek())
+}
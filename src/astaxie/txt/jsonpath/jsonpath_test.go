@@ -0,0 +1,179 @@
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+)
+
+const store = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95},
+		"odd key": 1
+	}
+}`
+
+func mustParse(t *testing.T, s string) *Value {
+	t.Helper()
+	v, err := Parse([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func Test_Query(t *testing.T) {
+	doc := mustParse(t, store)
+	cases := []struct {
+		path, want string
+	}{
+		{"$.store.book[0].title", `["Sayings of the Century"]`},
+		{"store.book[-1].author", `["J. R. R. Tolkien"]`},
+		{"$['store']['odd key']", `[1]`},
+		{"$.store.book[*].author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"$..author", `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{"$.store.bicycle.*", `["red",19.95]`},
+		{"$.store['odd key', 'bicycle'].color", `["red"]`},
+		{"$..book[0,2].price", `[8.95,8.99]`},
+		{"$.store.book[1:3].price", `[12.99,8.99]`},
+		{"$.store.book[::-2].price", `[22.99,12.99]`},
+		{"$.store.book[:-3].price", `[8.95]`},
+		{"$.store.book[?(@.isbn)].title", `["Moby Dick","The Lord of the Rings"]`},
+		{"$.store.book[?(!@.isbn)].price", `[8.95,12.99]`},
+		{"$.store.book[?(@.price < 10)].title", `["Sayings of the Century","Moby Dick"]`},
+		{"$.store.book[?(@.category == 'fiction' && (@.price > 20 || @.price < 9))].title", `["Moby Dick","The Lord of the Rings"]`},
+		{"$.store.book[?(@.price > $.store.bicycle.price)].price", `[22.99]`},
+		{"$..[?(@.color == \"red\")].price", `[19.95]`},
+		{"$.store.book[10]", `null`},
+		{"$.nothing.here", `null`},
+	}
+	for _, c := range cases {
+		vs, err := doc.Query(c.path)
+		if err != nil {
+			t.Errorf("Query(%s): %v", c.path, err)
+			continue
+		}
+		if got := New(toAny(vs)).String(); got != c.want {
+			t.Errorf("Query(%s) = %s, want %s", c.path, got, c.want)
+		}
+	}
+}
+
+func toAny(vs []*Value) any {
+	if len(vs) == 0 {
+		return nil
+	}
+	out := make([]any, len(vs))
+	for i, v := range vs {
+		out[i] = v.Interface()
+	}
+	return out
+}
+
+func Test_SyntaxError(t *testing.T) {
+	for _, path := range []string{"$.", "$[", "$['a", "$[1:2:0]", "$[?(@.a <)]", "$[?(@.a == 1]", "$.a b"} {
+		_, err := Compile(path)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Compile(%q) = %v, want SyntaxError", path, err)
+		}
+	}
+}
+
+func Test_Getters(t *testing.T) {
+	doc := mustParse(t, `{"i": 10, "big": 9223372036854775807, "f": 1.5, "s": "x", "b": true, "a": [1, 2], "n": null}`)
+	if i, err := doc.Get("big").Int(); err != nil || i != 9223372036854775807 {
+		t.Errorf("big = %d, %v", i, err)
+	}
+	if _, err := doc.Get("f").Int(); err == nil {
+		t.Error("Int of 1.5 succeeded")
+	}
+	if f, _ := doc.Get("i").Float(); f != 10 {
+		t.Errorf("Float = %v", f)
+	}
+	if _, err := doc.Get("missing").Get("deeper").Str(); err != ErrNotFound {
+		t.Errorf("missing Str err = %v", err)
+	}
+	if _, err := doc.Get("s").Int(); err == nil || err == ErrNotFound {
+		t.Errorf("type error = %v", err)
+	}
+	if doc.Get("s").StrOr("d") != "x" || doc.Get("n").StrOr("d") != "d" || doc.Get("x").IntOr(7) != 7 ||
+		!doc.Get("b").BoolOr(false) || doc.Get("a").Index(-1).FloatOr(0) != 2 {
+		t.Error("defaults")
+	}
+	if !doc.Get("n").Exists() || doc.Get("a").Index(2).Exists() {
+		t.Error("Exists")
+	}
+}
+
+func Test_Set(t *testing.T) {
+	doc := mustParse(t, `{"a": [1, 2], "b": {"c": 1}, "n": null}`)
+	steps := []struct {
+		path string
+		v    any
+	}{
+		{"$.b.c", 2},
+		{"$.x.y[0].z", "new"},
+		{"$.a[2]", 3},
+		{"$.a[-1]", 4},
+		{"$.n.k", true},
+		{"$.a[?(@ == 1)]", 0},
+	}
+	for _, s := range steps {
+		if err := doc.Set(s.path, s.v); err != nil {
+			t.Fatalf("Set(%s): %v", s.path, err)
+		}
+	}
+	want := `{"a":[0,2,4],"b":{"c":2},"n":{"k":true},"x":{"y":[{"z":"new"}]}}`
+	if got := doc.String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if err := doc.Set("$.a[5]", 1); err != ErrNotFound {
+		t.Errorf("Set past end = %v", err)
+	}
+	if err := doc.Set("$.b.c.d", 1); err != ErrNotFound {
+		t.Errorf("Set below number = %v", err)
+	}
+	// 没有选中任何位置时不留下创建了一半的路径
+	if err := doc.Set("$.p.q[3]", 1); err != ErrNotFound || doc.String() != want {
+		t.Errorf("failed Set = %v, doc %s", err, doc)
+	}
+	empty := New(nil)
+	if err := empty.Set("$.x[2].y", 1); err != ErrNotFound || empty.String() != "null" {
+		t.Errorf("failed Set on null = %v, doc %s", err, empty)
+	}
+	if err := doc.Set("$", mustParse(t, `[1]`)); err != nil || doc.String() != "[1]" {
+		t.Errorf("Set root = %s, %v", doc, err)
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	doc := mustParse(t, store)
+	n, err := doc.Delete("$.store.book[?(@.price < 10)]")
+	if err != nil || n != 2 {
+		t.Fatalf("Delete = %d, %v", n, err)
+	}
+	if got := New(toAny(doc.QueryPath(MustCompile("$..title")))).String(); got != `["Sword of Honour","The Lord of the Rings"]` {
+		t.Errorf("after delete: %s", got)
+	}
+	if n, _ := doc.Delete("$..price"); n != 3 {
+		t.Errorf("Delete($..price) = %d", n)
+	}
+	if n, _ := doc.Delete("$.store.book[0:1]"); n != 1 || doc.First("$.store.book[0].title").StrOr("") != "The Lord of the Rings" {
+		t.Errorf("Delete slice = %d, %s", n, doc)
+	}
+	if n, _ := doc.Delete("$.nothing"); n != 0 {
+		t.Errorf("Delete missing = %d", n)
+	}
+
+	// 内层数组先删除，放回外层数组时下标还没有改变
+	nested := mustParse(t, `[[1, 2], [3, 4]]`)
+	if n, _ := nested.Delete("$..[0]"); n != 3 || nested.String() != "[[4]]" {
+		t.Errorf("Delete nested = %d, %s", n, nested)
+	}
+}
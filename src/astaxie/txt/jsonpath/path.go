@@ -0,0 +1,283 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type selKind int
+
+const (
+	selName selKind = iota
+	selIndex
+	selSlice
+	selWildcard
+	selFilter
+)
+
+// selector 是方括号中的一项，或者 .name、.*
+type selector struct {
+	kind             selKind
+	name             string
+	index            int
+	start, end, step *int
+	filter           cond
+}
+
+// segment 是路径的一段，descendant 表示 ..，会作用在所有后代上
+type segment struct {
+	descendant bool
+	sels       []selector
+}
+
+// Path 是编译好的路径，可以重复使用
+type Path struct {
+	src  string
+	segs []segment
+}
+
+// SyntaxError 是路径的语法错误，Offset 是出错的字节位置
+type SyntaxError struct {
+	Path   string
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("jsonpath: %s at offset %d in %q", e.Msg, e.Offset, e.Path)
+}
+
+// Compile 编译路径
+func Compile(path string) (*Path, error) {
+	p := &parser{s: path}
+	p.skipSpace()
+	if p.peek() == '$' {
+		p.pos++
+	} else if p.pos < len(p.s) && p.peek() != '.' && p.peek() != '[' {
+		// 允许省略开头的 $.，例如 store.book[0]
+		name := p.name()
+		if name == "" {
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+		p.segs = append(p.segs, segment{sels: []selector{{kind: selName, name: name}}})
+	}
+	segs, err := p.segments(p.segs)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return &Path{src: path, segs: segs}, nil
+}
+
+// MustCompile 和 Compile 相同，出错时 panic
+func MustCompile(path string) *Path {
+	p, err := Compile(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *Path) String() string {
+	return p.src
+}
+
+type parser struct {
+	s    string
+	pos  int
+	segs []segment
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Path: p.s, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// name 读取 . 后面不带引号的键
+func (p *parser) name() string {
+	start := p.pos
+	for p.pos < len(p.s) && isNameByte(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// segments 读取连续的 .name、..name、[...]，遇到其他字符时停止
+func (p *parser) segments(segs []segment) ([]segment, error) {
+	for {
+		switch p.peek() {
+		case '.':
+			p.pos++
+			desc := false
+			if p.peek() == '.' {
+				p.pos++
+				desc = true
+			}
+			switch {
+			case p.peek() == '*':
+				p.pos++
+				segs = append(segs, segment{desc, []selector{{kind: selWildcard}}})
+			case desc && p.peek() == '[':
+				sels, err := p.bracket()
+				if err != nil {
+					return nil, err
+				}
+				segs = append(segs, segment{true, sels})
+			default:
+				name := p.name()
+				if name == "" {
+					return nil, p.errorf("expected name after '.'")
+				}
+				segs = append(segs, segment{desc, []selector{{kind: selName, name: name}}})
+			}
+		case '[':
+			sels, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, segment{sels: sels})
+		default:
+			return segs, nil
+		}
+	}
+}
+
+// bracket 读取 [...]，其中是用逗号分隔的键、下标、切片，或者 * 和 ?过滤表达式
+func (p *parser) bracket() ([]selector, error) {
+	p.pos++ // [
+	var sels []selector
+	for {
+		p.skipSpace()
+		sel, err := p.selector()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return sels, nil
+		default:
+			return nil, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *parser) selector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		return selector{kind: selWildcard}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		return selector{kind: selName, name: s}, err
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		f, err := p.orExpr()
+		return selector{kind: selFilter, filter: f}, err
+	case c == '-' || c == ':' || c >= '0' && c <= '9':
+		return p.indexOrSlice()
+	}
+	return selector{}, p.errorf("unexpected %q in brackets", p.peek())
+}
+
+func (p *parser) quoted() (string, error) {
+	q := p.s[p.pos]
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == q:
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			switch e := p.s[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) int() (*int, error) {
+	p.skipSpace()
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("bad integer")
+	}
+	return &n, nil
+}
+
+// indexOrSlice 读取 n 或 start:end:step，切片的每一部分都可以省略
+func (p *parser) indexOrSlice() (selector, error) {
+	var parts [3]*int
+	for i := range parts {
+		n, err := p.int()
+		if err != nil {
+			return selector{}, err
+		}
+		parts[i] = n
+		p.skipSpace()
+		if p.peek() != ':' || i == 2 {
+			if i == 0 {
+				if n == nil {
+					return selector{}, p.errorf("expected index")
+				}
+				return selector{kind: selIndex, index: *n}, nil
+			}
+			break
+		}
+		p.pos++
+	}
+	if parts[2] != nil && *parts[2] == 0 {
+		return selector{}, p.errorf("slice step cannot be zero")
+	}
+	return selector{kind: selSlice, start: parts[0], end: parts[1], step: parts[2]}, nil
+}
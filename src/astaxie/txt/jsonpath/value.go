@@ -0,0 +1,242 @@
+// Package jsonpath 是一个动态的 JSON 值，用 JSONPath 风格的路径查询和修改，用来代替 go-simplejson。
+//
+// 路径的语法：
+//
+//	$.store.book[0].title     对象的键和数组的下标，$ 可以省略
+//	$['key with space']       用引号写的键，[0, 2] 和 ['a', 'b'] 可以选择多个
+//	$.book[-1]  $.book[1:3]   负数下标从末尾数起，切片和 Python 相同
+//	$.book[*]  $.*            所有元素或所有值
+//	$..author                 任意深度的 author
+//	$.book[?(@.price < 10 && @.isbn)]   过滤：@ 是当前元素，支持 == != < <= > >= && || ! 和括号
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrNotFound 表示值不存在
+var ErrNotFound = errors.New("jsonpath: value not found")
+
+// Value 是一个 JSON 值：map[string]any、[]any、json.Number、string、bool 或 nil。
+// 不存在的值（例如 Get 一个没有的键）也是一个 Value，它的所有取值方法都返回 ErrNotFound，
+// 所以可以像 simplejson 一样链式调用而不用每一步都检查。
+type Value struct {
+	v      any
+	exists bool
+}
+
+// New 用 Go 的值创建 Value，v 应该是 json.Unmarshal 到 any 时会得到的类型
+func New(v any) *Value {
+	return &Value{v: v, exists: true}
+}
+
+var missing = &Value{}
+
+// Parse 解析 JSON 文本，数字保存为 json.Number，不会丢失大整数的精度
+func Parse(data []byte) (*Value, error) {
+	return Decode(bytes.NewReader(data))
+}
+
+// Decode 从 r 中读取一个 JSON 值
+func Decode(r io.Reader) (*Value, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return New(v), nil
+}
+
+// Exists 表示值是否存在
+func (v *Value) Exists() bool {
+	return v.exists
+}
+
+// Interface 返回底层的 Go 值，不存在时返回 nil
+func (v *Value) Interface() any {
+	return v.v
+}
+
+func (v *Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.v)
+}
+
+func (v *Value) UnmarshalJSON(b []byte) error {
+	nv, err := Parse(b)
+	if err != nil {
+		return err
+	}
+	*v = *nv
+	return nil
+}
+
+func (v *Value) String() string {
+	if !v.exists {
+		return "<missing>"
+	}
+	b, err := json.Marshal(v.v)
+	if err != nil {
+		return fmt.Sprint(v.v)
+	}
+	return string(b)
+}
+
+// Get 返回对象中键 key 的值，v 不是对象或没有这个键时返回不存在的 Value
+func (v *Value) Get(key string) *Value {
+	if m, ok := v.v.(map[string]any); ok {
+		if x, ok := m[key]; ok {
+			return New(x)
+		}
+	}
+	return missing
+}
+
+// Index 返回数组的第 i 个元素，负数从末尾数起
+func (v *Value) Index(i int) *Value {
+	if a, ok := v.v.([]any); ok {
+		if i < 0 {
+			i += len(a)
+		}
+		if i >= 0 && i < len(a) {
+			return New(a[i])
+		}
+	}
+	return missing
+}
+
+func (v *Value) typeError(want string) error {
+	if !v.exists {
+		return ErrNotFound
+	}
+	return fmt.Errorf("jsonpath: %s is %s, not %s", v, typeName(v.v), want)
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case json.Number, float64, int, int64:
+		return "a number"
+	case string:
+		return "a string"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toFloat 把各种数字类型转换成 float64
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// Str 返回字符串，String 方法返回的是 JSON 文本
+func (v *Value) Str() (string, error) {
+	if s, ok := v.v.(string); ok {
+		return s, nil
+	}
+	return "", v.typeError("a string")
+}
+
+// Int 返回整数，带小数的数字会出错
+func (v *Value) Int() (int64, error) {
+	switch n := v.v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	}
+	if f, ok := toFloat(v.v); ok && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f), nil
+	}
+	return 0, v.typeError("an integer")
+}
+
+// Float 返回数字
+func (v *Value) Float() (float64, error) {
+	if f, ok := toFloat(v.v); ok {
+		return f, nil
+	}
+	return 0, v.typeError("a number")
+}
+
+// Bool 返回布尔值
+func (v *Value) Bool() (bool, error) {
+	if b, ok := v.v.(bool); ok {
+		return b, nil
+	}
+	return false, v.typeError("a boolean")
+}
+
+// Array 返回数组
+func (v *Value) Array() ([]any, error) {
+	if a, ok := v.v.([]any); ok {
+		return a, nil
+	}
+	return nil, v.typeError("an array")
+}
+
+// Map 返回对象
+func (v *Value) Map() (map[string]any, error) {
+	if m, ok := v.v.(map[string]any); ok {
+		return m, nil
+	}
+	return nil, v.typeError("an object")
+}
+
+// StrOr 返回字符串，不存在或类型不对时返回 def
+func (v *Value) StrOr(def string) string {
+	if s, err := v.Str(); err == nil {
+		return s
+	}
+	return def
+}
+
+// IntOr 返回整数，不存在或类型不对时返回 def
+func (v *Value) IntOr(def int64) int64 {
+	if i, err := v.Int(); err == nil {
+		return i
+	}
+	return def
+}
+
+// FloatOr 返回数字，不存在或类型不对时返回 def
+func (v *Value) FloatOr(def float64) float64 {
+	if f, err := v.Float(); err == nil {
+		return f
+	}
+	return def
+}
+
+// BoolOr 返回布尔值，不存在或类型不对时返回 def
+func (v *Value) BoolOr(def bool) bool {
+	if b, err := v.Bool(); err == nil {
+		return b
+	}
+	return def
+}
@@ -0,0 +1,117 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxBodySize 是 DecodeRequest 和 Middleware 读取的请求体的最大字节数
+var MaxBodySize int64 = 1 << 20
+
+// RequestError 是请求体的错误，Status 是应该返回的 HTTP 状态码
+type RequestError struct {
+	Status int
+	Err    error
+}
+
+func (e *RequestError) Error() string { return e.Err.Error() }
+
+func (e *RequestError) Unwrap() error { return e.Err }
+
+// Decode 读取 r 中的 JSON，校验通过后解码到 v 中
+func (s *Schema) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidateJSON(data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// DecodeRequest 读取请求体并校验，通过后解码到 v 中。返回的错误是 *RequestError：
+// 请求体过大时状态码是 413，不是合法的 JSON 时是 400，校验失败时是 422，Err 是 *ValidationError。
+func (s *Schema) DecodeRequest(r *http.Request, v any) error {
+	data, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidateJSON(data); err != nil {
+		return requestError(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return &RequestError{http.StatusBadRequest, err}
+	}
+	return nil
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	if err != nil {
+		return nil, &RequestError{http.StatusBadRequest, err}
+	}
+	if int64(len(data)) > MaxBodySize {
+		return nil, &RequestError{http.StatusRequestEntityTooLarge, fmt.Errorf("request body larger than %d bytes", MaxBodySize)}
+	}
+	return data, nil
+}
+
+func requestError(err error) error {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return &RequestError{http.StatusUnprocessableEntity, err}
+	}
+	return &RequestError{http.StatusBadRequest, err}
+}
+
+// Middleware 在调用 next 之前校验 POST、PUT 和 PATCH 请求的请求体，失败时用 WriteError 返回错误。
+// 校验通过后请求体被放回 r.Body，next 可以照常读取。
+func (s *Schema) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		data, err := readBody(r)
+		if err == nil {
+			if err = s.ValidateJSON(data); err != nil {
+				err = requestError(err)
+			}
+		}
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WriteError 把错误写成 JSON 响应：{"error": "...", "errors": [{"path": ..., "keyword": ..., "message": ...}]}，
+// errors 只在校验失败时出现。不是 *RequestError 的错误返回 400。
+func WriteError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	var re *RequestError
+	if errors.As(err, &re) {
+		status = re.Status
+	}
+	body := struct {
+		Error  string        `json:"error"`
+		Errors []*FieldError `json:"errors,omitempty"`
+	}{Error: err.Error()}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		body.Error = "validation failed"
+		body.Errors = ve.Errors
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
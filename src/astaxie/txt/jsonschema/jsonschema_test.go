@@ -0,0 +1,240 @@
+package jsonschema
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var serverSchema = `{
+	"type": "object",
+	"required": ["servers"],
+	"properties": {
+		"servers": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/server"}},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"mode": {"enum": ["tcp", "udp"]},
+		"version": {"const": 2},
+		"weight": {"type": "number", "exclusiveMinimum": 0, "maximum": 1, "multipleOf": 0.25}
+	},
+	"$defs": {
+		"server": {
+			"type": "object",
+			"required": ["serverName", "serverIP"],
+			"properties": {
+				"serverName": {"type": "string", "minLength": 1, "pattern": "^[A-Za-z_]+$"},
+				"serverIP": {"type": "string", "format": "ipv4"},
+				"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+			}
+		}
+	}
+}`
+
+// fails 返回校验错误的 "path keyword" 列表
+func fails(t *testing.T, s *Schema, data string) []string {
+	t.Helper()
+	err := s.ValidateJSON([]byte(data))
+	if err == nil {
+		return nil
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("ValidateJSON(%s) = %v", data, err)
+	}
+	var out []string
+	for _, e := range ve.Errors {
+		out = append(out, e.Path+" "+e.Keyword)
+	}
+	return out
+}
+
+func Test_Validate(t *testing.T) {
+	s := MustCompile(serverSchema)
+	cases := []struct {
+		data string
+		want string
+	}{
+		{`{"servers": [{"serverName": "Shanghai_VPN", "serverIP": "127.0.0.1", "port": 443}]}`, ""},
+		{`{"servers": []}`, "/servers minItems"},
+		{`{}`, "/servers required"},
+		{`[]`, " type"},
+		{`{"servers": [{"serverName": "", "serverIP": "::1", "port": 8080.5}]}`,
+			"/servers/0/port type,/servers/0/serverIP format,/servers/0/serverName minLength,/servers/0/serverName pattern"},
+		{`{"servers": [{"serverIP": "10.0.0.1"}, {"serverName": "a", "serverIP": "10.0.0.2", "port": 0}]}`,
+			"/servers/0/serverName required,/servers/1/port minimum"},
+		{`{"servers": [{"serverName": "a", "serverIP": "1.1.1.1"}], "tags": ["x", "y", "x", "z"]}`,
+			"/tags maxItems,/tags/2 uniqueItems"},
+		{`{"servers": [{"serverName": "a", "serverIP": "1.1.1.1"}], "mode": "icmp", "version": 2.0, "weight": 0.3}`,
+			"/mode enum,/weight multipleOf"},
+		{`{"servers": [{"serverName": "a", "serverIP": "1.1.1.1"}], "version": "2", "weight": 0}`,
+			"/version const,/weight exclusiveMinimum"},
+	}
+	for _, c := range cases {
+		if got := strings.Join(fails(t, s, c.data), ","); got != c.want {
+			t.Errorf("%s:\n got  %s\n want %s", c.data, got, c.want)
+		}
+	}
+}
+
+func Test_RejectUnknown(t *testing.T) {
+	s := MustCompile(serverSchema)
+	data := `{"servers": [{"serverName": "a", "serverIP": "1.1.1.1", "serverIp": "x"}], "extra": 1}`
+	if got := fails(t, s, data); got != nil {
+		t.Errorf("unknown fields allowed by default, got %v", got)
+	}
+	s.RejectUnknown = true
+	if got := strings.Join(fails(t, s, data), ","); got != "/extra additionalProperties,/servers/0/serverIp additionalProperties" {
+		t.Errorf("got %s", got)
+	}
+
+	// 明确写了 additionalProperties 时以它为准
+	s = MustCompile(`{"properties": {"a": {}}, "additionalProperties": {"type": "integer"}}`)
+	s.RejectUnknown = true
+	if got := strings.Join(fails(t, s, `{"a": "x", "b": 1, "c": "y"}`), ","); got != "/c type" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func Test_Combinators(t *testing.T) {
+	s := MustCompile(`{
+		"$defs": {"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}}}},
+		"properties": {
+			"id": {"anyOf": [{"type": "integer"}, {"type": "string", "format": "uuid"}]},
+			"shape": {"oneOf": [{"required": ["radius"]}, {"required": ["width"]}]},
+			"name": {"not": {"const": "root"}},
+			"tree": {"$ref": "#/$defs/node"},
+			"kind": {"type": "string"},
+			"email": {"type": ["string", "null"], "format": "email"}
+		},
+		"if": {"properties": {"kind": {"const": "user"}}, "required": ["kind"]},
+		"then": {"required": ["email"]},
+		"dependentRequired": {"start": ["end"]}
+	}`)
+	cases := []struct {
+		data string
+		want string
+	}{
+		{`{"id": 1, "shape": {"radius": 1}, "name": "a", "tree": {"children": [{"children": []}]}}`, ""},
+		{`{"id": "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "kind": "user", "email": "a@example.com"}`, ""},
+		{`{"id": 1.5}`, "/id anyOf"},
+		{`{"shape": {"radius": 1, "width": 2}}`, "/shape oneOf"},
+		{`{"shape": {}}`, "/shape oneOf"},
+		{`{"name": "root"}`, "/name not"},
+		{`{"tree": {"children": [{"children": 1}]}}`, "/tree/children/0/children type"},
+		{`{"kind": "user"}`, "/email required"},
+		{`{"kind": "user", "email": "not an email"}`, "/email format"},
+		{`{"kind": "user", "email": null}`, ""},
+		{`{"start": 1}`, "/end dependentRequired"},
+	}
+	for _, c := range cases {
+		if got := strings.Join(fails(t, s, c.data), ","); got != c.want {
+			t.Errorf("%s:\n got  %s\n want %s", c.data, got, c.want)
+		}
+	}
+}
+
+func Test_Arrays(t *testing.T) {
+	s := MustCompile(`{"prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false, "contains": {"const": 7}}`)
+	if got := fails(t, s, `["a", 7]`); got != nil {
+		t.Errorf("got %v", got)
+	}
+	if got := strings.Join(fails(t, s, `[1, 2, 3]`), ","); got != "/0 type,/2 items, contains" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func Test_BigNumbers(t *testing.T) {
+	// 2^53 以上的整数转换成 float64 后相邻的值会相等
+	s := MustCompile(`{"properties": {
+		"c": {"const": 9007199254740993},
+		"max": {"maximum": 9007199254740992},
+		"min": {"exclusiveMinimum": 9007199254740992},
+		"m": {"multipleOf": 3},
+		"f": {"maximum": 1.5}
+	}}`)
+	cases := []struct {
+		data string
+		want string
+	}{
+		{`{"c": 9007199254740993, "max": 9007199254740992, "min": 9007199254740993, "m": 9007199254740993, "f": 1.5}`, ""},
+		{`{"c": 9007199254740992}`, "/c const"},
+		{`{"max": 9007199254740993}`, "/max maximum"},
+		{`{"min": 9007199254740992}`, "/min exclusiveMinimum"},
+		{`{"m": 9007199254740992}`, "/m multipleOf"},
+		{`{"c": 9.007199254740993e15, "f": 1.50001}`, "/f maximum"},
+	}
+	for _, c := range cases {
+		if got := strings.Join(fails(t, s, c.data), ","); got != c.want {
+			t.Errorf("%s:\n got  %s\n want %s", c.data, got, c.want)
+		}
+	}
+}
+
+func Test_ValidateGoValue(t *testing.T) {
+	type server struct {
+		Name string `json:"serverName"`
+		IP   string `json:"serverIP"`
+	}
+	s := MustCompile(`{"type": "object", "properties": {"serverIP": {"type": "string", "format": "ipv4"}}}`)
+	if err := s.Validate(server{"a", "1.2.3.4"}); err != nil {
+		t.Error(err)
+	}
+	if err := s.Validate(server{"a", "x"}); err == nil || !strings.Contains(err.Error(), "/serverIP: must be a valid ipv4") {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func Test_SchemaError(t *testing.T) {
+	for _, src := range []string{
+		`{"type": "float"}`,
+		`{"minLength": -1}`,
+		`{"pattern": "("}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "other.json"}`,
+		`{"properties": {"a": 1}}`,
+		`{"allOf": []}`,
+		`{"multipleOf": 0}`,
+		// 只在同一个值上循环的 $ref
+		`{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`,
+		`{"$ref": "#"}`,
+		`{"$defs": {"a": {"anyOf": [{"type": "string"}, {"$ref": "#/$defs/a"}]}}}`,
+	} {
+		_, err := Compile([]byte(src))
+		var se *SchemaError
+		if !errors.As(err, &se) {
+			t.Errorf("Compile(%s) = %v, want SchemaError", src, err)
+		}
+	}
+}
+
+func Test_HTTP(t *testing.T) {
+	s := MustCompile(serverSchema)
+	s.RejectUnknown = true
+	h := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v struct{ Servers []struct{ ServerName string } }
+		if err := s.DecodeRequest(r, &v); err != nil {
+			WriteError(w, err)
+			return
+		}
+		w.Write([]byte(v.Servers[0].ServerName))
+	}))
+	cases := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"servers": [{"serverName": "a", "serverIP": "1.1.1.1"}]}`, 200, "a"},
+		{`{"servers": [{"serverName": "a"}]}`, 422, `"path":"/servers/0/serverIP","keyword":"required"`},
+		{`{"servers": `, 400, `"error":"unexpected EOF"`},
+		{`{} {}`, 400, "unexpected data"},
+		{`{"servers": "` + strings.Repeat("x", int(MaxBodySize)) + `"}`, 413, "larger than"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(c.body)))
+		if w.Code != c.status || !strings.Contains(w.Body.String(), c.want) {
+			t.Errorf("%.40s: %d %s", c.body, w.Code, w.Body)
+		}
+	}
+}
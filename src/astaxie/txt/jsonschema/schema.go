@@ -0,0 +1,454 @@
+// Package jsonschema 按 JSON Schema（draft 2020-12 的一个子集）校验 JSON 数据。
+//
+// 支持的关键字：
+//
+//	通用    type enum const $ref $defs allOf anyOf oneOf not if then else
+//	对象    properties patternProperties additionalProperties required
+//	        dependentRequired minProperties maxProperties
+//	数组    items prefixItems contains minItems maxItems uniqueItems
+//	字符串  minLength maxLength pattern format
+//	数字    minimum maximum exclusiveMinimum exclusiveMaximum multipleOf
+//
+// format 校验 date-time、date、time、email、ipv4、ipv6、uri、uuid，其他的 format 被忽略。
+// $ref 只支持文档内部的 JSON Pointer，例如 #/$defs/server。不认识的关键字被忽略。
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Schema 是编译好的 schema，可以被多个 goroutine 同时使用
+type Schema struct {
+	root *node
+	// RejectUnknown 为 true 时，声明了 properties 但没有 additionalProperties 的对象不允许出现其他字段，
+	// 相当于给它们都加上 "additionalProperties": false。
+	// 注意用 allOf 组合的 schema 中每一部分是分别检查的，一部分声明的字段对另一部分来说是未知的。
+	RejectUnknown bool
+}
+
+type node struct {
+	// always 不为 nil 时这是布尔 schema：true 接受一切，false 拒绝一切
+	always *bool
+
+	types    []string
+	enum     []any
+	constVal any
+	hasConst bool
+	ref      string
+	refNode  *node
+
+	allOf, anyOf, oneOf []*node
+	not                 *node
+	ifNode, then, else_ *node
+
+	properties        map[string]*node
+	patternProperties []patternNode
+	additional        *node
+	required          []string
+	dependentRequired map[string][]string
+	minProps          *int
+	maxProps          *int
+
+	items       *node
+	prefixItems []*node
+	contains    *node
+	minItems    *int
+	maxItems    *int
+	unique      bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	format    string
+
+	// 数字保留 schema 中原来的值（json.Number 或 float64），整数可以精确比较
+	minimum, maximum                   any
+	exclusiveMinimum, exclusiveMaximum any
+	multipleOf                         any
+}
+
+type patternNode struct {
+	re *regexp.Regexp
+	n  *node
+}
+
+// SchemaError 是 schema 本身的错误，Pointer 指出出错的位置
+type SchemaError struct {
+	Pointer string
+	Msg     string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("jsonschema: invalid schema at %q: %s", "#"+e.Pointer, e.Msg)
+}
+
+// Compile 编译 JSON 文本形式的 schema
+func Compile(data []byte) (*Schema, error) {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("jsonschema: %w", err)
+	}
+	c := &compiler{doc: doc, nodes: map[string]*node{}}
+	root, err := c.compile(doc, "")
+	if err != nil {
+		return nil, err
+	}
+	// $ref 可以指向后面才编译的部分，也可以形成递归，所以最后统一解析
+	for len(c.refs) > 0 {
+		n := c.refs[0]
+		c.refs = c.refs[1:]
+		if n.refNode, err = c.resolve(n.ref); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkCycles(); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// MustCompile 和 Compile 相同，出错时 panic，用于初始化全局变量
+func MustCompile(data string) *Schema {
+	s, err := Compile([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+type compiler struct {
+	doc   any
+	nodes map[string]*node
+	refs  []*node
+}
+
+func (c *compiler) resolve(ref string) (*node, error) {
+	ptr, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, &SchemaError{"", "unsupported $ref " + strconv.Quote(ref) + ", only local references are supported"}
+	}
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+	v := c.doc
+	if ptr != "" {
+		for _, tok := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+			tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+			switch x := v.(type) {
+			case map[string]any:
+				v, ok = x[tok]
+			case []any:
+				i, err := strconv.Atoi(tok)
+				ok = err == nil && i >= 0 && i < len(x)
+				if ok {
+					v = x[i]
+				}
+			default:
+				ok = false
+			}
+			if !ok {
+				return nil, &SchemaError{"", "$ref " + strconv.Quote(ref) + " not found"}
+			}
+		}
+	}
+	return c.compile(v, ptr)
+}
+
+// checkCycles 检查 $ref 形成的、没有进入属性或数组元素的循环，例如 a 引用 b、b 又引用 a。
+// 验证时这样的循环一直在同一个值上递归，不会结束。
+func (c *compiler) checkCycles() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	ptrs := make(map[*node]string, len(c.nodes))
+	for ptr, n := range c.nodes {
+		ptrs[n] = ptr
+	}
+	state := map[*node]int{}
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		switch state[n] {
+		case visiting:
+			return &SchemaError{ptrs[n], "$ref cycle does not descend into the instance"}
+		case done:
+			return nil
+		}
+		state[n] = visiting
+		// 这些子 schema 验证的是同一个值
+		subs := []*node{n.refNode, n.not, n.ifNode, n.then, n.else_}
+		subs = append(append(append(subs, n.allOf...), n.anyOf...), n.oneOf...)
+		for _, sub := range subs {
+			if sub == nil {
+				continue
+			}
+			if err := visit(sub); err != nil {
+				return err
+			}
+		}
+		state[n] = done
+		return nil
+	}
+	for _, ptr := range slices.Sorted(maps.Keys(c.nodes)) {
+		if err := visit(c.nodes[ptr]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escape(tok string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1")
+}
+
+func (c *compiler) compile(v any, ptr string) (*node, error) {
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+	n := &node{}
+	c.nodes[ptr] = n
+	if b, ok := v.(bool); ok {
+		n.always = &b
+		return n, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, &SchemaError{ptr, "schema must be an object or a boolean"}
+	}
+	k := &keywords{c: c, m: m, ptr: ptr}
+
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		n.types = []string{t}
+	case []any:
+		for _, x := range t {
+			s, ok := x.(string)
+			if !ok {
+				return nil, k.errorf("type", "must be a string or an array of strings")
+			}
+			n.types = append(n.types, s)
+		}
+	default:
+		return nil, k.errorf("type", "must be a string or an array of strings")
+	}
+	for _, t := range n.types {
+		if !validTypes[t] {
+			return nil, k.errorf("type", "unknown type %q", t)
+		}
+	}
+	if e, ok := m["enum"]; ok {
+		a, ok := e.([]any)
+		if !ok {
+			return nil, k.errorf("enum", "must be an array")
+		}
+		n.enum = a
+	}
+	n.constVal, n.hasConst = m["const"]
+	if r, ok := m["$ref"]; ok {
+		s, ok := r.(string)
+		if !ok {
+			return nil, k.errorf("$ref", "must be a string")
+		}
+		n.ref = s
+		c.refs = append(c.refs, n)
+	}
+	if _, ok := m["$dynamicRef"]; ok {
+		return nil, k.errorf("$dynamicRef", "not supported")
+	}
+
+	k.schemaList("allOf", &n.allOf)
+	k.schemaList("anyOf", &n.anyOf)
+	k.schemaList("oneOf", &n.oneOf)
+	k.schemaList("prefixItems", &n.prefixItems)
+	k.schema("not", &n.not)
+	k.schema("if", &n.ifNode)
+	k.schema("then", &n.then)
+	k.schema("else", &n.else_)
+	k.schema("additionalProperties", &n.additional)
+	k.schema("items", &n.items)
+	k.schema("contains", &n.contains)
+	k.schemaMap("properties", &n.properties)
+	var patterns map[string]*node
+	k.schemaMap("patternProperties", &patterns)
+	for _, p := range sortedKeys(patterns) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, k.errorf("patternProperties", "%v", err)
+		}
+		n.patternProperties = append(n.patternProperties, patternNode{re, patterns[p]})
+	}
+	// $defs 中的 schema 只通过 $ref 使用，这里编译是为了尽早发现错误
+	var defs map[string]*node
+	k.schemaMap("$defs", &defs)
+
+	n.required = k.strings("required")
+	if d, ok := m["dependentRequired"].(map[string]any); ok {
+		n.dependentRequired = map[string][]string{}
+		for name := range d {
+			n.dependentRequired[name] = k.strings("dependentRequired/" + name)
+		}
+	}
+	n.minProps = k.int("minProperties")
+	n.maxProps = k.int("maxProperties")
+	n.minItems = k.int("minItems")
+	n.maxItems = k.int("maxItems")
+	n.minLength = k.int("minLength")
+	n.maxLength = k.int("maxLength")
+	n.unique, _ = m["uniqueItems"].(bool)
+	n.format, _ = m["format"].(string)
+	if p, ok := m["pattern"]; ok {
+		s, _ := p.(string)
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, k.errorf("pattern", "%v", err)
+		}
+		n.pattern = re
+	}
+	n.minimum = k.numberValue("minimum")
+	n.maximum = k.numberValue("maximum")
+	n.exclusiveMinimum = k.numberValue("exclusiveMinimum")
+	n.exclusiveMaximum = k.numberValue("exclusiveMaximum")
+	n.multipleOf = k.numberValue("multipleOf")
+	if f, ok := toFloat(n.multipleOf); ok && f <= 0 {
+		return nil, k.errorf("multipleOf", "must be greater than 0")
+	}
+	return n, k.err
+}
+
+var validTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// keywords 读取一个 schema 对象中的关键字，记录第一个错误
+type keywords struct {
+	c   *compiler
+	m   map[string]any
+	ptr string
+	err error
+}
+
+func (k *keywords) errorf(kw, format string, args ...any) error {
+	return &SchemaError{k.ptr + "/" + kw, fmt.Sprintf(format, args...)}
+}
+
+func (k *keywords) fail(kw, format string, args ...any) {
+	if k.err == nil {
+		k.err = k.errorf(kw, format, args...)
+	}
+}
+
+func (k *keywords) sub(v any, ptr string) *node {
+	if k.err != nil {
+		return nil
+	}
+	n, err := k.c.compile(v, ptr)
+	if err != nil {
+		k.err = err
+	}
+	return n
+}
+
+func (k *keywords) schema(kw string, out **node) {
+	if v, ok := k.m[kw]; ok {
+		*out = k.sub(v, k.ptr+"/"+escape(kw))
+	}
+}
+
+func (k *keywords) schemaList(kw string, out *[]*node) {
+	v, ok := k.m[kw]
+	if !ok {
+		return
+	}
+	a, ok := v.([]any)
+	if !ok || len(a) == 0 {
+		k.fail(kw, "must be a non-empty array")
+		return
+	}
+	for i, x := range a {
+		*out = append(*out, k.sub(x, fmt.Sprintf("%s/%s/%d", k.ptr, kw, i)))
+	}
+}
+
+func (k *keywords) schemaMap(kw string, out *map[string]*node) {
+	v, ok := k.m[kw]
+	if !ok {
+		return
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		k.fail(kw, "must be an object")
+		return
+	}
+	*out = map[string]*node{}
+	for _, name := range sortedKeys(m) {
+		(*out)[name] = k.sub(m[name], k.ptr+"/"+kw+"/"+escape(name))
+	}
+}
+
+// strings 读取字符串数组，kw 可以是 a/b 形式的路径
+func (k *keywords) strings(kw string) []string {
+	var v any = k.m
+	for _, tok := range strings.Split(kw, "/") {
+		m, _ := v.(map[string]any)
+		v = m[tok]
+	}
+	if v == nil {
+		return nil
+	}
+	a, ok := v.([]any)
+	var out []string
+	for _, x := range a {
+		s, isStr := x.(string)
+		ok = ok && isStr
+		out = append(out, s)
+	}
+	if !ok {
+		k.fail(kw, "must be an array of strings")
+	}
+	return out
+}
+
+func (k *keywords) number(kw string) *float64 {
+	v, ok := k.m[kw]
+	if !ok {
+		return nil
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		k.fail(kw, "must be a number")
+		return nil
+	}
+	return &f
+}
+
+// numberValue 和 number 一样检查关键字，但返回原来的值，不存在或者不是数字时返回 nil
+func (k *keywords) numberValue(kw string) any {
+	if k.number(kw) == nil {
+		return nil
+	}
+	return k.m[kw]
+}
+
+func (k *keywords) int(kw string) *int {
+	f := k.number(kw)
+	if f == nil {
+		return nil
+	}
+	if *f < 0 || *f != float64(int(*f)) {
+		k.fail(kw, "must be a non-negative integer")
+		return nil
+	}
+	i := int(*f)
+	return &i
+}
@@ -0,0 +1,472 @@
+package jsonschema
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError 是一处校验失败
+type FieldError struct {
+	// Path 是出错的值在数据中的位置，JSON Pointer 格式，例如 /servers/0/serverIP，根为空字符串
+	Path string `json:"path"`
+	// Keyword 是没有通过的关键字，例如 required
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidationError 包含所有的校验失败，按在数据中出现的顺序
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "jsonschema: " + strings.Join(msgs, "; ")
+}
+
+// Validate 校验一个值。v 通常是解码到 any 得到的值，其他的 Go 值（例如结构体）会先经过 JSON 编码再解码。
+// 校验失败时返回 *ValidationError。
+func (s *Schema) Validate(v any) error {
+	v, err := normalize(v)
+	if err != nil {
+		return err
+	}
+	vs := &validator{reject: s.RejectUnknown}
+	s.root.validate(v, "", vs)
+	if len(vs.errs) > 0 {
+		return &ValidationError{vs.errs}
+	}
+	return nil
+}
+
+// ValidateJSON 校验 JSON 文本。不带小数点和指数的整数按原样精确比较，不会因为转换成 float64 丢失精度，
+// 其他数字按 float64 比较
+func (s *Schema) ValidateJSON(data []byte) error {
+	v, err := decode(data)
+	if err != nil {
+		return err
+	}
+	return s.Validate(v)
+}
+
+func decode(data []byte) (any, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("jsonschema: unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+func normalize(v any) (any, error) {
+	switch v.(type) {
+	case nil, bool, string, json.Number, float64, []any, map[string]any:
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+type validator struct {
+	reject bool
+	errs   []*FieldError
+}
+
+func (vs *validator) fail(path, kw, format string, args ...any) {
+	vs.errs = append(vs.errs, &FieldError{path, kw, fmt.Sprintf(format, args...)})
+}
+
+// try 在一个新的 validator 中校验，用于 anyOf、oneOf、not、if 这些只关心是否通过的关键字
+func (vs *validator) try(n *node, v any, path string) []*FieldError {
+	sub := &validator{reject: vs.reject}
+	n.validate(v, path, sub)
+	return sub.errs
+}
+
+func typeOf(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		if f, ok := toFloat(x); ok && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+}
+
+func (n *node) validate(v any, path string, vs *validator) {
+	if n.always != nil {
+		if !*n.always {
+			vs.fail(path, "false", "no value is allowed here")
+		}
+		return
+	}
+	if n.refNode != nil {
+		n.refNode.validate(v, path, vs)
+	}
+
+	if n.types != nil {
+		t := typeOf(v)
+		if !slices.Contains(n.types, t) && !(t == "integer" && slices.Contains(n.types, "number")) {
+			vs.fail(path, "type", "expected %s, got %s", strings.Join(n.types, " or "), t)
+			// 类型不对时其他的关键字没有意义，只报告这一个错误
+			return
+		}
+	}
+	if n.enum != nil && !slices.ContainsFunc(n.enum, func(e any) bool { return equal(e, v) }) {
+		vs.fail(path, "enum", "must be one of %s", jsonText(n.enum))
+	}
+	if n.hasConst && !equal(n.constVal, v) {
+		vs.fail(path, "const", "must be %s", jsonText(n.constVal))
+	}
+
+	for _, sub := range n.allOf {
+		sub.validate(v, path, vs)
+	}
+	if n.anyOf != nil {
+		var errs []*FieldError
+		for _, sub := range n.anyOf {
+			e := vs.try(sub, v, path)
+			if len(e) == 0 {
+				errs = nil
+				break
+			}
+			errs = append(errs, e...)
+		}
+		if errs != nil {
+			vs.fail(path, "anyOf", "must match at least one schema in anyOf: %s", summary(errs))
+		}
+	}
+	if n.oneOf != nil {
+		var matched []int
+		for i, sub := range n.oneOf {
+			if len(vs.try(sub, v, path)) == 0 {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) != 1 {
+			vs.fail(path, "oneOf", "must match exactly one schema in oneOf, matched %d", len(matched))
+		}
+	}
+	if n.not != nil && len(vs.try(n.not, v, path)) == 0 {
+		vs.fail(path, "not", "must not match the schema in not")
+	}
+	if n.ifNode != nil {
+		if len(vs.try(n.ifNode, v, path)) == 0 {
+			if n.then != nil {
+				n.then.validate(v, path, vs)
+			}
+		} else if n.else_ != nil {
+			n.else_.validate(v, path, vs)
+		}
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		n.validateObject(x, path, vs)
+	case []any:
+		n.validateArray(x, path, vs)
+	case string:
+		n.validateString(x, path, vs)
+	case bool, nil:
+	default:
+		n.validateNumber(x, path, vs)
+	}
+}
+
+func (n *node) validateObject(m map[string]any, path string, vs *validator) {
+	for _, name := range n.required {
+		if _, ok := m[name]; !ok {
+			vs.fail(path+"/"+escape(name), "required", "required field is missing")
+		}
+	}
+	for _, name := range sortedKeys(n.dependentRequired) {
+		deps := n.dependentRequired[name]
+		if _, ok := m[name]; !ok {
+			continue
+		}
+		for _, d := range deps {
+			if _, ok := m[d]; !ok {
+				vs.fail(path+"/"+escape(d), "dependentRequired", "required when %q is present", name)
+			}
+		}
+	}
+	if n.minProps != nil && len(m) < *n.minProps {
+		vs.fail(path, "minProperties", "must have at least %d fields", *n.minProps)
+	}
+	if n.maxProps != nil && len(m) > *n.maxProps {
+		vs.fail(path, "maxProperties", "must have at most %d fields", *n.maxProps)
+	}
+	rejectUnknown := n.additional == nil && vs.reject && (n.properties != nil || n.patternProperties != nil)
+	for _, name := range sortedKeys(m) {
+		p := path + "/" + escape(name)
+		known := false
+		if sub, ok := n.properties[name]; ok {
+			known = true
+			sub.validate(m[name], p, vs)
+		}
+		for _, pp := range n.patternProperties {
+			if pp.re.MatchString(name) {
+				known = true
+				pp.n.validate(m[name], p, vs)
+			}
+		}
+		switch {
+		case known:
+		case n.additional != nil:
+			if f := n.additional.always; f != nil && !*f {
+				vs.fail(p, "additionalProperties", "unknown field")
+			} else {
+				n.additional.validate(m[name], p, vs)
+			}
+		case rejectUnknown:
+			vs.fail(p, "additionalProperties", "unknown field")
+		}
+	}
+}
+
+func (n *node) validateArray(a []any, path string, vs *validator) {
+	if n.minItems != nil && len(a) < *n.minItems {
+		vs.fail(path, "minItems", "must have at least %d items", *n.minItems)
+	}
+	if n.maxItems != nil && len(a) > *n.maxItems {
+		vs.fail(path, "maxItems", "must have at most %d items", *n.maxItems)
+	}
+	for i, e := range a {
+		p := fmt.Sprintf("%s/%d", path, i)
+		if i < len(n.prefixItems) {
+			n.prefixItems[i].validate(e, p, vs)
+		} else if n.items != nil {
+			if f := n.items.always; f != nil && !*f {
+				vs.fail(p, "items", "array must have at most %d items", len(n.prefixItems))
+			} else {
+				n.items.validate(e, p, vs)
+			}
+		}
+	}
+	if n.contains != nil && !slices.ContainsFunc(a, func(e any) bool { return len(vs.try(n.contains, e, path)) == 0 }) {
+		vs.fail(path, "contains", "must contain at least one matching item")
+	}
+	if n.unique {
+		for i := 1; i < len(a); i++ {
+			for j := range i {
+				if equal(a[i], a[j]) {
+					vs.fail(fmt.Sprintf("%s/%d", path, i), "uniqueItems", "duplicate of item %d", j)
+				}
+			}
+		}
+	}
+}
+
+func (n *node) validateString(s, path string, vs *validator) {
+	if n.minLength != nil || n.maxLength != nil {
+		l := utf8.RuneCountInString(s)
+		if n.minLength != nil && l < *n.minLength {
+			vs.fail(path, "minLength", "must be at least %d characters", *n.minLength)
+		}
+		if n.maxLength != nil && l > *n.maxLength {
+			vs.fail(path, "maxLength", "must be at most %d characters", *n.maxLength)
+		}
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		vs.fail(path, "pattern", "must match %s", n.pattern)
+	}
+	if check, ok := formats[n.format]; ok && !check(s) {
+		vs.fail(path, "format", "must be a valid %s", n.format)
+	}
+}
+
+func (n *node) validateNumber(x any, path string, vs *validator) {
+	if n.minimum != nil && compareNumbers(x, n.minimum) < 0 {
+		vs.fail(path, "minimum", "must be >= %v", n.minimum)
+	}
+	if n.maximum != nil && compareNumbers(x, n.maximum) > 0 {
+		vs.fail(path, "maximum", "must be <= %v", n.maximum)
+	}
+	if n.exclusiveMinimum != nil && compareNumbers(x, n.exclusiveMinimum) <= 0 {
+		vs.fail(path, "exclusiveMinimum", "must be > %v", n.exclusiveMinimum)
+	}
+	if n.exclusiveMaximum != nil && compareNumbers(x, n.exclusiveMaximum) >= 0 {
+		vs.fail(path, "exclusiveMaximum", "must be < %v", n.exclusiveMaximum)
+	}
+	if n.multipleOf != nil && !multipleOf(x, n.multipleOf) {
+		vs.fail(path, "multipleOf", "must be a multiple of %v", n.multipleOf)
+	}
+}
+
+var uuidRE = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var formats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"time": func(s string) bool {
+		_, err := time.Parse("15:04:05Z07:00", s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		a, err := mail.ParseAddress(s)
+		return err == nil && a.Address == s
+	},
+	"ipv4": func(s string) bool {
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is4()
+	},
+	"ipv6": func(s string) bool {
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is6()
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"uuid": uuidRE.MatchString,
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// toInt 把整数转换成 big.Int：json.Number 只接受不带小数点和指数的写法，长度和原文成正比；
+// float64 只接受没有小数部分的值。其他数字返回 false，按 float64 比较。
+func toInt(v any) (*big.Int, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		if strings.ContainsAny(string(n), ".eE") {
+			return nil, false
+		}
+		return new(big.Int).SetString(string(n), 10)
+	case float64:
+		if math.IsInf(n, 0) || n != math.Trunc(n) {
+			return nil, false
+		}
+		i, _ := big.NewFloat(n).Int(nil)
+		return i, true
+	}
+	return nil, false
+}
+
+// compareNumbers 比较两个数字，返回 -1、0 或 1。两个都是整数时精确比较，否则按 float64 比较
+func compareNumbers(a, b any) int {
+	if x, ok := toInt(a); ok {
+		if y, ok := toInt(b); ok {
+			return x.Cmp(y)
+		}
+	}
+	fa, _ := toFloat(a)
+	fb, _ := toFloat(b)
+	return cmp.Compare(fa, fb)
+}
+
+// multipleOf 表示 x 是 m 的整数倍，都是整数时精确计算
+func multipleOf(x, m any) bool {
+	if xi, ok := toInt(x); ok {
+		if mi, ok := toInt(m); ok {
+			return new(big.Int).Rem(xi, mi).Sign() == 0
+		}
+	}
+	f, _ := toFloat(x)
+	fm, _ := toFloat(m)
+	q := f / fm
+	return math.Abs(q-math.Round(q)) <= 1e-9
+}
+
+// equal 按 JSON 的语义比较两个值，数字按数值比较
+func equal(a, b any) bool {
+	if _, ok := toFloat(a); ok {
+		_, ok := toFloat(b)
+		return ok && compareNumbers(a, b) == 0
+	}
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		return ok && slices.EqualFunc(x, y, equal)
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, ok := y[k]
+			if !ok || !equal(xv, yv) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func jsonText(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// summary 把子 schema 的错误合并成一句话
+func summary(errs []*FieldError) string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,215 @@
+// Package jsonstream 用 json.Decoder.Token 流式地处理 JSON，不把整个文档读入内存。
+//
+// 路径是用 . 分隔的键，例如 "servers" 或 "data.items.0.name"：对象用键名，数组用下标，
+// * 匹配数组的每个元素或对象的每个值。不在路径上的值只被扫描跳过，不会被解码。
+// 解码到 any 时数字是 json.Number，不会丢失大整数的精度。
+package jsonstream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound 表示文档中没有路径指向的值
+var ErrNotFound = errors.New("jsonstream: path not found")
+
+// errStop 用来在找到值之后停止遍历
+var errStop = errors.New("stop")
+
+// splitPath 把路径拆成各段，空路径表示整个文档
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// Find 把路径指向的第一个值解码到 v，找到之后就停止读取 r
+func Find(r io.Reader, path string, v any) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	err := walk(dec, splitPath(path), func(dec *json.Decoder) error {
+		if err := dec.Decode(v); err != nil {
+			return err
+		}
+		return errStop
+	})
+	switch err {
+	case errStop:
+		return nil
+	case nil:
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	return err
+}
+
+// Each 遍历路径指向的数组，把每个元素解码成 T。整个数组不会同时出现在内存中。
+// 路径中有 * 时依次遍历所有匹配的数组；路径指向的不是数组时产生一个错误。
+// 出错后遍历停止。
+func Each[T any](r io.Reader, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		found := false
+		err := walk(dec, splitPath(path), func(dec *json.Decoder) error {
+			found = true
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok != json.Delim('[') {
+				return fmt.Errorf("jsonstream: %s at offset %d is %s, not an array", path, dec.InputOffset(), describe(tok))
+			}
+			for dec.More() {
+				var v T
+				if err := dec.Decode(&v); err != nil {
+					return err
+				}
+				if !yield(v, nil) {
+					return errStop
+				}
+			}
+			_, err = dec.Token() // ']'
+			return err
+		})
+		if err == nil && !found {
+			err = fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		if err != nil && err != errStop {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// Lines 读取换行分隔的 JSON（NDJSON），每一行解码成一个 T，跳过空行。
+// 一次只读入一行；解码出错时错误中包含行号，之后遍历停止。
+func Lines[T any](r io.Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		br := bufio.NewReader(r)
+		for n := 1; ; n++ {
+			line, err := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				var v T
+				dec := json.NewDecoder(bytes.NewReader(line))
+				dec.UseNumber()
+				if derr := dec.Decode(&v); derr != nil {
+					yield(v, fmt.Errorf("jsonstream: line %d: %v", n, derr))
+					return
+				}
+				if dec.More() {
+					yield(v, fmt.Errorf("jsonstream: line %d: more than one value", n))
+					return
+				}
+				if !yield(v, nil) {
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+		}
+	}
+}
+
+// walk 沿着路径 segs 前进，对每一个匹配的值调用 f。
+// 调用 f 时 dec 正好位于这个值之前，f 必须完整地读取这个值。f 返回错误时停止。
+func walk(dec *json.Decoder, segs []string, f func(*json.Decoder) error) error {
+	if len(segs) == 0 {
+		return f(dec)
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	seg, rest := segs[0], segs[1:]
+	switch tok {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if seg == "*" || key == seg {
+				err = walk(dec, rest, f)
+			} else {
+				err = skip(dec)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		index := -1
+		if seg != "*" {
+			if index, err = strconv.Atoi(seg); err != nil {
+				index = -2 // 数组没有这个键，跳过所有元素
+			}
+		}
+		for i := 0; dec.More(); i++ {
+			if index == -1 || i == index {
+				err = walk(dec, rest, f)
+			} else {
+				err = skip(dec)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		// 标量没有子节点
+		return nil
+	}
+	_, err = dec.Token() // '}' 或 ']'
+	return err
+}
+
+// skip 跳过一个完整的值
+func skip(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func describe(tok json.Token) string {
+	switch tok.(type) {
+	case json.Delim:
+		if tok == json.Delim('{') {
+			return "an object"
+		}
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(tok)
+}
@@ -0,0 +1,168 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type Server struct {
+	ServerName string
+	ServerIP   string
+}
+
+const doc = `{
+	"meta": {"count": 2, "tags": ["a", "b"], "big": 9223372036854775807},
+	"servers": [
+		{"serverName": "Shanghai_VPN", "serverIP": "127.0.0.1", "ports": [80, 443]},
+		{"serverName": "Beijing_VPN", "serverIP": "127.0.0.2", "ports": [22]}
+	],
+	"regions": {"east": {"servers": [{"serverName": "e1"}]}, "west": {"servers": [{"serverName": "w1"}, {"serverName": "w2"}]}}
+}`
+
+func Test_Find(t *testing.T) {
+	var name string
+	if err := Find(strings.NewReader(doc), "servers.1.serverName", &name); err != nil || name != "Beijing_VPN" {
+		t.Errorf("Find = %q, %v", name, err)
+	}
+	var big any
+	if err := Find(strings.NewReader(doc), "meta.big", &big); err != nil || big != json.Number("9223372036854775807") {
+		t.Errorf("big = %v (%T), %v", big, big, err)
+	}
+	var s Server
+	if err := Find(strings.NewReader(doc), "regions.*.servers.0", &s); err != nil || s.ServerName != "e1" {
+		t.Errorf("wildcard = %+v, %v", s, err)
+	}
+	for _, missing := range []string{"nope", "servers.5", "servers.name", "meta.count.x"} {
+		var v any
+		if err := Find(strings.NewReader(doc), missing, &v); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Find(%q) = %v", missing, err)
+		}
+	}
+}
+
+// 找到之后不再读取剩下的输入
+func Test_FindStopsEarly(t *testing.T) {
+	r := io.MultiReader(strings.NewReader(`{"a": 1, "b": `), errReader{})
+	var a int
+	if err := Find(r, "a", &a); err != nil || a != 1 {
+		t.Errorf("Find = %d, %v", a, err)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("read past the value") }
+
+func Test_Each(t *testing.T) {
+	var names []string
+	for s, err := range Each[Server](strings.NewReader(doc), "servers") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, s.ServerName)
+	}
+	if strings.Join(names, ",") != "Shanghai_VPN,Beijing_VPN" {
+		t.Errorf("names = %v", names)
+	}
+
+	names = nil
+	for s, err := range Each[Server](strings.NewReader(doc), "regions.*.servers") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, s.ServerName)
+	}
+	if strings.Join(names, ",") != "e1,w1,w2" {
+		t.Errorf("wildcard names = %v", names)
+	}
+
+	var ports []int
+	for p, err := range Each[int](strings.NewReader(doc), "servers.*.ports") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ports = append(ports, p)
+	}
+	if fmt.Sprint(ports) != "[80 443 22]" {
+		t.Errorf("ports = %v", ports)
+	}
+
+	// 提前 break
+	n := 0
+	for range Each[Server](strings.NewReader(doc), "servers") {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("n = %d", n)
+	}
+
+	for _, c := range []struct{ path, want string }{
+		{"meta", "not an array"},
+		{"missing", "path not found"},
+	} {
+		var err error
+		for _, err = range Each[any](strings.NewReader(doc), c.path) {
+		}
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Each(%q) error = %v", c.path, err)
+		}
+	}
+}
+
+// 一个很大的数组，元素是逐个解码的
+func Test_EachLarge(t *testing.T) {
+	pr, pw := io.Pipe()
+	go func() {
+		io.WriteString(pw, `{"items": [`)
+		for i := range 100000 {
+			if i > 0 {
+				io.WriteString(pw, ",")
+			}
+			fmt.Fprintf(pw, `{"id": %d, "pad": "%s"}`, i, strings.Repeat("x", 100))
+		}
+		io.WriteString(pw, `]}`)
+		pw.Close()
+	}()
+	sum := 0
+	for item, err := range Each[struct{ ID int }](pr, "items") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += item.ID
+	}
+	if sum != 100000*99999/2 {
+		t.Errorf("sum = %d", sum)
+	}
+}
+
+func Test_Lines(t *testing.T) {
+	input := `{"serverName": "a"}
+
+{"serverName": "b"}
+{"serverName": "c"}`
+	var names []string
+	for s, err := range Lines[Server](strings.NewReader(input)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, s.ServerName)
+	}
+	if strings.Join(names, "") != "abc" {
+		t.Errorf("names = %v", names)
+	}
+
+	bad := "{\"serverName\": \"a\"}\n{\"serverName\": \n{}\n"
+	var err error
+	count := 0
+	for _, err = range Lines[Server](strings.NewReader(bad)) {
+		count++
+	}
+	if count != 2 || err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("count = %d, err = %v", count, err)
+	}
+}
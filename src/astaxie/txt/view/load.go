@@ -0,0 +1,189 @@
+package view
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// file 是目录中的一个模板文件
+type file struct {
+	name   string // 相对于 Dir 的路径，用 / 分隔，也是模板名
+	path   string
+	text   string
+	layout string // 继承的布局，没有时为空
+	line   int    // layout 指令所在的行
+}
+
+// fileStamp 用修改时间和大小判断文件是否改变
+type fileStamp struct {
+	name string
+	mod  int64
+	size int64
+}
+
+// layoutDirective 匹配文件开头的 {{/* layout: base */}}
+var layoutDirective = regexp.MustCompile(`^\s*\{\{-?\s*/\*\s*layout:\s*"?([^"\s*]+)"?\s*\*/\s*-?\}\}`)
+
+// errorPos 匹配 text/template 错误信息开头的 template: name:line:
+var errorPos = regexp.MustCompile(`(?s)^template: (.*?):(\d+): (.*)$`)
+
+// walk 按路径顺序遍历目录中的模板文件，跳过以 . 开头的文件和目录
+func walk(opt Options, fn func(name, path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(opt.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != opt.Dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !slices.Contains(opt.Exts, filepath.Ext(path)) {
+			return nil
+		}
+		rel, err := filepath.Rel(opt.Dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), path, d)
+	})
+}
+
+func scanStamps(opt Options) ([]fileStamp, error) {
+	var stamps []fileStamp
+	err := walk(opt, func(name, path string, d fs.DirEntry) error {
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stamps = append(stamps, fileStamp{name, fi.ModTime().UnixNano(), fi.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("view: %w", err)
+	}
+	return stamps, nil
+}
+
+// load 读取并编译目录中的所有模板
+func load(opt Options) (*set, error) {
+	s := &set{pages: map[string]*page{}}
+	var partials []*file
+	layouts := map[string]*file{}
+	pages := map[string]*file{}
+	err := walk(opt, func(name, path string, d fs.DirEntry) error {
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		s.stamps = append(s.stamps, fileStamp{name, fi.ModTime().UnixNano(), fi.Size()})
+
+		f := &file{name: name, path: path, text: string(data)}
+		if loc := layoutDirective.FindStringSubmatchIndex(f.text); loc != nil {
+			f.layout = f.text[loc[2]:loc[3]]
+			f.line = 1 + strings.Count(f.text[:loc[2]], "\n")
+		}
+		short := strings.TrimSuffix(name, filepath.Ext(name))
+		if rest, ok := strings.CutPrefix(short, opt.PartialDir+"/"); ok && rest != "" {
+			partials = append(partials, f)
+		} else if rest, ok := strings.CutPrefix(short, opt.LayoutDir+"/"); ok && rest != "" {
+			layouts[rest] = f
+		} else if other, ok := pages[short]; ok {
+			return &ParseError{File: path, Msg: fmt.Sprintf("page %s is also defined by %s", short, other.path)}
+		} else {
+			pages[short] = f
+		}
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(*ParseError); ok {
+			return nil, err
+		}
+		return nil, fmt.Errorf("view: %w", err)
+	}
+
+	base := template.New("").Funcs(opt.Funcs)
+	for _, f := range partials {
+		if err := parse(base, f); err != nil {
+			return nil, err
+		}
+	}
+
+	// 每个布局编译一次，继承它的布局和页面在它的基础上克隆
+	compiled := map[string]*page{}
+	visiting := map[string]bool{}
+	var compile func(f *file) (*page, error)
+	compileLayout := func(name string, from *file) (*page, error) {
+		if p, ok := compiled[name]; ok {
+			return p, nil
+		}
+		f, ok := layouts[name]
+		if !ok {
+			return nil, &ParseError{File: from.path, Line: from.line, Msg: fmt.Sprintf("unknown layout %q", name)}
+		}
+		if visiting[name] {
+			return nil, &ParseError{File: from.path, Line: from.line, Msg: fmt.Sprintf("layout %q inherits itself", name)}
+		}
+		visiting[name] = true
+		p, err := compile(f)
+		if err != nil {
+			return nil, err
+		}
+		compiled[name] = p
+		return p, nil
+	}
+	compile = func(f *file) (*page, error) {
+		parent := &page{t: base, root: f.name}
+		if f.layout != "" {
+			p, err := compileLayout(f.layout, f)
+			if err != nil {
+				return nil, err
+			}
+			parent = p
+		}
+		t, err := parent.t.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if err := parse(t, f); err != nil {
+			return nil, err
+		}
+		return &page{t: t, root: parent.root}, nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(pages)) {
+		p, err := compile(pages[name])
+		if err != nil {
+			return nil, err
+		}
+		s.pages[name] = p
+	}
+	return s, nil
+}
+
+// parse 把 f 解析到 t 中，模板名是 f.name，错误中的模板名换成文件路径
+func parse(t *template.Template, f *file) error {
+	_, err := t.New(f.name).Parse(f.text)
+	if err == nil {
+		return nil
+	}
+	e := &ParseError{File: f.path, Msg: err.Error()}
+	if m := errorPos.FindStringSubmatch(err.Error()); m != nil && m[1] == f.name {
+		e.Line, _ = strconv.Atoi(m[2])
+		e.Msg = m[3]
+	}
+	return e
+}
@@ -0,0 +1,191 @@
+// Package view 从一个目录加载 html/template 模板，编译一次后缓存起来。
+//
+// 目录中的文件按位置分为三类，模板名是相对于目录的路径（包括扩展名），执行出错时的信息中就是这个文件名：
+//
+//   - LayoutDir（默认 layouts）下的布局，用 {{block "content" .}}{{end}} 留出页面可以替换的部分
+//   - PartialDir（默认 partials）下的片段，用 {{define "header"}} 定义可以在任何页面和布局中使用的模板
+//   - 其他文件是页面，用去掉扩展名的路径渲染，例如 users/show.tmpl 是 "users/show"
+//
+// 页面或布局的第一行可以是 {{/* layout: base */}}，表示继承 LayoutDir 下的 base 布局：渲染时执行最外层的布局，
+// 页面中的 {{define}} 覆盖布局中同名的 block。布局也可以这样继承另一个布局。
+//
+// 片段只解析一次，每个布局在片段的基础上克隆，每个页面再在布局的基础上克隆。
+// Reload 为 true 时每次渲染前检查目录中的文件，改变后重新加载，用于开发时修改模板。
+package view
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNotFound 表示没有这个名字的页面
+var ErrNotFound = errors.New("view: template not found")
+
+// Options 是模板目录和加载方式
+type Options struct {
+	// Dir 是模板目录
+	Dir string
+	// Exts 是模板文件的扩展名，默认是 .tmpl、.gtpl 和 .html
+	Exts []string
+	// LayoutDir 和 PartialDir 是 Dir 下布局和片段的子目录，默认是 layouts 和 partials
+	LayoutDir  string
+	PartialDir string
+	// Funcs 是模板中可以使用的函数
+	Funcs template.FuncMap
+	// Reload 为 true 时每次渲染前检查文件是否改变
+	Reload bool
+	// ErrorLog 记录 HTML 中渲染失败的错误，默认是 log.Default()
+	ErrorLog *log.Logger
+}
+
+// ParseError 是模板文件中的错误，File 是文件路径，Line 从 1 开始，不知道行号时是 0
+type ParseError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Msg)
+}
+
+// Manager 保存编译好的页面，可以被多个 goroutine 同时使用
+type Manager struct {
+	opt Options
+	cur atomic.Pointer[set]
+	mu  sync.Mutex
+}
+
+// set 是一次加载的结果
+type set struct {
+	pages  map[string]*page
+	stamps []fileStamp
+}
+
+// page 是一个页面，执行 root，也就是最外层的布局或者页面自身
+type page struct {
+	t    *template.Template
+	root string
+}
+
+// New 加载目录中的所有模板，有模板不能解析时返回 *ParseError
+func New(opt Options) (*Manager, error) {
+	if opt.Exts == nil {
+		opt.Exts = []string{".tmpl", ".gtpl", ".html"}
+	}
+	if opt.LayoutDir == "" {
+		opt.LayoutDir = "layouts"
+	}
+	if opt.PartialDir == "" {
+		opt.PartialDir = "partials"
+	}
+	m := &Manager{opt: opt}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Must 在 err 不为 nil 时 panic，用于在初始化时加载模板
+func Must(m *Manager, err error) *Manager {
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Reload 重新加载所有模板，失败时继续使用原来的模板
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := load(m.opt)
+	if err != nil {
+		return err
+	}
+	m.cur.Store(s)
+	return nil
+}
+
+// current 返回当前的模板，Reload 为 true 并且文件改变时先重新加载
+func (m *Manager) current() (*set, error) {
+	if !m.opt.Reload {
+		return m.cur.Load(), nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stamps, err := scanStamps(m.opt)
+	if err != nil {
+		return nil, err
+	}
+	if s := m.cur.Load(); slices.Equal(s.stamps, stamps) {
+		return s, nil
+	}
+	s, err := load(m.opt)
+	if err != nil {
+		return nil, err
+	}
+	m.cur.Store(s)
+	return s, nil
+}
+
+// Names 返回所有页面的名字
+func (m *Manager) Names() []string {
+	s := m.cur.Load()
+	return slices.Sorted(maps.Keys(s.pages))
+}
+
+// Render 用 data 渲染页面 name。先渲染到缓冲区，出错时 w 中不会有不完整的输出。
+func (m *Manager) Render(w io.Writer, name string, data any) error {
+	var buf bytes.Buffer
+	if err := m.execute(&buf, name, data); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func (m *Manager) execute(buf *bytes.Buffer, name string, data any) error {
+	s, err := m.current()
+	if err != nil {
+		return err
+	}
+	p, ok := s.pages[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return p.t.ExecuteTemplate(buf, p.root, data)
+}
+
+// HTML 用状态码 status 返回渲染后的页面。出错时记录到 ErrorLog 并返回 500，
+// Reload 为 true 时把错误写在响应中，方便开发时查看。
+func (m *Manager) HTML(w http.ResponseWriter, status int, name string, data any) {
+	var buf bytes.Buffer
+	if err := m.execute(&buf, name, data); err != nil {
+		logger := m.opt.ErrorLog
+		if logger == nil {
+			logger = log.Default()
+		}
+		logger.Printf("view: render %s: %v", name, err)
+		msg := http.StatusText(http.StatusInternalServerError)
+		if m.opt.Reload {
+			msg = err.Error()
+		}
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+}
@@ -0,0 +1,186 @@
+package view
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles 在临时目录中创建模板文件，返回目录
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, text := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+var site = map[string]string{
+	"layouts/base.tmpl":    `<html>{{template "header" .}}{{block "content" .}}default{{end}}</html>`,
+	"layouts/admin.tmpl":   "{{/* layout: base */}}\n{{define \"content\"}}[admin {{block \"main\" .}}{{end}}]{{end}}",
+	"partials/header.tmpl": `{{define "header"}}<h1>{{.Title | upper}}</h1>{{end}}`,
+	"index.tmpl":           "{{/* layout: base */}}\n{{define \"content\"}}<p>{{.Body}}</p>{{end}}",
+	"empty.tmpl":           `{{/* layout: "base" */}}`,
+	"admin/users.gtpl":     "{{/* layout: admin */}}\n{{define \"main\"}}users{{end}}",
+	"plain.html":           `{{template "header" .}}plain`,
+	".hidden/x.tmpl":       `{{`,
+	"notes.txt":            `{{`,
+}
+
+var funcs = template.FuncMap{"upper": strings.ToUpper}
+
+func Test_Render(t *testing.T) {
+	m, err := New(Options{Dir: writeFiles(t, site), Funcs: funcs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"admin/users", "empty", "index", "plain"}; !reflect.DeepEqual(m.Names(), want) {
+		t.Errorf("Names = %v, want %v", m.Names(), want)
+	}
+	data := map[string]string{"Title": "hi", "Body": "<b>"}
+	cases := map[string]string{
+		"index":       "<html><h1>HI</h1><p>&lt;b&gt;</p></html>",
+		"empty":       "<html><h1>HI</h1>default</html>",
+		"admin/users": "<html><h1>HI</h1>[admin users]</html>",
+		"plain":       "<h1>HI</h1>plain",
+	}
+	for name, want := range cases {
+		var out strings.Builder
+		if err := m.Render(&out, name, data); err != nil {
+			t.Errorf("Render(%s): %v", name, err)
+		} else if out.String() != want {
+			t.Errorf("Render(%s) = %q, want %q", name, out.String(), want)
+		}
+	}
+
+	if err := m.Render(&strings.Builder{}, "missing", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Render(missing) = %v", err)
+	}
+	// 执行出错时不输出不完整的内容，错误中有文件名
+	var out strings.Builder
+	err = m.Render(&out, "index", map[string]any{"Title": 1})
+	if err == nil || out.Len() != 0 || !strings.Contains(err.Error(), "partials/header.tmpl") {
+		t.Errorf("Render = %q, %v", out.String(), err)
+	}
+}
+
+func Test_ParseError(t *testing.T) {
+	cases := []struct {
+		files map[string]string
+		file  string
+		line  int
+		msg   string
+	}{
+		{map[string]string{"a.tmpl": "ok\n\n{{if .X}}"}, "a.tmpl", 3, "unexpected EOF"},
+		{map[string]string{"partials/p.tmpl": "{{define \"p\"}}\n{{nope}}{{end}}"}, "partials/p.tmpl", 2, `function "nope" not defined`},
+		{map[string]string{"a.tmpl": "\n{{/* layout: base */}}"}, "a.tmpl", 2, `unknown layout "base"`},
+		{map[string]string{
+			"layouts/a.tmpl": "{{/* layout: b */}}",
+			"layouts/b.tmpl": "{{/* layout: a */}}",
+			"page.tmpl":      "{{/* layout: a */}}",
+		}, "layouts/b.tmpl", 1, `layout "a" inherits itself`},
+		{map[string]string{"a.tmpl": "", "a.html": ""}, "a.tmpl", 0, "page a is also defined by"},
+	}
+	for _, c := range cases {
+		dir := writeFiles(t, c.files)
+		_, err := New(Options{Dir: dir})
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%v: error %v", c.files, err)
+			continue
+		}
+		if pe.File != filepath.Join(dir, c.file) || pe.Line != c.line || !strings.Contains(pe.Msg, c.msg) {
+			t.Errorf("%v: error %#v", c.files, pe)
+		}
+	}
+
+	if _, err := New(Options{Dir: filepath.Join(t.TempDir(), "none")}); err == nil {
+		t.Error("missing directory: no error")
+	}
+}
+
+func Test_Reload(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.tmpl": "one"})
+	m, err := New(Options{Dir: dir, Reload: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	render := func() string {
+		var out strings.Builder
+		if err := m.Render(&out, "a", nil); err != nil {
+			return err.Error()
+		}
+		return out.String()
+	}
+	if got := render(); got != "one" {
+		t.Fatalf("Render = %q", got)
+	}
+
+	// 修改时间的精度可能不够，所以同时改变文件大小
+	write := func(name, text string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.tmpl", "two!")
+	if got := render(); got != "two!" {
+		t.Errorf("after change Render = %q", got)
+	}
+	write("a.tmpl", "{{.")
+	if got := render(); !strings.Contains(got, "a.tmpl:1:") {
+		t.Errorf("after bad change Render = %q", got)
+	}
+	write("a.tmpl", "three")
+	write("b.tmpl", "b")
+	if got := render(); got != "three" || len(m.Names()) != 2 {
+		t.Errorf("after fix Render = %q, Names = %v", got, m.Names())
+	}
+
+	// 不是开发模式时不检查文件
+	m, err = New(Options{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("a.tmpl", "four!")
+	if got := render(); got != "three" {
+		t.Errorf("without Reload Render = %q", got)
+	}
+	if err := m.Reload(); err != nil || render() != "four!" {
+		t.Errorf("Reload = %v, Render = %q", err, render())
+	}
+}
+
+func Test_HTML(t *testing.T) {
+	m, err := New(Options{Dir: writeFiles(t, site), Funcs: funcs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	m.HTML(w, http.StatusCreated, "plain", map[string]string{"Title": "x"})
+	if w.Code != http.StatusCreated || w.Body.String() != "<h1>X</h1>plain" ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("HTML = %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	var logs strings.Builder
+	m.opt.ErrorLog = log.New(&logs, "", 0)
+	w = httptest.NewRecorder()
+	m.HTML(w, http.StatusOK, "missing", nil)
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "missing") ||
+		!strings.Contains(logs.String(), "render missing") {
+		t.Errorf("HTML = %d %q, log %q", w.Code, w.Body.String(), logs.String())
+	}
+}
@@ -2,15 +2,33 @@ package main
 
 import (
 	"crypto/md5"
+	"errors"
+	"flag"
 	"fmt"
-	"html/template"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"astaxie/txt/config"
+	"astaxie/txt/view"
+)
+
+// loginConfig 可以写在 login.json 中，也可以用 LOGIN_ADDR 等环境变量或 -addr 等命令行参数设置
+type loginConfig struct {
+	Addr      string `json:"addr" default:":9090" validate:"required" usage:"监听的地址"`
+	UploadDir string `json:"uploadDir" default:"./test" validate:"required" usage:"上传文件保存的目录"`
+	Views     string `json:"views" default:"views" validate:"required" usage:"模板目录"`
+	Dev       bool   `json:"dev" usage:"开发模式，修改模板后自动重新加载"`
+}
+
+var (
+	cfg   loginConfig
+	views *view.Manager
 )
 
 func sayhelloName1(w http.ResponseWriter, r *http.Request) {
@@ -30,8 +48,7 @@ func sayhelloName1(w http.ResponseWriter, r *http.Request) {
 func login(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("method:", r.Method) //获取请求的方法
 	if r.Method == "GET" {
-		t, _ := template.ParseFiles("login.gtpl")
-		log.Println(t.Execute(w, nil))
+		views.HTML(w, http.StatusOK, "login", nil)
 	} else {
 		//请求的是登录数据，那么执行登录的逻辑判断
 		r.ParseForm()
@@ -49,8 +66,7 @@ func upload(w http.ResponseWriter, r *http.Request) {
 		io.WriteString(h, strconv.FormatInt(crutime, 10))
 		token := fmt.Sprintf("%x", h.Sum(nil))
 
-		t, _ := template.ParseFiles("upload.gtpl")
-		t.Execute(w, token)
+		views.HTML(w, http.StatusOK, "upload", token)
 	} else {
 		r.ParseMultipartForm(32 << 20)
 		file, handler, err := r.FormFile("uploadfile")
@@ -60,7 +76,7 @@ func upload(w http.ResponseWriter, r *http.Request) {
 		}
 		defer file.Close()
 		fmt.Fprintf(w, "%v", handler.Header)
-		f, err := os.OpenFile("./test/"+handler.Filename, os.O_WRONLY|os.O_CREATE, 0666) // 此处假设当前目录下已存在test目录
+		f, err := os.OpenFile(filepath.Join(cfg.UploadDir, filepath.Base(handler.Filename)), os.O_WRONLY|os.O_CREATE, 0666) // 此处假设上传目录已存在
 		if err != nil {
 			fmt.Println(err)
 			return
@@ -71,10 +87,28 @@ func upload(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	err := config.Load(&cfg, config.Options{
+		Name:         "login",
+		File:         "login.json",
+		FileOptional: true,
+		EnvPrefix:    "LOGIN_",
+		Args:         os.Args[1:],
+	})
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	views, err = view.New(view.Options{Dir: cfg.Views, Reload: cfg.Dev})
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	http.HandleFunc("/", sayhelloName1) //设置访问的路由
 	http.HandleFunc("/login", login)    //设置访问的路由
 	http.HandleFunc("/upload", upload)
-	err := http.ListenAndServe(":9090", nil) //设置监听的端口
+	err = http.ListenAndServe(cfg.Addr, nil) //设置监听的端口
 	if err != nil {
 		log.Fatal("ListenAndServe: ", err)
 	}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"astaxie/webservice/lbproxy"
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// 在 RestTest.go 的几个实例前面放一个负载均衡器:
+// LoadBalancer -backends http://127.0.0.1:8080,http://127.0.0.1:8081 -strategy lc
+func main() {
+	listen := flag.String("listen", ":8000", "listen address")
+	backends := flag.String("backends", "http://127.0.0.1:8080", "comma separated backend URLs")
+	strategy := flag.String("strategy", "rr", "balancing strategy: rr (round robin) or lc (least connections)")
+	healthPath := flag.String("health", "/", "health check path")
+	interval := flag.Duration("interval", 10*time.Second, "health check interval")
+	trusted := flag.String("trusted", "", "comma separated CIDRs of upstream proxies whose X-Forwarded-For is kept")
+	flag.Parse()
+
+	var balancer lbproxy.Balancer
+	switch *strategy {
+	case "rr":
+		balancer = &lbproxy.RoundRobin{}
+	case "lc":
+		balancer = lbproxy.LeastConnections{}
+	default:
+		log.Fatalf("unknown strategy %q", *strategy)
+	}
+
+	p, err := lbproxy.New(strings.Split(*backends, ","), balancer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p.HealthPath = *healthPath
+	p.HealthInterval = *interval
+	if *trusted != "" {
+		for _, cidr := range strings.Split(*trusted, ",") {
+			prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+			if err != nil {
+				log.Fatal(err)
+			}
+			p.TrustedProxies = append(p.TrustedProxies, prefix)
+		}
+	}
+	go p.Run(context.Background())
+
+	log.Fatal(http.ListenAndServe(*listen, p))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"astaxie/webservice/netaddr"
 	"fmt"
 	"net"
 	"os"
@@ -17,6 +18,8 @@ func main() {
 		fmt.Println("Invalid address")
 	} else {
 		fmt.Println("The address is ", addr.String())
+		a, _ := netaddr.ParseAddr(name)
+		fmt.Println("The address class is ", netaddr.Classify(a))
 	}
 	os.Exit(0)
 }
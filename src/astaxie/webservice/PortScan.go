@@ -0,0 +1,72 @@
+package main
+
+import (
+	"astaxie/webservice/netaddr"
+	"astaxie/webservice/portscan"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// 用法: PortScan -p 22,80,8000-8100 -json 127.0.0.1 192.168.1.0/24 example.com
+func main() {
+	ports := flag.String("p", "21,22,25,80,443,3306,6379,8080", "ports, e.g. 22,80,8000-8100")
+	workers := flag.Int("c", 100, "max concurrent connections")
+	timeout := flag.Duration("timeout", 2*time.Second, "dial timeout")
+	banner := flag.Duration("banner", 500*time.Millisecond, "banner read timeout, 0 to disable")
+	probe := flag.String("probe", "HEAD / HTTP/1.0\r\n\r\n", "data sent when the service does not speak first")
+	asJSON := flag.Bool("json", false, "output JSON instead of a table")
+	all := flag.Bool("all", false, "include closed ports in output")
+	flag.Parse()
+
+	checkError := func(err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Fatal error: %s\n", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] host|ip|cidr|ip-ip ...\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	portList, err := portscan.ParsePorts(*ports)
+	checkError(err)
+
+	var hosts []string
+	for _, arg := range flag.Args() {
+		r, err := netaddr.ParseRange(arg)
+		if err != nil {
+			// 不是 IP 地址，当作主机名
+			hosts = append(hosts, arg)
+			continue
+		}
+		addrs, err := r.Addrs(65536)
+		checkError(err)
+		for _, a := range addrs {
+			hosts = append(hosts, a.String())
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	s := portscan.Scanner{
+		Concurrency:   *workers,
+		Timeout:       *timeout,
+		BannerTimeout: *banner,
+		Probe:         []byte(*probe),
+	}
+	results := s.ScanAll(ctx, hosts, portList)
+	if *asJSON {
+		err = portscan.WriteJSON(os.Stdout, results, !*all)
+	} else {
+		err = portscan.WriteTable(os.Stdout, results, !*all)
+	}
+	checkError(err)
+}
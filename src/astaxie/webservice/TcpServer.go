@@ -1,18 +1,48 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log"
 	"net"
 	"os"
 	"time"
+
+	"astaxie/txt/config"
 )
 
+// daytimeConfig 中 Layout 修改 daytime.json 后立即生效，Addr 只在启动时使用
+type daytimeConfig struct {
+	Addr   string `json:"addr" default:":1200" validate:"required" usage:"监听的地址"`
+	Layout string `json:"layout" default:"2006-01-02 15:04:05.999999999 -0700 MST" usage:"时间的格式"`
+}
+
+var cfg *config.Config[daytimeConfig]
+
 func main() {
-	service := ":1200"
-	tcpAddr, err := net.ResolveTCPAddr("tcp4", service)
-	checkError(err)
+	var err error
+	cfg, err = config.New[daytimeConfig](config.Options{
+		Name:         "TcpServer",
+		File:         "daytime.json",
+		FileOptional: true,
+		EnvPrefix:    "DAYTIME_",
+		Args:         os.Args[1:],
+	})
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	checkError1(err)
+	cfg.OnReload(func(old, new *daytimeConfig) {
+		log.Println("config reloaded:", config.Changed(old, new))
+	})
+	go cfg.Watch(context.Background(), time.Second)
+
+	tcpAddr, err := net.ResolveTCPAddr("tcp4", cfg.Get().Addr)
+	checkError1(err)
 	listener, err := net.ListenTCP("tcp", tcpAddr)
-	checkError(err)
+	checkError1(err)
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
@@ -24,7 +54,7 @@ func main() {
 
 func handleClient(conn net.Conn) {
 	defer conn.Close()
-	daytime := time.Now().String()
+	daytime := time.Now().Format(cfg.Get().Layout)
 	conn.Write([]byte(daytime)) // don't care about return value
 	// we're finished with this client
 }
@@ -1,37 +1,39 @@
 package main
 
 import (
+	"astaxie/webservice/wsproto"
+	"encoding/json"
 	"fmt"
-	"golang.org/x/net/websocket"
 	"log"
 	"net/http"
+	"time"
 )
 
-func Echo(ws *websocket.Conn) {
-	var err error
-
-	for {
-		var reply string
-
-		if err = websocket.Message.Receive(ws, &reply); err != nil {
-			fmt.Println("Can't receive")
-			break
-		}
+func Echo(c *wsproto.Conn, payload json.RawMessage) (interface{}, error) {
+	var reply string
+	if err := json.Unmarshal(payload, &reply); err != nil {
+		return nil, err
+	}
 
-		fmt.Println("Received back from client: " + reply)
+	fmt.Println("Received back from client: " + reply)
+	return "Received:  " + reply, nil
+}
 
-		msg := "Received:  " + reply
-		fmt.Println("Sending to client: " + msg)
+func main() {
+	s := wsproto.NewServer()
+	s.Handle("echo", Echo)
+	s.OnConnect = func(c *wsproto.Conn) {
+		c.Push("welcome", "hello from server")
+	}
 
-		if err = websocket.Message.Send(ws, msg); err != nil {
-			fmt.Println("Can't send")
-			break
+	// 每隔5秒向所有客户端推送一次服务器时间
+	go func() {
+		for t := range time.Tick(5 * time.Second) {
+			s.Broadcast("time", t.Format(time.RFC3339))
 		}
-	}
-}
+	}()
 
-func main() {
-	http.Handle("/", websocket.Handler(Echo))
+	http.Handle("/", s)
 
 	if err := http.ListenAndServe(":1234", nil); err != nil {
 		log.Fatal("ListenAndServe:", err)
@@ -0,0 +1,63 @@
+// Package lbproxy 是一个带健康检查的 HTTP 反向代理和负载均衡器。
+package lbproxy
+
+import (
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"sync/atomic"
+)
+
+// Backend 是一个后端服务实例
+type Backend struct {
+	URL   *url.URL
+	proxy *httputil.ReverseProxy
+
+	active atomic.Int64 // 正在处理的请求数
+	alive  atomic.Bool
+
+	mu       sync.Mutex
+	failures int // 连续失败的健康检查次数
+	passes   int // 连续成功的健康检查次数
+}
+
+// Alive 返回后端当前是否被认为健康
+func (b *Backend) Alive() bool {
+	return b.alive.Load()
+}
+
+// Active 返回后端正在处理的请求数
+func (b *Backend) Active() int64 {
+	return b.active.Load()
+}
+
+// Balancer 从健康的后端中挑选一个处理请求
+type Balancer interface {
+	Next(backends []*Backend) *Backend
+}
+
+// RoundRobin 按顺序轮流选择后端
+type RoundRobin struct {
+	n atomic.Uint64
+}
+
+func (r *RoundRobin) Next(backends []*Backend) *Backend {
+	if len(backends) == 0 {
+		return nil
+	}
+	i := r.n.Add(1) - 1
+	return backends[i%uint64(len(backends))]
+}
+
+// LeastConnections 选择正在处理的请求最少的后端，相同时选择靠前的
+type LeastConnections struct{}
+
+func (LeastConnections) Next(backends []*Backend) *Backend {
+	var best *Backend
+	for _, b := range backends {
+		if best == nil || b.Active() < best.Active() {
+			best = b
+		}
+	}
+	return best
+}
@@ -0,0 +1,240 @@
+package lbproxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader 是用来串联日志的请求 ID 头
+const RequestIDHeader = "X-Request-Id"
+
+// Proxy 把请求转发到健康的后端
+type Proxy struct {
+	Balancer Balancer
+
+	// HealthPath 是健康检查请求的路径，默认 "/"
+	HealthPath string
+	// HealthInterval 是两次健康检查的间隔，默认 10 秒
+	HealthInterval time.Duration
+	// HealthTimeout 是单次健康检查的超时时间，默认 2 秒
+	HealthTimeout time.Duration
+	// UnhealthyThreshold 次连续失败后摘除后端，默认 2
+	UnhealthyThreshold int
+	// HealthyThreshold 次连续成功后恢复后端，默认 1
+	HealthyThreshold int
+
+	// TrustedProxies 是可信的上游代理的地址段。只有来自它们的请求会保留原有的 X-Forwarded-For，
+	// 其他客户端的会被丢弃，以免伪造来源地址。为空时不信任任何来源
+	TrustedProxies []netip.Prefix
+
+	// ErrorLog 为 nil 时使用 log 包的默认 Logger
+	ErrorLog *log.Logger
+
+	backends []*Backend
+	client   *http.Client
+}
+
+// New 为 targets 中的每个地址创建一个后端，balancer 为 nil 时使用轮询
+func New(targets []string, balancer Balancer) (*Proxy, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("lbproxy: no backends")
+	}
+	if balancer == nil {
+		balancer = &RoundRobin{}
+	}
+	p := &Proxy{Balancer: balancer, client: &http.Client{}}
+	for _, t := range targets {
+		u, err := url.Parse(strings.TrimSpace(t))
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("lbproxy: backend %q must be an absolute URL", t)
+		}
+		p.backends = append(p.backends, p.newBackend(u))
+	}
+	return p, nil
+}
+
+func (p *Proxy) newBackend(u *url.URL) *Backend {
+	b := &Backend{URL: u}
+	b.alive.Store(true)
+	b.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(u)
+			// 保留可信的上游代理已经写入的地址，SetXForwarded 会把客户端地址追加在后面
+			if p.trusted(r.In.RemoteAddr) {
+				r.Out.Header["X-Forwarded-For"] = r.In.Header["X-Forwarded-For"]
+			}
+			r.SetXForwarded()
+			r.Out.Header.Set(RequestIDHeader, r.In.Header.Get(RequestIDHeader))
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			p.logf("lbproxy: %s %s via %s: %v", r.Method, r.URL.Path, u.Host, err)
+			// 转发失败也算一次失败的检查，这样不用等到下一轮健康检查。
+			// 客户端断开导致的失败与后端无关，不计入
+			if !errors.Is(err, context.Canceled) && r.Context().Err() == nil {
+				p.record(b, false)
+			}
+			http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		},
+	}
+	return b
+}
+
+// trusted 判断 remoteAddr 是否来自 TrustedProxies
+func (p *Proxy) trusted(remoteAddr string) bool {
+	ap, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := ap.Addr().Unmap()
+	for _, prefix := range p.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backends 返回所有的后端，包括不健康的
+func (p *Proxy) Backends() []*Backend {
+	return p.backends
+}
+
+func (p *Proxy) alive() []*Backend {
+	var out []*Backend
+	for _, b := range p.backends {
+		if b.Alive() {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" {
+		id = newRequestID()
+		r.Header.Set(RequestIDHeader, id)
+	}
+	w.Header().Set(RequestIDHeader, id)
+
+	b := p.Balancer.Next(p.alive())
+	if b == nil {
+		http.Error(w, "no healthy backend", http.StatusServiceUnavailable)
+		return
+	}
+	b.active.Add(1)
+	defer b.active.Add(-1)
+	b.proxy.ServeHTTP(w, r)
+}
+
+// Run 周期性地检查所有后端，直到 ctx 被取消
+func (p *Proxy) Run(ctx context.Context) {
+	interval := p.HealthInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		p.CheckHealth(ctx)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CheckHealth 对所有后端做一次健康检查
+func (p *Proxy) CheckHealth(ctx context.Context) {
+	done := make(chan struct{})
+	for _, b := range p.backends {
+		go func(b *Backend) {
+			p.record(b, p.check(ctx, b))
+			done <- struct{}{}
+		}(b)
+	}
+	for range p.backends {
+		<-done
+	}
+}
+
+func (p *Proxy) check(ctx context.Context, b *Backend) bool {
+	timeout := p.HealthTimeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	path := p.HealthPath
+	if path == "" {
+		path = "/"
+	}
+	u := b.URL.JoinPath(path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 400
+}
+
+func (p *Proxy) record(b *Backend, ok bool) {
+	down, up := p.UnhealthyThreshold, p.HealthyThreshold
+	if down <= 0 {
+		down = 2
+	}
+	if up <= 0 {
+		up = 1
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ok {
+		b.failures = 0
+		b.passes++
+		if !b.Alive() && b.passes >= up {
+			b.alive.Store(true)
+			p.logf("lbproxy: backend %s is up", b.URL.Host)
+		}
+		return
+	}
+	b.passes = 0
+	b.failures++
+	if b.Alive() && b.failures >= down {
+		b.alive.Store(false)
+		p.logf("lbproxy: backend %s is down", b.URL.Host)
+	}
+}
+
+func (p *Proxy) logf(format string, args ...interface{}) {
+	if p.ErrorLog != nil {
+		p.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,166 @@
+package lbproxy
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type backendServer struct {
+	*httptest.Server
+	name    string
+	healthy atomic.Bool
+}
+
+func newBackendServer(t *testing.T, name string) *backendServer {
+	s := &backendServer{name: name}
+	s.healthy.Store(true)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			if !s.healthy.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		w.Header().Set("X-Seen-For", r.Header.Get("X-Forwarded-For"))
+		w.Header().Set("X-Seen-Id", r.Header.Get(RequestIDHeader))
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func get(t *testing.T, h http.Handler, hdr map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/user/1", nil)
+	r.RemoteAddr = "10.0.0.9:5555"
+	for k, v := range hdr {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func Test_RoundRobin(t *testing.T) {
+	a, b := newBackendServer(t, "a"), newBackendServer(t, "b")
+	p, err := New([]string{a.URL, b.URL}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	for i := 0; i < 4; i++ {
+		got += get(t, p, nil).Body.String()
+	}
+	if got != "abab" {
+		t.Errorf("round robin order = %q", got)
+	}
+}
+
+func Test_Headers(t *testing.T) {
+	a := newBackendServer(t, "a")
+	p, _ := New([]string{a.URL}, nil)
+
+	// 不可信的客户端写入的 X-Forwarded-For 被丢弃
+	w := get(t, p, map[string]string{"X-Forwarded-For": "1.2.3.4"})
+	if f := w.Header().Get("X-Seen-For"); f != "10.0.0.9" {
+		t.Errorf("untrusted X-Forwarded-For = %q", f)
+	}
+	p.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	w = get(t, p, map[string]string{"X-Forwarded-For": "1.2.3.4"})
+	if f := w.Header().Get("X-Seen-For"); f != "1.2.3.4, 10.0.0.9" {
+		t.Errorf("trusted X-Forwarded-For = %q", f)
+	}
+	id := w.Header().Get(RequestIDHeader)
+	if len(id) != 32 || w.Header().Get("X-Seen-Id") != id {
+		t.Errorf("request id = %q, backend saw %q", id, w.Header().Get("X-Seen-Id"))
+	}
+
+	w = get(t, p, map[string]string{RequestIDHeader: "abc"})
+	if w.Header().Get("X-Seen-Id") != "abc" {
+		t.Errorf("incoming request id not kept: %q", w.Header().Get("X-Seen-Id"))
+	}
+}
+
+func Test_HealthCheck(t *testing.T) {
+	a, b := newBackendServer(t, "a"), newBackendServer(t, "b")
+	p, _ := New([]string{a.URL, b.URL}, nil)
+	p.HealthPath = "/health"
+	p.UnhealthyThreshold = 1
+	p.ErrorLog = log.New(io.Discard, "", 0)
+
+	b.healthy.Store(false)
+	p.CheckHealth(context.Background())
+	if p.Backends()[1].Alive() {
+		t.Fatal("b should be marked down")
+	}
+	for i := 0; i < 3; i++ {
+		if body := get(t, p, nil).Body.String(); body != "a" {
+			t.Errorf("request %d went to %q", i, body)
+		}
+	}
+
+	b.healthy.Store(true)
+	p.CheckHealth(context.Background())
+	if !p.Backends()[1].Alive() {
+		t.Fatal("b should be back up")
+	}
+
+	a.healthy.Store(false)
+	b.healthy.Store(false)
+	p.CheckHealth(context.Background())
+	if w := get(t, p, nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no healthy backends: code %d", w.Code)
+	}
+}
+
+func Test_ClientCancel(t *testing.T) {
+	a := newBackendServer(t, "a")
+	p, _ := New([]string{a.URL}, nil)
+	p.UnhealthyThreshold = 1
+	p.ErrorLog = log.New(io.Discard, "", 0)
+
+	// 客户端已经断开的请求转发失败，但后端没有问题
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	p.ServeHTTP(httptest.NewRecorder(), r)
+	if !p.Backends()[0].Alive() {
+		t.Error("backend marked down by a canceled client request")
+	}
+}
+
+func Test_LeastConnections(t *testing.T) {
+	release := make(chan struct{})
+	var once sync.Once
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, "slow")
+	}))
+	defer slow.Close()
+	defer once.Do(func() { close(release) })
+	fast := newBackendServer(t, "fast")
+
+	p, _ := New([]string{slow.URL, fast.URL}, LeastConnections{})
+	done := make(chan string)
+	go func() { done <- get(t, p, nil).Body.String() }()
+	// 等第一个请求被分配到 slow
+	for p.Backends()[0].Active() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 3; i++ {
+		if body := get(t, p, nil).Body.String(); body != "fast" {
+			t.Errorf("request %d went to %q", i, body)
+		}
+	}
+	once.Do(func() { close(release) })
+	if body := <-done; body != "slow" {
+		t.Errorf("first request went to %q", body)
+	}
+}
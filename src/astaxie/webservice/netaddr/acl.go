@@ -0,0 +1,119 @@
+package netaddr
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// ACL 是由允许列表和拒绝列表组成的访问控制表。
+// 拒绝列表优先；允许列表为空时除拒绝列表外的地址都被允许，否则只允许命中允许列表的地址。
+type ACL struct {
+	mu    sync.RWMutex
+	allow []netip.Prefix
+	deny  []netip.Prefix
+
+	// TrustedProxies 中的地址发来的请求会使用 X-Forwarded-For 中最右边的非代理地址作为客户端地址
+	TrustedProxies []netip.Prefix
+}
+
+func NewACL() *ACL {
+	return &ACL{}
+}
+
+// Allow 添加允许的地址或 CIDR
+func (l *ACL) Allow(cidrs ...string) error {
+	ps, err := parsePrefixes(cidrs)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.allow = append(l.allow, ps...)
+	l.mu.Unlock()
+	return nil
+}
+
+// Deny 添加拒绝的地址或 CIDR
+func (l *ACL) Deny(cidrs ...string) error {
+	ps, err := parsePrefixes(cidrs)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.deny = append(l.deny, ps...)
+	l.mu.Unlock()
+	return nil
+}
+
+func parsePrefixes(ss []string) ([]netip.Prefix, error) {
+	ps := make([]netip.Prefix, 0, len(ss))
+	for _, s := range ss {
+		p, err := ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// Allowed 判断地址是否被允许访问
+func (l *ACL) Allowed(a netip.Addr) bool {
+	a = a.Unmap()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if matchAny(l.deny, a) {
+		return false
+	}
+	return len(l.allow) == 0 || matchAny(l.allow, a)
+}
+
+func matchAny(ps []netip.Prefix, a netip.Addr) bool {
+	for _, p := range ps {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientAddr 取出请求的客户端地址
+func (l *ACL) ClientAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	a, err := ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	if !matchAny(l.TrustedProxies, a) {
+		return a, true
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		h, err := ParseAddr(hops[i])
+		if err != nil {
+			break
+		}
+		a = h
+		if !matchAny(l.TrustedProxies, h) {
+			break
+		}
+	}
+	return a, true
+}
+
+// Middleware 拒绝不被允许的客户端，返回 403
+func (l *ACL) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a, ok := l.ClientAddr(r)
+		if !ok || !l.Allowed(a) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
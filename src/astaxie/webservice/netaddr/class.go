@@ -0,0 +1,86 @@
+package netaddr
+
+import "net/netip"
+
+// Class 是地址的用途分类
+type Class int
+
+const (
+	Public Class = iota
+	Unspecified
+	Loopback
+	Private
+	LinkLocal
+	Multicast
+	Reserved
+)
+
+var classNames = [...]string{
+	Public:      "public",
+	Unspecified: "unspecified",
+	Loopback:    "loopback",
+	Private:     "private",
+	LinkLocal:   "link-local",
+	Multicast:   "multicast",
+	Reserved:    "reserved",
+}
+
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return "unknown"
+	}
+	return classNames[c]
+}
+
+// 不能在公网上路由的特殊用途地址段（RFC 6890 等），私有、环回等已经由 netip 判断的段不在此列
+var reserved = mustPrefixes(
+	"0.0.0.0/8",          // 本网络
+	"100.64.0.0/10",      // 运营商级 NAT
+	"192.0.0.0/24",       // IETF 协议分配
+	"192.0.2.0/24",       // 文档 TEST-NET-1
+	"198.18.0.0/15",      // 基准测试
+	"198.51.100.0/24",    // 文档 TEST-NET-2
+	"203.0.113.0/24",     // 文档 TEST-NET-3
+	"240.0.0.0/4",        // 保留
+	"255.255.255.255/32", // 广播
+	"64:ff9b:1::/48",     // 本地 IPv4/IPv6 转换
+	"100::/64",           // 丢弃
+	"2001::/23",          // IETF 协议分配
+	"2001:db8::/32",      // 文档
+)
+
+func mustPrefixes(ss ...string) []netip.Prefix {
+	ps := make([]netip.Prefix, len(ss))
+	for i, s := range ss {
+		ps[i] = netip.MustParsePrefix(s)
+	}
+	return ps
+}
+
+// Classify 返回地址的分类
+func Classify(a netip.Addr) Class {
+	a = a.Unmap()
+	switch {
+	case a.IsUnspecified():
+		return Unspecified
+	case a.IsLoopback():
+		return Loopback
+	case a.IsPrivate():
+		return Private
+	case a.IsLinkLocalUnicast(), a.IsLinkLocalMulticast():
+		return LinkLocal
+	case a.IsMulticast():
+		return Multicast
+	}
+	for _, p := range reserved {
+		if p.Contains(a) {
+			return Reserved
+		}
+	}
+	return Public
+}
+
+// IsPublic 判断地址是否是公网可路由的单播地址
+func IsPublic(a netip.Addr) bool {
+	return a.IsValid() && Classify(a) == Public
+}
@@ -0,0 +1,155 @@
+// Package netaddr 提供 IP 地址校验、CIDR 解析、地址段展开和地址分类等工具。
+package netaddr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ErrTooLarge 在展开的地址数量超过上限时返回
+var ErrTooLarge = errors.New("netaddr: range too large")
+
+// IsIPv4 判断 s 是否是点分十进制的 IPv4 地址，每段必须在 0-255 之间且不能有前导 0
+func IsIPv4(s string) bool {
+	a, err := netip.ParseAddr(s)
+	return err == nil && a.Is4()
+}
+
+// IsIPv6 判断 s 是否是 IPv6 地址（包括 ::ffff:1.2.3.4 这种 IPv4 映射的写法）
+func IsIPv6(s string) bool {
+	a, err := netip.ParseAddr(s)
+	return err == nil && a.Is6()
+}
+
+// IsIP 判断 s 是否是 IPv4 或 IPv6 地址
+func IsIP(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// ParseAddr 解析 IP 地址，IPv4 映射的 IPv6 地址会被转换成 IPv4
+func ParseAddr(s string) (netip.Addr, error) {
+	a, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return a.Unmap(), nil
+}
+
+// ParsePrefix 解析 CIDR，例如 "10.0.0.0/8"。单独的地址被当作 /32 或 /128。
+// 返回的前缀已经去掉了主机位，所以 "10.1.2.3/8" 得到 10.0.0.0/8。
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		a, err := ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(a, a.BitLen()), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return p.Masked(), nil
+}
+
+// Contains 判断 cidr 是否包含 ip
+func Contains(cidr, ip string) (bool, error) {
+	p, err := ParsePrefix(cidr)
+	if err != nil {
+		return false, err
+	}
+	a, err := ParseAddr(ip)
+	if err != nil {
+		return false, err
+	}
+	return p.Contains(a), nil
+}
+
+// Range 是一段连续的地址 [From, To]
+type Range struct {
+	From, To netip.Addr
+}
+
+// ParseRange 解析 "10.0.0.1-10.0.0.9"、CIDR 或单个地址
+func ParseRange(s string) (Range, error) {
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		a, err := ParseAddr(from)
+		if err != nil {
+			return Range{}, err
+		}
+		b, err := ParseAddr(to)
+		if err != nil {
+			return Range{}, err
+		}
+		if a.Is4() != b.Is4() || b.Less(a) {
+			return Range{}, fmt.Errorf("netaddr: invalid range %q", s)
+		}
+		return Range{a, b}, nil
+	}
+	p, err := ParsePrefix(s)
+	if err != nil {
+		return Range{}, err
+	}
+	return PrefixRange(p), nil
+}
+
+// PrefixRange 返回前缀覆盖的地址段
+func PrefixRange(p netip.Prefix) Range {
+	p = p.Masked()
+	from := p.Addr()
+	to := from
+	bits := from.BitLen() - p.Bits()
+	b := to.AsSlice()
+	for i := len(b) - 1; bits > 0; i-- {
+		n := bits
+		if n > 8 {
+			n = 8
+		}
+		b[i] |= byte(1<<n - 1)
+		bits -= n
+	}
+	to, _ = netip.AddrFromSlice(b)
+	return Range{from, to}
+}
+
+// Contains 判断 a 是否在地址段内
+func (r Range) Contains(a netip.Addr) bool {
+	a = a.Unmap()
+	return a.BitLen() == r.From.BitLen() && r.From.Compare(a) <= 0 && a.Compare(r.To) <= 0
+}
+
+// Addrs 展开地址段，地址数量超过 limit 时返回 ErrTooLarge
+func (r Range) Addrs(limit int) ([]netip.Addr, error) {
+	var out []netip.Addr
+	for a := r.From; a.IsValid() && a.Compare(r.To) <= 0; a = a.Next() {
+		if len(out) == limit {
+			return nil, ErrTooLarge
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// Hosts 展开 CIDR 中可分配给主机的地址：IPv4 的 /30 及更大的网段会去掉网络地址和广播地址
+func Hosts(cidr string, limit int) ([]netip.Addr, error) {
+	p, err := ParsePrefix(cidr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := PrefixRange(p).Addrs(limit)
+	if err != nil {
+		return nil, err
+	}
+	if p.Addr().Is4() && p.Bits() <= 30 {
+		addrs = addrs[1 : len(addrs)-1]
+	}
+	return addrs, nil
+}
+
+func (r Range) String() string {
+	return r.From.String() + "-" + r.To.String()
+}
@@ -0,0 +1,123 @@
+package netaddr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func Test_IsIP(t *testing.T) {
+	cases := []struct {
+		s        string
+		ip4, ip6 bool
+	}{
+		{"127.0.0.1", true, false},
+		{"255.255.255.255", true, false},
+		{"999.999.999.999", false, false},
+		{"1.2.3", false, false},
+		{"01.2.3.4", false, false},
+		{"::1", false, true},
+		{"2001:db8::1", false, true},
+		{"abc", false, false},
+	}
+	for _, c := range cases {
+		if IsIPv4(c.s) != c.ip4 || IsIPv6(c.s) != c.ip6 || IsIP(c.s) != (c.ip4 || c.ip6) {
+			t.Errorf("%q: IsIPv4=%v IsIPv6=%v", c.s, IsIPv4(c.s), IsIPv6(c.s))
+		}
+	}
+}
+
+func Test_Prefix(t *testing.T) {
+	p, err := ParsePrefix("10.1.2.3/8")
+	if err != nil || p.String() != "10.0.0.0/8" {
+		t.Errorf("ParsePrefix = %v, %v", p, err)
+	}
+	if ok, _ := Contains("192.168.0.0/16", "192.168.10.1"); !ok {
+		t.Error("192.168.0.0/16 should contain 192.168.10.1")
+	}
+	if ok, _ := Contains("192.168.0.0/16", "::ffff:10.0.0.1"); ok {
+		t.Error("192.168.0.0/16 should not contain 10.0.0.1")
+	}
+	if r := PrefixRange(netip.MustParsePrefix("10.0.0.0/22")); r.String() != "10.0.0.0-10.0.3.255" {
+		t.Errorf("PrefixRange = %v", r)
+	}
+}
+
+func Test_Expand(t *testing.T) {
+	hosts, err := Hosts("192.168.1.0/30", 10)
+	if err != nil || len(hosts) != 2 || hosts[0].String() != "192.168.1.1" {
+		t.Errorf("Hosts = %v, %v", hosts, err)
+	}
+	r, err := ParseRange("10.0.0.250-10.0.1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, _ := r.Addrs(100)
+	if len(addrs) != 9 || addrs[8].String() != "10.0.1.2" {
+		t.Errorf("Addrs = %v", addrs)
+	}
+	if _, err := r.Addrs(5); err != ErrTooLarge {
+		t.Errorf("Addrs(5) err = %v", err)
+	}
+	if _, err := ParseRange("10.0.0.9-10.0.0.1"); err == nil {
+		t.Error("reversed range should fail")
+	}
+}
+
+func Test_Classify(t *testing.T) {
+	cases := map[string]Class{
+		"8.8.8.8":        Public,
+		"127.0.0.1":      Loopback,
+		"10.1.1.1":       Private,
+		"172.16.0.1":     Private,
+		"169.254.1.1":    LinkLocal,
+		"239.1.1.1":      Multicast,
+		"100.64.0.1":     Reserved,
+		"192.0.2.1":      Reserved,
+		"0.0.0.0":        Unspecified,
+		"::1":            Loopback,
+		"fd00::1":        Private,
+		"2001:db8::1":    Reserved,
+		"2606:4700::1":   Public,
+		"::ffff:8.8.4.4": Public,
+	}
+	for s, want := range cases {
+		if got := Classify(netip.MustParseAddr(s)); got != want {
+			t.Errorf("Classify(%s) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func Test_ACLMiddleware(t *testing.T) {
+	acl := NewACL()
+	acl.Allow("10.0.0.0/8", "::1")
+	acl.Deny("10.0.0.66")
+	acl.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}
+
+	h := acl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	cases := []struct {
+		remote, xff string
+		code        int
+	}{
+		{"10.1.1.1:1234", "", 200},
+		{"10.0.0.66:1234", "", 403},
+		{"8.8.8.8:1234", "", 403},
+		{"[::1]:1234", "", 200},
+		{"127.0.0.1:1234", "8.8.8.8, 10.2.2.2", 200},
+		{"127.0.0.1:1234", "10.2.2.2, 8.8.8.8", 403},
+		{"8.8.8.8:1234", "10.2.2.2", 403}, // 不信任的代理
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remote
+		if c.xff != "" {
+			r.Header.Set("X-Forwarded-For", c.xff)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("%s xff=%q: code %d, want %d", c.remote, c.xff, w.Code, c.code)
+		}
+	}
+}
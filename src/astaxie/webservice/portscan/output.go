@@ -0,0 +1,42 @@
+package portscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable 以对齐的表格输出结果，openOnly 为 true 时只输出开放的端口
+func WriteTable(w io.Writer, rs []Result, openOnly bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tPORT\tSTATE\tLATENCY\tBANNER")
+	for _, r := range rs {
+		if openOnly && !r.Open {
+			continue
+		}
+		state := "open"
+		if !r.Open {
+			state = r.Err
+		}
+		banner, _, _ := strings.Cut(r.Banner, "\n")
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", r.Host, r.Port, state, r.Latency.Round(time.Microsecond), banner)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON 以 JSON 数组输出结果
+func WriteJSON(w io.Writer, rs []Result, openOnly bool) error {
+	out := make([]Result, 0, len(rs))
+	for _, r := range rs {
+		if openOnly && !r.Open {
+			continue
+		}
+		out = append(out, r)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,235 @@
+// Package portscan 并发地探测主机端口是否在监听，并读取服务的欢迎信息（banner）。
+package portscan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Result 是一个端口的探测结果
+type Result struct {
+	Host    string        `json:"host"`
+	Port    int           `json:"port"`
+	Open    bool          `json:"open"`
+	Banner  string        `json:"banner,omitempty"`
+	Latency time.Duration `json:"latency_ns"`
+	Err     string        `json:"error,omitempty"`
+}
+
+func (r Result) Addr() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+// Scanner 保存探测参数，零值可以直接使用
+type Scanner struct {
+	// Concurrency 是同时进行的连接数上限，默认 100
+	Concurrency int
+	// Timeout 是建立连接的超时时间，默认 2 秒
+	Timeout time.Duration
+	// BannerTimeout 是等待服务端发送数据的时间，为 0 时不读取 banner
+	BannerTimeout time.Duration
+	// Probe 在服务端没有主动发送数据时写入连接，例如 "HEAD / HTTP/1.0\r\n\r\n"
+	Probe []byte
+	// BannerSize 是 banner 最多读取的字节数，默认 256
+	BannerSize int
+}
+
+func (s *Scanner) concurrency() int {
+	if s.Concurrency > 0 {
+		return s.Concurrency
+	}
+	return 100
+}
+
+func (s *Scanner) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return 2 * time.Second
+}
+
+// Scan 探测 hosts 和 ports 的所有组合，结果按完成顺序发送到返回的信道，全部完成后信道被关闭。
+// ctx 取消后不再发起新的连接。
+func (s *Scanner) Scan(ctx context.Context, hosts []string, ports []int) <-chan Result {
+	jobs := make(chan Result)
+	results := make(chan Result)
+
+	go func() {
+		defer close(jobs)
+		for _, h := range hosts {
+			for _, p := range ports {
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case jobs <- Result{Host: h, Port: p}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case results <- s.probe(ctx, r):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// ScanAll 和 Scan 一样，但是等待所有结果并按主机、端口排序后返回
+func (s *Scanner) ScanAll(ctx context.Context, hosts []string, ports []int) []Result {
+	var out []Result
+	for r := range s.Scan(ctx, hosts, ports) {
+		out = append(out, r)
+	}
+	Sort(out)
+	return out
+}
+
+func (s *Scanner) probe(ctx context.Context, r Result) Result {
+	d := net.Dialer{Timeout: s.timeout()}
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", r.Addr())
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Err = errString(err)
+		return r
+	}
+	defer conn.Close()
+	r.Open = true
+	if s.BannerTimeout > 0 {
+		r.Banner = s.grab(conn)
+	}
+	return r
+}
+
+func (s *Scanner) grab(conn net.Conn) string {
+	size := s.BannerSize
+	if size <= 0 {
+		size = 256
+	}
+	buf := make([]byte, size)
+
+	// 先等服务端主动说话（SSH、SMTP 之类），没有数据再发送探测内容
+	conn.SetReadDeadline(time.Now().Add(s.BannerTimeout))
+	n, err := conn.Read(buf)
+	if n == 0 && isTimeout(err) && len(s.Probe) > 0 {
+		conn.SetDeadline(time.Now().Add(s.BannerTimeout))
+		if _, err = conn.Write(s.Probe); err == nil {
+			n, _ = conn.Read(buf)
+		}
+	}
+	return clean(buf[:n])
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// errString 把常见的错误缩短成适合表格显示的形式
+func errString(err error) string {
+	switch {
+	case isTimeout(err):
+		return "timeout"
+	case strings.Contains(err.Error(), "connection refused"):
+		return "refused"
+	}
+	return err.Error()
+}
+
+// clean 去掉 banner 中不可打印的字符
+func clean(b []byte) string {
+	b = bytes.TrimSpace(b)
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || unicode.IsPrint(r) {
+			return r
+		}
+		if r == '\r' {
+			return -1
+		}
+		return '.'
+	}, string(b))
+}
+
+// Sort 按主机、端口排序
+func Sort(rs []Result) {
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Host != rs[j].Host {
+			return rs[i].Host < rs[j].Host
+		}
+		return rs[i].Port < rs[j].Port
+	})
+}
+
+// ParsePorts 解析 "22,80,8000-8100" 这样的端口列表，结果去重并排序
+func ParsePorts(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := parsePort(lo)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = parsePort(hi); err != nil {
+				return nil, err
+			}
+			if to < from {
+				return nil, fmt.Errorf("portscan: invalid port range %q", part)
+			}
+		}
+		for p := from; p <= to; p++ {
+			seen[p] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, errors.New("portscan: no ports")
+	}
+	ports := make([]int, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("portscan: invalid port %q", s)
+	}
+	return p, nil
+}
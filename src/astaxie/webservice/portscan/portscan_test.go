@@ -0,0 +1,112 @@
+package portscan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen 启动一个本地监听，greeting 不为空时连接后立即发送，否则回显收到的第一段数据
+func listen(t *testing.T, greeting string) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if greeting != "" {
+					conn.Write([]byte(greeting))
+					return
+				}
+				buf := make([]byte, 64)
+				n, _ := conn.Read(buf)
+				conn.Write(buf[:n])
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort 返回一个当前没有监听的端口
+func closedPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return p
+}
+
+func Test_ParsePorts(t *testing.T) {
+	ports, err := ParsePorts("80, 22,8000-8002,80")
+	if err != nil || len(ports) != 5 || ports[0] != 22 || ports[4] != 8002 {
+		t.Errorf("ParsePorts = %v, %v", ports, err)
+	}
+	for _, bad := range []string{"0", "70000", "9-1", "a", ""} {
+		if _, err := ParsePorts(bad); err == nil {
+			t.Errorf("ParsePorts(%q) should fail", bad)
+		}
+	}
+}
+
+func Test_Scan(t *testing.T) {
+	ssh := listen(t, "SSH-2.0-OpenSSH_9.0\r\n")
+	echo := listen(t, "")
+	closed := closedPort(t)
+
+	s := Scanner{Concurrency: 2, Timeout: time.Second, BannerTimeout: 200 * time.Millisecond, Probe: []byte("HEAD / HTTP/1.0\r\n\r\n")}
+	rs := s.ScanAll(context.Background(), []string{"127.0.0.1"}, []int{ssh, echo, closed})
+	if len(rs) != 3 {
+		t.Fatalf("got %d results", len(rs))
+	}
+	byPort := make(map[int]Result)
+	for _, r := range rs {
+		byPort[r.Port] = r
+	}
+	if r := byPort[ssh]; !r.Open || r.Banner != "SSH-2.0-OpenSSH_9.0" {
+		t.Errorf("ssh result = %+v", r)
+	}
+	if r := byPort[echo]; !r.Open || r.Banner != "HEAD / HTTP/1.0" {
+		t.Errorf("echo result = %+v", r)
+	}
+	if r := byPort[closed]; r.Open || r.Err == "" {
+		t.Errorf("closed result = %+v", r)
+	}
+
+	var buf bytes.Buffer
+	WriteTable(&buf, rs, true)
+	if strings.Count(buf.String(), "\n") != 3 || !strings.Contains(buf.String(), "SSH-2.0") {
+		t.Errorf("table:\n%s", buf.String())
+	}
+	buf.Reset()
+	WriteJSON(&buf, rs, false)
+	var decoded []Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 3 {
+		t.Errorf("json = %s, %v", buf.String(), err)
+	}
+}
+
+func Test_ScanCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := Scanner{Concurrency: 4}
+	n := 0
+	for range s.Scan(ctx, []string{"127.0.0.1"}, []int{1, 2, 3, 4, 5, 6, 7, 8}) {
+		n++
+	}
+	if n != 0 {
+		t.Errorf("cancelled scan returned %d results", n)
+	}
+}
@@ -0,0 +1,73 @@
+package wsproto
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// Server 接受 websocket 连接，用同一个 Mux 处理所有连接上的消息，并且可以向所有连接推送事件
+type Server struct {
+	*Mux
+
+	// OnConnect 在新连接开始读取消息之前调用，可以为 nil
+	OnConnect func(c *Conn)
+	// OnDisconnect 在连接关闭之后调用，可以为 nil
+	OnDisconnect func(c *Conn, err error)
+
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+func NewServer() *Server {
+	return &Server{Mux: NewMux(), conns: make(map[*Conn]struct{})}
+}
+
+// ServeHTTP 使 Server 可以直接注册到 http.Handle
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Handler(s.serveWS).ServeHTTP(w, r)
+}
+
+func (s *Server) serveWS(ws *websocket.Conn) {
+	c := NewConn(ws, s.Mux)
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+
+	if s.OnConnect != nil {
+		s.OnConnect(c)
+	}
+	err := c.Serve()
+
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+	if s.OnDisconnect != nil {
+		s.OnDisconnect(c, err)
+	}
+}
+
+// Len 返回当前的连接数
+func (s *Server) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Broadcast 向所有连接推送一个事件，返回推送失败的连接数
+func (s *Server) Broadcast(typ string, v interface{}) (failed int) {
+	s.mu.Lock()
+	conns := make([]*Conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		if err := c.Push(typ, v); err != nil {
+			failed++
+		}
+	}
+	return
+}
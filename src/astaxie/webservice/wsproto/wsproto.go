@@ -0,0 +1,260 @@
+// Package wsproto 在 golang.org/x/net/websocket 之上实现一个简单的 JSON 消息协议。
+//
+// 每条消息都是一个 Envelope：
+//
+//	{"type": "echo", "id": "1", "payload": {...}}
+//
+// 带 id 的消息是请求，对端必须用相同的 id 回复一条 type 为 "result" 或 "error" 的消息；
+// 不带 id 的消息是事件（例如服务器主动推送），不需要回复。
+package wsproto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// 回复消息使用的保留类型
+const (
+	TypeResult = "result"
+	TypeError  = "error"
+)
+
+// ErrClosed 在连接关闭后发送或等待回复时返回
+var ErrClosed = errors.New("wsproto: connection closed")
+
+// Envelope 是线上传输的消息格式
+type Envelope struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// RemoteError 是对端处理请求失败时返回的错误
+type RemoteError struct {
+	Type    string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("wsproto: %s: %s", e.Type, e.Message)
+}
+
+// HandlerFunc 处理一条消息。对于请求，返回值会被编码为回复的 payload；对于事件，返回值被忽略。
+type HandlerFunc func(c *Conn, payload json.RawMessage) (interface{}, error)
+
+// Mux 是按消息类型注册的处理器表
+type Mux struct {
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+func NewMux() *Mux {
+	return &Mux{handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle 为消息类型 typ 注册处理器，重复注册会覆盖之前的处理器
+func (m *Mux) Handle(typ string, h HandlerFunc) {
+	if typ == TypeResult || typ == TypeError {
+		panic("wsproto: reserved message type " + typ)
+	}
+	m.mu.Lock()
+	m.handlers[typ] = h
+	m.mu.Unlock()
+}
+
+func (m *Mux) lookup(typ string) (HandlerFunc, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	h, ok := m.handlers[typ]
+	return h, ok
+}
+
+// Conn 是一条双向的消息连接，服务端和客户端共用
+type Conn struct {
+	ws  *websocket.Conn
+	mux *Mux
+
+	wmu sync.Mutex // 保证同一时刻只有一个写者
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[string]chan Envelope
+	closed  bool
+	done    chan struct{}
+}
+
+// NewConn 包装一个已经建立的 websocket 连接，mux 可以为 nil
+func NewConn(ws *websocket.Conn, mux *Mux) *Conn {
+	if mux == nil {
+		mux = NewMux()
+	}
+	return &Conn{
+		ws:      ws,
+		mux:     mux,
+		pending: make(map[string]chan Envelope),
+		done:    make(chan struct{}),
+	}
+}
+
+// Dial 连接到 url 并返回已经开始读取消息的 Conn
+func Dial(url, origin string, mux *Mux) (*Conn, error) {
+	ws, err := websocket.Dial(url, "", origin)
+	if err != nil {
+		return nil, err
+	}
+	c := NewConn(ws, mux)
+	go c.Serve()
+	return c, nil
+}
+
+// Done 在连接关闭后被关闭
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Serve 循环读取消息并分发，直到连接出错或关闭。
+// 不能解码的消息不会关闭连接，而是回复一条没有 id 的 "error" 消息后继续读取。
+func (c *Conn) Serve() error {
+	defer c.Close()
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(c.ws, &data); err != nil {
+			return err
+		}
+		var env Envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			c.write(Envelope{Type: TypeError, Error: "invalid message: " + err.Error()})
+			continue
+		}
+		if env.Type == TypeResult || env.Type == TypeError {
+			c.deliver(env)
+			continue
+		}
+		// 处理器可能会发起新的请求，所以不能在读循环里同步执行
+		go c.dispatch(env)
+	}
+}
+
+func (c *Conn) deliver(env Envelope) {
+	c.mu.Lock()
+	ch, ok := c.pending[env.ID]
+	delete(c.pending, env.ID)
+	c.mu.Unlock()
+	if ok {
+		ch <- env
+	}
+}
+
+func (c *Conn) dispatch(env Envelope) {
+	h, ok := c.mux.lookup(env.Type)
+	if !ok {
+		if env.ID != "" {
+			c.write(Envelope{Type: TypeError, ID: env.ID, Error: "unknown message type " + strconv.Quote(env.Type)})
+		}
+		return
+	}
+	v, err := h(c, env.Payload)
+	if env.ID == "" {
+		return
+	}
+	reply := Envelope{Type: TypeResult, ID: env.ID}
+	if err == nil {
+		reply.Payload, err = marshal(v)
+	}
+	if err != nil {
+		reply = Envelope{Type: TypeError, ID: env.ID, Error: err.Error()}
+	}
+	c.write(reply)
+}
+
+// Push 发送一条不需要回复的事件
+func (c *Conn) Push(typ string, v interface{}) error {
+	payload, err := marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.write(Envelope{Type: typ, Payload: payload})
+}
+
+// Call 发送一个请求并等待对端回复，结果解码到 out（out 为 nil 时丢弃结果）
+func (c *Conn) Call(ctx context.Context, typ string, v, out interface{}) error {
+	payload, err := marshal(v)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan Envelope, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.nextID++
+	id := strconv.FormatUint(c.nextID, 10)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.write(Envelope{Type: typ, ID: id, Payload: payload}); err != nil {
+		c.forget(id)
+		return err
+	}
+
+	select {
+	case reply := <-ch:
+		if reply.Type == TypeError {
+			return &RemoteError{Type: typ, Message: reply.Error}
+		}
+		if out == nil || len(reply.Payload) == 0 {
+			return nil
+		}
+		return json.Unmarshal(reply.Payload, out)
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		c.forget(id)
+		return ctx.Err()
+	}
+}
+
+func (c *Conn) forget(id string) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *Conn) write(env Envelope) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return websocket.JSON.Send(c.ws, env)
+}
+
+// Close 关闭底层连接，所有等待回复的 Call 都会返回 ErrClosed
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.pending = make(map[string]chan Envelope)
+	close(c.done)
+	c.mu.Unlock()
+	return c.ws.Close()
+}
+
+func marshal(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return json.Marshal(v)
+}
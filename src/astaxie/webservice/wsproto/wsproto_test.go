@@ -0,0 +1,129 @@
+package wsproto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func startServer(t *testing.T) (*Server, string) {
+	s := NewServer()
+	s.Handle("echo", func(c *Conn, payload json.RawMessage) (interface{}, error) {
+		var msg string
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return nil, err
+		}
+		return "Received: " + msg, nil
+	})
+	s.Handle("fail", func(c *Conn, payload json.RawMessage) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func Test_Call(t *testing.T) {
+	_, url := startServer(t)
+	c, err := Dial(url, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var reply string
+	if err := c.Call(ctx, "echo", "hello", &reply); err != nil || reply != "Received: hello" {
+		t.Errorf("echo = %q, %v", reply, err)
+	}
+
+	var re *RemoteError
+	if err := c.Call(ctx, "fail", nil, nil); !errors.As(err, &re) || re.Message != "boom" {
+		t.Errorf("fail = %v", err)
+	}
+	if err := c.Call(ctx, "nope", nil, nil); !errors.As(err, &re) {
+		t.Errorf("unknown type = %v", err)
+	}
+}
+
+func Test_BadMessage(t *testing.T) {
+	_, url := startServer(t)
+	ws, err := websocket.Dial(url, "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// 不能解码的消息得到错误回复，连接仍然可用
+	for _, msg := range []string{"not json", `{"type": 1}`} {
+		var env Envelope
+		if err := websocket.Message.Send(ws, msg); err != nil {
+			t.Fatal(err)
+		}
+		if err := websocket.JSON.Receive(ws, &env); err != nil || env.Type != TypeError || !strings.Contains(env.Error, "invalid message") {
+			t.Fatalf("%s: reply %+v, %v", msg, env, err)
+		}
+	}
+	var env Envelope
+	if err := websocket.Message.Send(ws, `{"type": "echo", "id": "1", "payload": "hi"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := websocket.JSON.Receive(ws, &env); err != nil || env.Type != TypeResult || string(env.Payload) != `"Received: hi"` {
+		t.Errorf("echo reply %+v, %v", env, err)
+	}
+}
+
+func Test_Broadcast(t *testing.T) {
+	s, url := startServer(t)
+
+	got := make(chan string, 1)
+	mux := NewMux()
+	mux.Handle("tick", func(c *Conn, payload json.RawMessage) (interface{}, error) {
+		var v string
+		json.Unmarshal(payload, &v)
+		got <- v
+		return nil, nil
+	})
+	c, err := Dial(url, "http://localhost/", mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// 等服务器登记这个连接
+	for i := 0; s.Len() == 0 && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if failed := s.Broadcast("tick", "now"); failed != 0 {
+		t.Fatalf("broadcast failed for %d conns", failed)
+	}
+	select {
+	case v := <-got:
+		if v != "now" {
+			t.Errorf("tick payload = %q", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no push received")
+	}
+}
+
+func Test_CallAfterClose(t *testing.T) {
+	_, url := startServer(t)
+	c, err := Dial(url, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if err := c.Call(context.Background(), "echo", "x", nil); err != ErrClosed {
+		t.Errorf("Call after Close = %v, want ErrClosed", err)
+	}
+}
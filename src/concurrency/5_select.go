@@ -1,6 +1,11 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+
+	"concurrency/gen"
+)
 
 // select 语句使一个 Go 程可以等待多个通信操作。
 // select 会阻塞到某个分支可以继续执行为止，这时就会执行该分支。当多个分支都准备好时会随机选择一个执行。
@@ -28,4 +33,11 @@ func main() {
 		quit <- 0
 	}() // TODO:这里是啥语法
 	fibonacci5(c, quit)
+
+	// 用 context 代替手写的 quit 信道：cancel 之后生成器的 Go 程会退出
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for v := range gen.TakeChan(ctx, gen.FibonacciChan(ctx), 10) {
+		fmt.Println(v)
+	}
 }
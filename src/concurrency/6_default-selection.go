@@ -16,6 +16,9 @@ import (
 //
 //	    // 从 c 中接收会阻塞时执行
 //	}
+//
+// time.Tick 和 time.After 直接使用真实的时间，依赖它们的代码只能靠睡眠来测试。
+// concurrency/ratelimit 中的限流器和调度器通过 Clock 接口获取时间，测试时可以换成假时钟。
 func main() {
 	tick := time.Tick(100 * time.Millisecond)  // 相当于定时器每隔0.1秒
 	boom := time.After(500 * time.Millisecond) // 0.5秒后
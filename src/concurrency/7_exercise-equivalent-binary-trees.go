@@ -2,7 +2,7 @@ package main
 
 import "fmt"
 
-import "golang.org/x/tour/tree"
+import "concurrency/tree"
 
 // 1. 实现 Walk 函数。
 //
@@ -24,23 +24,24 @@ import "golang.org/x/tour/tree"
 //}
 
 // Walk 步进 tree t 将所有的值从 tree 发送到 channel ch。
-func Walk(t *tree.Tree, ch chan int) {
-	Walkn(t, ch)
+func Walk(t *tree.Tree[int], ch chan int) {
+	Walkn(t.Root(), ch)
 	close(ch)
 }
 
-func Walkn(t *tree.Tree, ch chan int) {
-	if t == nil {
+func Walkn(n *tree.Node[int], ch chan int) {
+	if n == nil {
 		return
 	}
 
-	Walkn(t.Left, ch)
-	ch <- t.Value
-	Walkn(t.Right, ch)
+	Walkn(n.Left, ch)
+	ch <- n.Value
+	Walkn(n.Right, ch)
 }
 
 // Same 检测树 t1 和 t2 是否含有相同的值。
-func Same(t1, t2 *tree.Tree) bool {
+// 注意：提前返回 false 时两个 Walk 的 Go 程会一直阻塞在发送上，tree.Same 不会有这个问题。
+func Same(t1, t2 *tree.Tree[int]) bool {
 	ch1, ch2 := make(chan int), make(chan int)
 	go Walk(t1, ch1)
 	go Walk(t2, ch2)
@@ -61,4 +62,5 @@ func main() {
 	fmt.Println()
 	fmt.Println(Same(tree.New(1), tree.New(1)))
 	fmt.Println(Same(tree.New(1), tree.New(2)))
+	fmt.Println(tree.Same(tree.New(1), tree.New(2)))
 }
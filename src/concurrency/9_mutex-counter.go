@@ -3,7 +3,8 @@ package main
 import (
 	"fmt"
 	"sync"
-	"time"
+
+	"concurrency/shardmap"
 )
 
 // 我们已经看到信道非常适合在各个 Go 程间进行通信。
@@ -16,6 +17,9 @@ import (
 //
 // 我们可以通过在代码前调用 Lock 方法，在代码后调用 Unlock 方法来保证一段代码的互斥执行。参见 Inc 方法。
 // 我们也可以用 defer 语句来保证互斥锁一定会被解锁。参见 Value 方法。
+//
+// SafeCounter 所有的 Go 程都在同一把锁上排队，concurrency/shardmap 把键分到多个分片，
+// 每个分片一把读写锁，竞争激烈时吞吐量更高。
 
 type SafeCounter struct {
 	v   map[string]int
@@ -36,10 +40,19 @@ func (c *SafeCounter) Value(key string) int {
 
 func main() {
 	c := SafeCounter{v: make(map[string]int)}
+	sc := shardmap.NewCounter[string]()
+
+	// 用 sync.WaitGroup 等待所有 Go 程结束，而不是睡眠一段时间碰运气
+	var wg sync.WaitGroup
 	for i := 0; i < 1000; i++ {
-		go c.Inc("somekey")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Inc("somekey")
+			sc.Inc("somekey")
+		}()
 	}
 
-	time.Sleep(time.Second)
-	fmt.Println(c.Value("somekey"))
+	wg.Wait()
+	fmt.Println(c.Value("somekey"), sc.Value("somekey"))
 }
@@ -0,0 +1,258 @@
+// Package crawler 是 10_exercise-web-crawler.go 练习的可复用版本：
+// 并发地从种子 URL 开始抓取页面，每个 URL 只抓取一次，结果通过信道返回。
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Fetcher 抓取一个 URL，返回页面内容和页面上找到的 URL
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (body string, urls []string, err error)
+}
+
+// FetcherFunc 让普通函数实现 Fetcher
+type FetcherFunc func(ctx context.Context, url string) (string, []string, error)
+
+func (f FetcherFunc) Fetch(ctx context.Context, url string) (string, []string, error) {
+	return f(ctx, url)
+}
+
+// Result 是一个页面的抓取结果
+type Result struct {
+	URL   string
+	Depth int    // 种子的深度为 0
+	From  string // 第一次发现这个 URL 的页面，种子为空
+	Body  string
+	URLs  []string
+	Err   error
+}
+
+// Crawler 保存抓取参数
+type Crawler struct {
+	Fetcher Fetcher
+	// MaxDepth 是最大抓取深度，种子的深度为 0，MaxDepth 为 0 时只抓取种子
+	MaxDepth int
+	// Concurrency 是同时进行的抓取数，默认 4
+	Concurrency int
+	// Delay 是对同一个主机的两次请求之间至少间隔的时间，robots.txt 中的 Crawl-delay 更长时以它为准
+	Delay time.Duration
+
+	// Normalize 把 URL 转换成去重用的规范形式，返回错误的 URL 会被丢弃。
+	// 为 nil 时使用包里的 Normalize。抓取时仍然使用第一次发现的原始 URL。
+	Normalize func(string) (string, error)
+	// Scope 不为 nil 时只跟随范围内的链接，种子不受限制
+	Scope *Scope
+	// Robots 不为 nil 时遵守各主机的 robots.txt，被禁止的页面结果中的错误是 ErrDisallowed
+	Robots *RobotsCache
+	// Frontier 记录发现的 URL 和抓取状态，为 nil 时每次 Crawl 使用一个新的 MemoryFrontier。
+	// 使用 LogFrontier 时，被中断的抓取再次调用 Crawl 会先继续抓取上次没有完成的 URL。
+	Frontier Frontier
+}
+
+type task struct {
+	key   string
+	url   string
+	depth int
+	from  string
+}
+
+type taskResult struct {
+	key string
+	Result
+}
+
+// Crawl 开始抓取并立即返回，结果按完成顺序发送到返回的信道。
+// 所有可达的页面抓取完毕或者 ctx 被取消后信道被关闭。
+// Frontier 读写失败时抓取会停止，最后一个结果的 URL 为空，Err 是 Frontier 返回的错误。
+func (c *Crawler) Crawl(ctx context.Context, seeds ...string) <-chan Result {
+	out := make(chan Result)
+	go c.run(ctx, seeds, out)
+	return out
+}
+
+func (c *Crawler) run(ctx context.Context, seeds []string, out chan<- Result) {
+	defer close(out)
+	// 提前返回时取消正在进行的抓取，否则 worker 会一直等着发送没有人接收的结果，wg.Wait 永远不返回
+	ctx, cancel := context.WithCancel(ctx)
+
+	n := c.Concurrency
+	if n <= 0 {
+		n = 4
+	}
+	tasks := make(chan task)
+	done := make(chan taskResult)
+	polite := newHostDelay()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				r := taskResult{key: t.key, Result: c.fetch(ctx, polite, t)}
+				select {
+				case done <- r:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(tasks)
+	defer cancel()
+
+	normalize := c.Normalize
+	if normalize == nil {
+		normalize = Normalize
+	}
+	frontier := c.Frontier
+	if frontier == nil {
+		frontier = NewMemoryFrontier()
+	}
+	fail := func(err error) {
+		select {
+		case out <- Result{Err: fmt.Errorf("crawler: frontier: %w", err)}:
+		case <-ctx.Done():
+		}
+	}
+
+	// 只有这个 goroutine 访问 queue，所以不需要加锁
+	var queue []task
+	enqueue := func(t task) error {
+		key, err := normalize(t.url)
+		if err != nil {
+			return nil
+		}
+		added, err := frontier.Add(Entry{Key: key, URL: t.url, Depth: t.depth, From: t.from, Found: time.Now()})
+		if added {
+			t.key = key
+			queue = append(queue, t)
+		}
+		return err
+	}
+	// 先继续上次没有完成的 URL
+	for _, e := range frontier.Pending() {
+		queue = append(queue, task{key: e.Key, url: e.URL, depth: e.Depth, from: e.From})
+	}
+	for _, s := range seeds {
+		if err := enqueue(task{url: s}); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	inflight := 0
+	for len(queue) > 0 || inflight > 0 {
+		// queue 为空时 send 是 nil 信道，select 不会选中它
+		var send chan task
+		var next task
+		if len(queue) > 0 {
+			send, next = tasks, queue[0]
+		}
+
+		select {
+		case send <- next:
+			queue = queue[1:]
+			inflight++
+		case d := <-done:
+			inflight--
+			r := d.Result
+			if ctx.Err() != nil {
+				// 被取消的抓取保持 Pending，恢复时重新抓取
+				return
+			}
+			if r.Err == nil && r.Depth < c.MaxDepth {
+				for _, u := range r.URLs {
+					if c.Scope != nil && !c.Scope.Contains(u) {
+						continue
+					}
+					if err := enqueue(task{url: u, depth: r.Depth + 1, from: r.URL}); err != nil {
+						fail(err)
+						return
+					}
+				}
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+			// 结果交给调用者之后才标记完成，中断时没有送出的页面在恢复时会重新抓取
+			if err := frontier.Finish(d.key, r.Err); err != nil {
+				fail(err)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Crawler) fetch(ctx context.Context, polite *hostDelay, t task) Result {
+	r := Result{URL: t.url, Depth: t.depth, From: t.from}
+	delay := c.Delay
+	if c.Robots != nil {
+		rb, err := c.Robots.Get(ctx, t.url)
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		u, _ := url.Parse(t.url)
+		if !rb.Allowed(c.Robots.Agent, u.RequestURI()) {
+			r.Err = ErrDisallowed
+			return r
+		}
+		if d := rb.CrawlDelay(c.Robots.Agent); d > delay {
+			delay = d
+		}
+	}
+	if r.Err = polite.wait(ctx, t.url, delay); r.Err != nil {
+		return r
+	}
+	r.Body, r.URLs, r.Err = c.Fetcher.Fetch(ctx, t.url)
+	return r
+}
+
+// hostDelay 保证对同一个主机的请求之间至少间隔一段时间
+type hostDelay struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func newHostDelay() *hostDelay {
+	return &hostDelay{next: make(map[string]time.Time)}
+}
+
+func (h *hostDelay) wait(ctx context.Context, rawURL string, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	// 先预约一个时间点再睡眠，这样同一个主机的多个请求会依次排开
+	h.mu.Lock()
+	now := time.Now()
+	at := h.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	h.next[host] = at.Add(d)
+	h.mu.Unlock()
+
+	t := time.NewTimer(time.Until(at))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
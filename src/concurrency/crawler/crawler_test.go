@@ -0,0 +1,194 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFetcher 和练习里的一样，另外记录每个 URL 被抓取的次数
+type fakeFetcher struct {
+	pages map[string][]string
+	mu    sync.Mutex
+	hits  map[string]int
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, url string) (string, []string, error) {
+	f.mu.Lock()
+	f.hits[url]++
+	f.mu.Unlock()
+	if urls, ok := f.pages[url]; ok {
+		return "body of " + url, urls, nil
+	}
+	return "", nil, fmt.Errorf("not found: %s", url)
+}
+
+func newFakeFetcher() *fakeFetcher {
+	return &fakeFetcher{
+		hits: make(map[string]int),
+		pages: map[string][]string{
+			"https://golang.org/":         {"https://golang.org/pkg/", "https://golang.org/cmd/"},
+			"https://golang.org/pkg/":     {"https://golang.org/", "https://golang.org/cmd/", "https://golang.org/pkg/fmt/", "https://golang.org/pkg/os/"},
+			"https://golang.org/pkg/fmt/": {"https://golang.org/", "https://golang.org/pkg/"},
+			"https://golang.org/pkg/os/":  {"https://golang.org/", "https://golang.org/pkg/"},
+		},
+	}
+}
+
+func collect(ch <-chan Result) map[string]Result {
+	m := make(map[string]Result)
+	for r := range ch {
+		m[r.URL] = r
+	}
+	return m
+}
+
+func Test_Crawl(t *testing.T) {
+	f := newFakeFetcher()
+	c := Crawler{Fetcher: f, MaxDepth: 3, Concurrency: 3}
+	got := collect(c.Crawl(context.Background(), "https://golang.org/"))
+
+	if len(got) != 5 {
+		t.Fatalf("crawled %d pages, want 5: %v", len(got), got)
+	}
+	for u, n := range f.hits {
+		if n != 1 {
+			t.Errorf("%s fetched %d times", u, n)
+		}
+	}
+	if r := got["https://golang.org/cmd/"]; r.Err == nil || r.Depth != 1 || r.From != "https://golang.org/" {
+		t.Errorf("cmd result = %+v", r)
+	}
+	if r := got["https://golang.org/pkg/fmt/"]; r.Depth != 2 || r.From != "https://golang.org/pkg/" {
+		t.Errorf("fmt result = %+v", r)
+	}
+}
+
+func Test_CrawlDepth(t *testing.T) {
+	c := Crawler{Fetcher: newFakeFetcher(), MaxDepth: 0}
+	if got := collect(c.Crawl(context.Background(), "https://golang.org/")); len(got) != 1 {
+		t.Errorf("MaxDepth 0 crawled %d pages", len(got))
+	}
+}
+
+func Test_CrawlCancel(t *testing.T) {
+	// 每个页面都链接到两个新页面，不取消的话永远抓不完
+	f := FetcherFunc(func(ctx context.Context, u string) (string, []string, error) {
+		return "", []string{u + "a/", u + "b/"}, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	c := Crawler{Fetcher: f, MaxDepth: 1 << 30}
+	ch := c.Crawl(ctx, "https://example.com/")
+	for i := 0; i < 10; i++ {
+		<-ch
+	}
+	cancel()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("results channel not closed after cancel")
+		}
+	}
+}
+
+func Test_PolitenessDelay(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	f := FetcherFunc(func(ctx context.Context, u string) (string, []string, error) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		if u == "https://example.com/" {
+			return "", []string{"https://example.com/1", "https://example.com/2"}, nil
+		}
+		return "", nil, nil
+	})
+	c := Crawler{Fetcher: f, MaxDepth: 1, Concurrency: 3, Delay: 30 * time.Millisecond}
+	collect(c.Crawl(context.Background(), "https://example.com/"))
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for i := 1; i < len(times); i++ {
+		if d := times[i].Sub(times[i-1]); d < 25*time.Millisecond {
+			t.Errorf("requests %d and %d only %v apart", i-1, i, d)
+		}
+	}
+}
+
+func Test_HTTPFetcher(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body>
+			<a href="/docs/">docs</a>
+			<a href="about#team">about</a>
+			<a href="/docs/">dup</a>
+			<a href="mailto:x@example.com">mail</a>
+			<a href="https://other.example/">other</a>
+		</body></html>`)
+	})
+	mux.HandleFunc("/docs/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/docs/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<base href="/docs/api/"><a href="v1">v1</a><a href="/">home</a>`)
+	})
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, `<a href="/hidden">not html</a>`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	f := &HTTPFetcher{}
+	_, urls, err := f.Fetch(context.Background(), ts.URL+"/")
+	want := []string{ts.URL + "/docs/", ts.URL + "/about", "https://other.example/"}
+	if err != nil || strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("links = %v, %v; want %v", urls, err, want)
+	}
+
+	// 限定在测试服务器上抓取
+	scoped := FetcherFunc(func(ctx context.Context, u string) (string, []string, error) {
+		body, urls, err := f.Fetch(ctx, u)
+		var local []string
+		for _, l := range urls {
+			if strings.HasPrefix(l, ts.URL) {
+				local = append(local, l)
+			}
+		}
+		return body, local, err
+	})
+	c := Crawler{Fetcher: scoped, MaxDepth: 5}
+	got := collect(c.Crawl(context.Background(), ts.URL+"/"))
+	if len(got) != 4 {
+		t.Errorf("crawled %d pages: %v", len(got), got)
+	}
+	if r := got[ts.URL+"/docs/api/v1"]; r.Err == nil {
+		t.Errorf("missing page should fail: %+v", r)
+	} else if se, ok := r.Err.(*StatusError); !ok || se.Code != 404 {
+		t.Errorf("err = %v", r.Err)
+	}
+	if _, ok := got[ts.URL+"/hidden"]; ok {
+		t.Error("links in non-HTML documents should be ignored")
+	}
+	if u, _ := url.Parse(ts.URL); got[ts.URL+"/about"].From != u.String()+"/" {
+		t.Errorf("about discovered from %q", got[ts.URL+"/about"].From)
+	}
+}
@@ -0,0 +1,305 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// State 是 URL 在抓取边界中的状态
+type State int
+
+const (
+	Pending State = iota // 已发现，还没有抓取完成
+	Done                 // 抓取成功
+	Failed               // 抓取失败
+)
+
+func (s State) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case Done:
+		return "done"
+	case Failed:
+		return "failed"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Entry 是抓取边界中的一个 URL
+type Entry struct {
+	Key   string    `json:"key"`            // 规范化后的 URL，用来去重
+	URL   string    `json:"url"`            // 第一次发现时的原始 URL
+	Depth int       `json:"depth"`          // 种子的深度为 0
+	From  string    `json:"from,omitempty"` // 第一次发现这个 URL 的页面
+	State State     `json:"state"`
+	Err   string    `json:"err,omitempty"`
+	Found time.Time `json:"found"`
+}
+
+// Frontier 记录已经发现的 URL 和它们的抓取状态
+type Frontier interface {
+	// Add 记录一个新发现的 URL，Key 已经存在时返回 false
+	Add(e Entry) (bool, error)
+	// Finish 记录 URL 的抓取结果，err 为 nil 表示成功
+	Finish(key string, err error) error
+	// Pending 按发现顺序返回还没有完成的 URL，用于恢复抓取
+	Pending() []Entry
+}
+
+// MemoryFrontier 是只保存在内存中的 Frontier，Crawler 默认使用它
+type MemoryFrontier struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+	order   []string
+}
+
+func NewMemoryFrontier() *MemoryFrontier {
+	return &MemoryFrontier{entries: make(map[string]*Entry)}
+}
+
+func (f *MemoryFrontier) Add(e Entry) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.add(e), nil
+}
+
+func (f *MemoryFrontier) add(e Entry) bool {
+	if _, ok := f.entries[e.Key]; ok {
+		return false
+	}
+	f.entries[e.Key] = &e
+	f.order = append(f.order, e.Key)
+	return true
+}
+
+func (f *MemoryFrontier) Finish(key string, err error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finish(key, errString(err))
+	return nil
+}
+
+func (f *MemoryFrontier) finish(key, errMsg string) {
+	e, ok := f.entries[key]
+	if !ok {
+		return
+	}
+	e.State, e.Err = Done, errMsg
+	if errMsg != "" {
+		e.State = Failed
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (f *MemoryFrontier) Pending() []Entry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Entry
+	for _, k := range f.order {
+		if e := f.entries[k]; e.State == Pending {
+			out = append(out, *e)
+		}
+	}
+	return out
+}
+
+// Entries 按发现顺序返回所有的 URL
+func (f *MemoryFrontier) Entries() []Entry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Entry, 0, len(f.order))
+	for _, k := range f.order {
+		out = append(out, *f.entries[k])
+	}
+	return out
+}
+
+// Lookup 返回 key 对应的 URL
+func (f *MemoryFrontier) Lookup(key string) (Entry, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.entries[key]
+	if !ok {
+		return Entry{}, false
+	}
+	return *e, true
+}
+
+// Stats 返回各个状态的 URL 数量
+func (f *MemoryFrontier) Stats() map[State]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m := make(map[State]int)
+	for _, e := range f.entries {
+		m[e.State]++
+	}
+	return m
+}
+
+// LogFrontier 把抓取边界保存在一个只追加的 JSON 行日志里，
+// 进程重启后用 OpenLogFrontier 打开同一个文件就可以从中断的地方继续抓取。
+type LogFrontier struct {
+	MemoryFrontier
+	path string
+	f    *os.File
+	w    *bufio.Writer
+}
+
+// 日志中的一行：发现一个 URL 或者完成一个 URL
+type logRecord struct {
+	Op    string `json:"op"` // "add" 或 "finish"
+	Entry *Entry `json:"entry,omitempty"`
+	Key   string `json:"key,omitempty"`
+	Err   string `json:"err,omitempty"`
+}
+
+// OpenLogFrontier 打开或创建日志文件并重放其中的记录。
+// 最后一行不完整（写入时进程被杀掉）会被忽略。
+func OpenLogFrontier(path string) (*LogFrontier, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	lf := &LogFrontier{MemoryFrontier: MemoryFrontier{entries: make(map[string]*Entry)}, path: path, f: f}
+	valid, err := lf.replay(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// 截掉不完整的尾部，后面追加的记录从这里开始
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	lf.w = bufio.NewWriter(f)
+	return lf, nil
+}
+
+func (lf *LogFrontier) replay(r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var valid int64
+	for line := 1; ; line++ {
+		b, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return valid, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		var rec logRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return 0, fmt.Errorf("crawler: frontier log line %d: %v", line, err)
+		}
+		switch {
+		case rec.Op == "add" && rec.Entry != nil:
+			lf.add(*rec.Entry)
+		case rec.Op == "finish":
+			lf.finish(rec.Key, rec.Err)
+		default:
+			return 0, fmt.Errorf("crawler: frontier log line %d: bad record", line)
+		}
+		valid += int64(len(b))
+	}
+}
+
+func (lf *LogFrontier) Add(e Entry) (bool, error) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if !lf.add(e) {
+		return false, nil
+	}
+	return true, lf.append(logRecord{Op: "add", Entry: &e})
+}
+
+func (lf *LogFrontier) Finish(key string, err error) error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	lf.finish(key, errString(err))
+	return lf.append(logRecord{Op: "finish", Key: key, Err: errString(err)})
+}
+
+// append 写入一条记录，调用者必须持有锁。每条记录都会立即写到文件，进程崩溃最多丢失最后一条。
+func (lf *LogFrontier) append(rec logRecord) error {
+	if lf.w == nil {
+		return errors.New("crawler: frontier is closed")
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	lf.w.Write(b)
+	lf.w.WriteByte('\n')
+	return lf.w.Flush()
+}
+
+// Checkpoint 把当前状态压缩成一个新的日志（每个 URL 一行）并原子地替换旧文件，
+// 同时把数据同步到磁盘。长时间的抓取可以定期调用它来控制日志的大小。
+func (lf *LogFrontier) Checkpoint() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.w == nil {
+		return errors.New("crawler: frontier is closed")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(lf.path), ".frontier-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // 成功重命名后这里什么也不做
+
+	w := bufio.NewWriter(tmp)
+	for _, k := range lf.order {
+		e := *lf.entries[k]
+		b, _ := json.Marshal(logRecord{Op: "add", Entry: &e})
+		w.Write(b)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), lf.path); err != nil {
+		tmp.Close()
+		return err
+	}
+	lf.f.Close()
+	lf.f, lf.w = tmp, bufio.NewWriter(tmp)
+	return nil
+}
+
+// Close 把日志同步到磁盘并关闭文件
+func (lf *LogFrontier) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.w == nil {
+		return nil
+	}
+	lf.w = nil
+	if err := lf.f.Sync(); err != nil {
+		lf.f.Close()
+		return err
+	}
+	return lf.f.Close()
+}
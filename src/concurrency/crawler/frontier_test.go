@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_LogFrontierReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frontier.log")
+	lf, err := OpenLogFrontier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf.Add(Entry{Key: "a", URL: "https://x.org/a"})
+	lf.Add(Entry{Key: "b", URL: "https://x.org/b", Depth: 1, From: "https://x.org/a"})
+	lf.Add(Entry{Key: "c", URL: "https://x.org/c", Depth: 1, From: "https://x.org/a"})
+	if added, _ := lf.Add(Entry{Key: "a"}); added {
+		t.Error("duplicate key added")
+	}
+	lf.Finish("a", nil)
+	lf.Finish("c", os.ErrNotExist)
+	lf.Close()
+
+	// 模拟写到一半被杀掉的进程
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"op":"finish","key":"b"`)
+	f.Close()
+
+	lf, err = OpenLogFrontier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lf.Close()
+	p := lf.Pending()
+	if len(p) != 1 || p[0].Key != "b" || p[0].Depth != 1 || p[0].From != "https://x.org/a" {
+		t.Errorf("pending = %+v", p)
+	}
+	if e, _ := lf.Lookup("c"); e.State != Failed || e.Err == "" {
+		t.Errorf("c = %+v", e)
+	}
+	if st := lf.Stats(); st[Done] != 1 || st[Failed] != 1 || st[Pending] != 1 {
+		t.Errorf("stats = %v", st)
+	}
+
+	if err := lf.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	lf.Finish("b", nil)
+	b, _ := os.ReadFile(path)
+	if n := strings.Count(string(b), "\n"); n != 4 {
+		t.Errorf("log after checkpoint has %d lines:\n%s", n, b)
+	}
+	if err := lf.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CrawlResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frontier.log")
+	f := newFakeFetcher()
+
+	// 第一次抓取在抓到两个页面后被中断
+	lf, err := OpenLogFrontier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := Crawler{Fetcher: f, MaxDepth: 3, Concurrency: 1, Frontier: lf}
+	ch := c.Crawl(ctx, "https://golang.org/")
+	first := map[string]bool{}
+	for r := range ch {
+		first[r.URL] = true
+		if len(first) == 2 {
+			cancel()
+			break
+		}
+	}
+	for r := range ch {
+		first[r.URL] = true
+	}
+	lf.Close()
+
+	// 第二次从日志恢复，只抓取剩下的页面
+	lf, err = OpenLogFrontier(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lf.Close()
+	second := map[string]bool{}
+	c.Frontier = lf
+	for r := range c.Crawl(context.Background(), "https://golang.org/") {
+		second[r.URL] = true
+	}
+
+	for u := range first {
+		if second[u] {
+			t.Errorf("%s crawled again after resume", u)
+		}
+	}
+	if len(first)+len(second) != 5 {
+		t.Errorf("first %v + second %v, want 5 pages", first, second)
+	}
+	if p := lf.Pending(); len(p) != 0 {
+		t.Errorf("still pending: %v", p)
+	}
+	if e, ok := lf.Lookup("https://golang.org/pkg/fmt"); !ok || e.Depth != 2 || e.From != "https://golang.org/pkg/" {
+		t.Errorf("fmt entry = %+v", e)
+	}
+}
+
+// brokenFrontier 在记录新发现的链接时失败
+type brokenFrontier struct {
+	*MemoryFrontier
+}
+
+func (f brokenFrontier) Add(e Entry) (bool, error) {
+	if e.Depth > 0 {
+		return false, errors.New("disk full")
+	}
+	return f.MemoryFrontier.Add(e)
+}
+
+func Test_CrawlFrontierError(t *testing.T) {
+	// 第二个种子的链接写入失败时，第一个种子还在抓取中
+	f := FetcherFunc(func(ctx context.Context, u string) (string, []string, error) {
+		if strings.HasSuffix(u, "/slow") {
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-ctx.Done():
+				return "", nil, ctx.Err()
+			}
+		}
+		return "", []string{u + "/next"}, nil
+	})
+	c := Crawler{Fetcher: f, MaxDepth: 2, Concurrency: 2, Frontier: brokenFrontier{NewMemoryFrontier()}}
+	ch := c.Crawl(context.Background(), "https://x.org/slow", "https://x.org/fast")
+	var last Result
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case r, ok := <-ch:
+			if !ok {
+				if last.URL != "" || last.Err == nil || !strings.Contains(last.Err.Error(), "disk full") {
+					t.Errorf("last result %+v", last)
+				}
+				return
+			}
+			last = r
+		case <-timeout:
+			t.Fatal("results channel not closed after frontier error")
+		}
+	}
+}
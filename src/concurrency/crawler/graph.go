@@ -0,0 +1,263 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Page 是链接图中的一个节点
+type Page struct {
+	URL        string   `json:"url"`
+	Fetched    bool     `json:"fetched"` // 没有抓取过的页面只是被其他页面链接到
+	Depth      int      `json:"depth"`
+	Err        string   `json:"error,omitempty"`
+	Disallowed bool     `json:"disallowed,omitempty"` // 被 robots.txt 禁止而没有抓取，Err 是 ErrDisallowed
+	Links      []string `json:"links,omitempty"`      // 页面上链接到的 URL（规范形式）
+}
+
+// Edge 是一条链接
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph 是由抓取结果构成的页面链接图，节点以规范化后的 URL 为键
+type Graph struct {
+	Pages map[string]*Page `json:"pages"`
+}
+
+func NewGraph() *Graph {
+	return &Graph{Pages: make(map[string]*Page)}
+}
+
+// BuildGraph 读取所有结果并构建链接图
+func BuildGraph(results <-chan Result) *Graph {
+	g := NewGraph()
+	for r := range results {
+		g.Add(r)
+	}
+	return g
+}
+
+func graphKey(u string) string {
+	if k, err := Normalize(u); err == nil {
+		return k
+	}
+	return u
+}
+
+func (g *Graph) page(key string) *Page {
+	p, ok := g.Pages[key]
+	if !ok {
+		p = &Page{URL: key, Depth: -1}
+		g.Pages[key] = p
+	}
+	return p
+}
+
+// Add 把一个抓取结果加入图中，URL 为空的结果（Frontier 错误）会被忽略
+func (g *Graph) Add(r Result) {
+	if r.URL == "" {
+		return
+	}
+	p := g.page(graphKey(r.URL))
+	p.Fetched = true
+	p.Depth = r.Depth
+	p.Err = errString(r.Err)
+	p.Disallowed = errors.Is(r.Err, ErrDisallowed)
+	p.Links = p.Links[:0]
+	seen := make(map[string]bool)
+	for _, u := range r.URLs {
+		k := graphKey(u)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		p.Links = append(p.Links, k)
+		g.page(k)
+	}
+}
+
+// Keys 返回排好序的所有节点
+func (g *Graph) Keys() []string {
+	keys := make([]string, 0, len(g.Pages))
+	for k := range g.Pages {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Edges 返回所有的链接，按起点、终点排序
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, k := range g.Keys() {
+		links := append([]string(nil), g.Pages[k].Links...)
+		sort.Strings(links)
+		for _, to := range links {
+			edges = append(edges, Edge{From: k, To: to})
+		}
+	}
+	return edges
+}
+
+// WriteJSON 输出 {"pages": [...], "edges": [...]}
+func (g *Graph) WriteJSON(w io.Writer) error {
+	pages := make([]*Page, 0, len(g.Pages))
+	for _, k := range g.Keys() {
+		pages = append(pages, g.Pages[k])
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Pages []*Page `json:"pages"`
+		Edges []Edge  `json:"edges"`
+	}{pages, g.Edges()})
+}
+
+// WriteCSV 输出带表头的边列表 from,to
+func (g *Graph) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"from", "to"})
+	for _, e := range g.Edges() {
+		cw.Write([]string{e.From, e.To})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteDOT 输出 Graphviz 的 DOT 格式，抓取失败的页面标成红色，没有抓取的页面用虚线
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph crawl {\n\tnode [shape=box];\n")
+	for _, k := range g.Keys() {
+		p := g.Pages[k]
+		attrs := ""
+		switch {
+		case p.Err != "":
+			attrs = ` [color=red]`
+		case !p.Fetched:
+			attrs = ` [style=dashed]`
+		}
+		fmt.Fprintf(&b, "\t%s%s;\n", dotQuote(k), attrs)
+	}
+	for _, e := range g.Edges() {
+		fmt.Fprintf(&b, "\t%s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// BrokenLinks 返回指向抓取失败页面的链接，被 robots.txt 禁止的页面不算
+func (g *Graph) BrokenLinks() []Edge {
+	var out []Edge
+	for _, e := range g.Edges() {
+		if p := g.Pages[e.To]; p.Err != "" && !p.Disallowed {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// inbound 统计每个节点被其他页面链接的次数，自己链接自己不算
+func (g *Graph) inbound() map[string]int {
+	in := make(map[string]int)
+	for k, p := range g.Pages {
+		for _, to := range p.Links {
+			if to != k {
+				in[to]++
+			}
+		}
+	}
+	return in
+}
+
+// Orphans 返回抓取过但是没有被任何其他页面链接到的页面，seeds 不算在内
+func (g *Graph) Orphans(seeds ...string) []string {
+	isSeed := make(map[string]bool)
+	for _, s := range seeds {
+		isSeed[graphKey(s)] = true
+	}
+	in := g.inbound()
+	var out []string
+	for _, k := range g.Keys() {
+		if g.Pages[k].Fetched && in[k] == 0 && !isSeed[k] {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// ClickDepth 返回从 seed 出发到每个可达页面最少需要点击的次数
+func (g *Graph) ClickDepth(seed string) map[string]int {
+	start := graphKey(seed)
+	depth := make(map[string]int)
+	if _, ok := g.Pages[start]; !ok {
+		return depth
+	}
+	depth[start] = 0
+	queue := []string{start}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for _, to := range g.Pages[k].Links {
+			if _, ok := depth[to]; !ok {
+				depth[to] = depth[k] + 1
+				queue = append(queue, to)
+			}
+		}
+	}
+	return depth
+}
+
+// PageRank 用幂迭代计算 PageRank，damping 一般取 0.85。
+// 没有出链的页面把权重平均分给所有页面。迭代在变化量小于 1e-9 或达到 iterations 次后停止。
+func (g *Graph) PageRank(damping float64, iterations int) map[string]float64 {
+	keys := g.Keys()
+	n := float64(len(keys))
+	rank := make(map[string]float64, len(keys))
+	if n == 0 {
+		return rank
+	}
+	for _, k := range keys {
+		rank[k] = 1 / n
+	}
+
+	for i := 0; i < iterations; i++ {
+		next := make(map[string]float64, len(keys))
+		dangling := 0.0
+		for _, k := range keys {
+			if len(g.Pages[k].Links) == 0 {
+				dangling += rank[k]
+			}
+		}
+		base := (1-damping)/n + damping*dangling/n
+		for _, k := range keys {
+			next[k] += base
+			links := g.Pages[k].Links
+			for _, to := range links {
+				next[to] += damping * rank[k] / float64(len(links))
+			}
+		}
+		delta := 0.0
+		for _, k := range keys {
+			delta += math.Abs(next[k] - rank[k])
+		}
+		rank = next
+		if delta < 1e-9 {
+			break
+		}
+	}
+	return rank
+}
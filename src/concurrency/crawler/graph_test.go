@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func crawlGraph(t *testing.T) *Graph {
+	c := Crawler{Fetcher: newFakeFetcher(), MaxDepth: 3}
+	return BuildGraph(c.Crawl(context.Background(), "https://golang.org/"))
+}
+
+func Test_GraphAnalyses(t *testing.T) {
+	g := crawlGraph(t)
+	if len(g.Pages) != 5 || len(g.Edges()) != 10 {
+		t.Fatalf("%d pages, %d edges", len(g.Pages), len(g.Edges()))
+	}
+
+	broken := g.BrokenLinks()
+	if len(broken) != 2 || broken[0].To != "https://golang.org/cmd" {
+		t.Errorf("broken links = %v", broken)
+	}
+
+	d := g.ClickDepth("https://golang.org/")
+	if d["https://golang.org/pkg/os"] != 2 || d["https://golang.org/cmd"] != 1 || d["https://golang.org/"] != 0 {
+		t.Errorf("click depth = %v", d)
+	}
+
+	// 一个没有被任何页面链接到的页面
+	g.Add(Result{URL: "https://golang.org/lonely", Depth: 1})
+	if o := g.Orphans("https://golang.org/"); len(o) != 1 || o[0] != "https://golang.org/lonely" {
+		t.Errorf("orphans = %v", o)
+	}
+
+	pr := g.PageRank(0.85, 100)
+	sum := 0.0
+	for _, v := range pr {
+		sum += v
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("PageRank sums to %v", sum)
+	}
+	if pr["https://golang.org/"] <= pr["https://golang.org/pkg/os"] || pr["https://golang.org/lonely"] >= pr["https://golang.org/pkg/os"] {
+		t.Errorf("unexpected ranking: %v", pr)
+	}
+}
+
+func Test_BrokenLinksDisallowed(t *testing.T) {
+	// 被 robots.txt 禁止的页面不是坏链接
+	g := NewGraph()
+	g.Add(Result{URL: "https://x.org/", URLs: []string{"https://x.org/private", "https://x.org/gone"}})
+	g.Add(Result{URL: "https://x.org/private", Depth: 1, Err: ErrDisallowed})
+	g.Add(Result{URL: "https://x.org/gone", Depth: 1, Err: errors.New("404")})
+	if b := g.BrokenLinks(); len(b) != 1 || b[0].To != "https://x.org/gone" {
+		t.Errorf("broken links = %v", b)
+	}
+}
+
+func Test_GraphExport(t *testing.T) {
+	g := NewGraph()
+	g.Add(Result{URL: "https://x.org/", URLs: []string{"https://x.org/a", "https://x.org/a/", `https://x.org/"q"`}})
+	g.Add(Result{URL: "https://x.org/a", Depth: 1, Err: errors.New("404")})
+
+	var buf bytes.Buffer
+	if err := g.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Pages []Page
+		Edges []Edge
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Pages) != 3 || len(decoded.Edges) != 2 {
+		t.Errorf("json = %s (%v)", buf.String(), err)
+	}
+
+	buf.Reset()
+	g.WriteCSV(&buf)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 3 || rows[0][0] != "from" || rows[1][1] != `https://x.org/%22q%22` {
+		t.Errorf("csv = %v, %v", rows, err)
+	}
+
+	buf.Reset()
+	g.WriteDOT(&buf)
+	dot := buf.String()
+	for _, want := range []string{"digraph crawl {", `"https://x.org/a" [color=red];`, `"https://x.org/" -> "https://x.org/a";`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("dot missing %q:\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTTPFetcher 通过 HTTP GET 抓取页面，并从 HTML 中提取链接
+type HTTPFetcher struct {
+	// Client 为 nil 时使用 http.DefaultClient
+	Client *http.Client
+	// UserAgent 为空时使用 Go 的默认值
+	UserAgent string
+	// MaxBodySize 是读取响应体的上限，默认 1MB
+	MaxBodySize int64
+}
+
+// StatusError 是响应状态码不是 2xx 时返回的错误
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("crawler: %s: %d %s", e.URL, e.Code, http.StatusText(e.Code))
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (string, []string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", nil, &StatusError{URL: rawURL, Code: resp.StatusCode}
+	}
+	limit := f.MaxBodySize
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return "", nil, err
+	}
+	body := string(b)
+
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mt != "text/html" && mt != "application/xhtml+xml" {
+		return body, nil, nil
+	}
+	// 跟随重定向后以最终的地址为基准解析相对链接
+	return body, ExtractLinks(resp.Request.URL, strings.NewReader(body)), nil
+}
+
+// ExtractLinks 返回 HTML 中 <a href> 指向的 http/https 绝对地址，去掉了片段并去重。
+// 页面中的 <base href> 会改变相对链接的基准。
+func ExtractLinks(base *url.URL, r io.Reader) []string {
+	var links []string
+	seen := make(map[string]bool)
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if (tag != "a" && tag != "base") || !hasAttr {
+				continue
+			}
+			for {
+				key, val, more := z.TagAttr()
+				if string(key) == "href" {
+					u, err := base.Parse(strings.TrimSpace(string(val)))
+					if err != nil {
+						break
+					}
+					if tag == "base" {
+						base = u
+						break
+					}
+					if u.Scheme != "http" && u.Scheme != "https" {
+						break
+					}
+					u.Fragment, u.RawFragment = "", ""
+					if s := u.String(); !seen[s] {
+						seen[s] = true
+						links = append(links, s)
+					}
+					break
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"errors"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Normalize 把 URL 转换成规范形式，用来判断两个 URL 是否指向同一个页面：
+//   - scheme 和主机名转成小写，去掉默认端口（http 的 80，https 的 443）
+//   - 去掉片段（#...）
+//   - 解析路径中的 . 和 ..，空路径变成 "/"，其他路径去掉末尾的 "/"
+//   - 查询参数按名字排序，同名参数保持原来的顺序，去掉空的 "?"
+//
+// 只接受 http 和 https 的绝对地址。
+func Normalize(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("crawler: unsupported scheme in " + raw)
+	}
+	if u.Host == "" {
+		return "", errors.New("crawler: missing host in " + raw)
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	p = path.Clean(p)
+	u.Path = p
+	u.RawPath = ""
+
+	if u.RawQuery != "" {
+		u.RawQuery = sortQuery(u.RawQuery)
+	}
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// sortQuery 按参数名稳定排序，不重新编码参数值
+func sortQuery(q string) string {
+	parts := strings.FieldsFunc(q, func(r rune) bool { return r == '&' })
+	sort.SliceStable(parts, func(i, j int) bool {
+		ki, _, _ := strings.Cut(parts[i], "=")
+		kj, _, _ := strings.Cut(parts[j], "=")
+		return ki < kj
+	})
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,266 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed 是 robots.txt 禁止抓取时结果中的错误
+var ErrDisallowed = errors.New("crawler: disallowed by robots.txt")
+
+// Robots 是解析后的 robots.txt
+type Robots struct {
+	groups []robotsGroup
+}
+
+type robotsGroup struct {
+	agents []string // 小写
+	rules  []robotsRule
+	delay  time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// allowAll 和 disallowAll 用于 robots.txt 不存在或者无法获取的情况
+var (
+	allowAll    = &Robots{}
+	disallowAll = &Robots{groups: []robotsGroup{{agents: []string{"*"}, rules: []robotsRule{{pattern: "/"}}}}}
+)
+
+// ParseRobots 解析 robots.txt，不认识的行会被忽略
+func ParseRobots(r io.Reader) (*Robots, error) {
+	rb := &Robots{}
+	var cur *robotsGroup
+	inAgents := false // 连续的 User-agent 行属于同一组
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				rb.groups = append(rb.groups, robotsGroup{})
+				cur = &rb.groups[len(rb.groups)-1]
+			}
+			cur.agents = append(cur.agents, strings.ToLower(val))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if cur == nil {
+				continue
+			}
+			// "Disallow:" 后面为空表示不限制
+			if val == "" {
+				continue
+			}
+			cur.rules = append(cur.rules, robotsRule{allow: key == "allow", pattern: val})
+		case "crawl-delay":
+			inAgents = false
+			if cur == nil {
+				continue
+			}
+			if secs, err := strconv.ParseFloat(val, 64); err == nil && secs >= 0 {
+				cur.delay = time.Duration(secs * float64(time.Second))
+			}
+		default:
+			inAgents = false
+		}
+	}
+	return rb, sc.Err()
+}
+
+// group 返回最匹配 agent 的组：名字被 agent 包含的最长的组，没有的话使用 "*" 组
+func (rb *Robots) group(agent string) *robotsGroup {
+	agent = strings.ToLower(agent)
+	var best *robotsGroup
+	bestLen := -1
+	for i := range rb.groups {
+		g := &rb.groups[i]
+		for _, a := range g.agents {
+			n := len(a)
+			if a == "*" {
+				n = 0
+			} else if !strings.Contains(agent, a) {
+				continue
+			}
+			if n > bestLen {
+				best, bestLen = g, n
+			}
+		}
+	}
+	return best
+}
+
+// Allowed 判断 agent 是否可以抓取 path（可以带查询参数）。
+// 最长的匹配规则生效，长度相同时 Allow 优先；/robots.txt 本身总是允许的。
+func (rb *Robots) Allowed(agent, path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	g := rb.group(agent)
+	if g == nil {
+		return true
+	}
+	allowed, bestLen := true, -1
+	for _, r := range g.rules {
+		if !matchRobots(r.pattern, path) {
+			continue
+		}
+		if n := len(r.pattern); n > bestLen || (n == bestLen && r.allow) {
+			allowed, bestLen = r.allow, n
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay 返回 agent 对应组的 Crawl-delay
+func (rb *Robots) CrawlDelay(agent string) time.Duration {
+	if g := rb.group(agent); g != nil {
+		return g.delay
+	}
+	return 0
+}
+
+// matchRobots 实现 robots.txt 的匹配规则：前缀匹配，"*" 匹配任意字符，结尾的 "$" 表示必须匹配到末尾
+func matchRobots(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	if len(parts) == 1 {
+		return !anchored || rest == ""
+	}
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, p)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(p):]
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return strings.HasSuffix(rest, last)
+	}
+	return strings.Contains(rest, last)
+}
+
+// RobotsCache 按主机获取并缓存 robots.txt
+type RobotsCache struct {
+	// Agent 是用来选择规则组的 User-agent，同时作为请求 robots.txt 的 User-Agent
+	Agent string
+	// Client 为 nil 时使用 http.DefaultClient
+	Client *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry
+}
+
+type robotsEntry struct {
+	ready chan struct{}
+	rb    *Robots
+	err   error
+}
+
+func NewRobotsCache(agent string, client *http.Client) *RobotsCache {
+	return &RobotsCache{Agent: agent, Client: client, hosts: make(map[string]*robotsEntry)}
+}
+
+// Get 返回 rawURL 所在主机的 robots.txt，同一个主机只请求一次
+func (c *RobotsCache) Get(ctx context.Context, rawURL string) (*Robots, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	c.mu.Lock()
+	if c.hosts == nil {
+		c.hosts = make(map[string]*robotsEntry)
+	}
+	e, ok := c.hosts[key]
+	if !ok {
+		e = &robotsEntry{ready: make(chan struct{})}
+		c.hosts[key] = e
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		e.rb = c.fetch(ctx, key+"/robots.txt")
+		if e.err = ctx.Err(); e.err != nil {
+			// 被取消的请求不能说明 robots.txt 的内容，下次重新获取
+			c.mu.Lock()
+			delete(c.hosts, key)
+			c.mu.Unlock()
+		}
+		close(e.ready)
+	}
+	select {
+	case <-e.ready:
+		return e.rb, e.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Allowed 判断是否可以抓取 rawURL
+func (c *RobotsCache) Allowed(ctx context.Context, rawURL string) (bool, error) {
+	rb, err := c.Get(ctx, rawURL)
+	if err != nil {
+		return false, err
+	}
+	u, _ := url.Parse(rawURL)
+	return rb.Allowed(c.Agent, u.RequestURI()), nil
+}
+
+// fetch 按 RFC 9309 处理：4xx 表示没有限制，5xx 或网络错误表示全部禁止
+func (c *RobotsCache) fetch(ctx context.Context, robotsURL string) *Robots {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return disallowAll
+	}
+	if c.Agent != "" {
+		req.Header.Set("User-Agent", c.Agent)
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return disallowAll
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return disallowAll
+	case resp.StatusCode >= 400:
+		return allowAll
+	}
+	rb, err := ParseRobots(io.LimitReader(resp.Body, 500<<10))
+	if err != nil {
+		return disallowAll
+	}
+	return rb
+}
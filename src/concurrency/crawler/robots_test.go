@@ -0,0 +1,138 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Normalize(t *testing.T) {
+	cases := map[string]string{
+		"https://golang.org/pkg":             "https://golang.org/pkg",
+		"https://golang.org/pkg/":            "https://golang.org/pkg",
+		"HTTPS://GoLang.org:443/pkg/#top":    "https://golang.org/pkg",
+		"http://golang.org:80":               "http://golang.org/",
+		"http://golang.org:8080/a/./b/../c/": "http://golang.org:8080/a/c",
+		"https://x.org/s?b=2&a=1&b=1":        "https://x.org/s?a=1&b=2&b=1",
+		"https://x.org/s?":                   "https://x.org/s",
+		"https://user:pw@x.org/%7Euser/":     "https://x.org/~user",
+		"http://[2001:DB8::1]:80/":           "http://[2001:db8::1]/",
+	}
+	for in, want := range cases {
+		if got, err := Normalize(in); err != nil || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"mailto:a@b.c", "/relative", "ftp://x.org/"} {
+		if _, err := Normalize(bad); err == nil {
+			t.Errorf("Normalize(%q) should fail", bad)
+		}
+	}
+}
+
+const robotsTxt = `
+# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 0.05
+
+User-agent: BadBot
+User-agent: EvilBot
+Disallow: /
+
+User-agent: GoodBot
+Disallow:
+`
+
+func Test_Robots(t *testing.T) {
+	rb, err := ParseRobots(strings.NewReader(robotsTxt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		agent, path string
+		allowed     bool
+	}{
+		{"mycrawler/1.0", "/", true},
+		{"mycrawler/1.0", "/private/x", false},
+		{"mycrawler/1.0", "/private/public/x", true},
+		{"mycrawler/1.0", "/doc.pdf", false},
+		{"mycrawler/1.0", "/doc.pdf?x=1", true},
+		{"Mozilla/5.0 (compatible; BadBot/2.1)", "/", false},
+		{"evilbot", "/robots.txt", true},
+		{"EvilBot", "/index.html", false},
+		{"GoodBot", "/private/x", true},
+	}
+	for _, c := range cases {
+		if got := rb.Allowed(c.agent, c.path); got != c.allowed {
+			t.Errorf("Allowed(%q, %q) = %v", c.agent, c.path, got)
+		}
+	}
+	if d := rb.CrawlDelay("mycrawler"); d != 50*time.Millisecond {
+		t.Errorf("CrawlDelay = %v", d)
+	}
+}
+
+func Test_Scope(t *testing.T) {
+	s := &Scope{
+		AllowDomains: []string{"golang.org"},
+		DenyDomains:  []string{"play.golang.org"},
+		DenyPaths:    []string{"/dl/", "*.zip$"},
+	}
+	cases := map[string]bool{
+		"https://golang.org/pkg/":       true,
+		"https://blog.golang.org/":      true,
+		"https://notgolang.org/":        false,
+		"https://play.golang.org/p/abc": false,
+		"https://golang.org/dl/go.tgz":  false,
+		"https://golang.org/x/a.zip":    false,
+		"https://golang.org/x/a.zip/":   true,
+	}
+	for u, want := range cases {
+		if got := s.Contains(u); got != want {
+			t.Errorf("Contains(%q) = %v", u, got)
+		}
+	}
+}
+
+func Test_CrawlRobotsAndScope(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /secret\n")
+			return
+		case "/", "/a", "/secret":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		// /a 和 /a/ 是同一个页面，外部链接不在范围内
+		fmt.Fprint(w, `<a href="/a">a</a><a href="/a/">a again</a><a href="/secret">s</a><a href="https://elsewhere.example/">x</a>`)
+	}))
+	defer ts.Close()
+
+	c := Crawler{
+		Fetcher:  &HTTPFetcher{UserAgent: "testbot"},
+		MaxDepth: 3,
+		Scope:    &Scope{AllowDomains: []string{"127.0.0.1"}},
+		Robots:   NewRobotsCache("testbot", nil),
+	}
+	got := collect(c.Crawl(context.Background(), ts.URL+"/"))
+	if len(got) != 3 {
+		t.Fatalf("crawled %d pages: %v", len(got), got)
+	}
+	if r := got[ts.URL+"/secret"]; r.Err != ErrDisallowed {
+		t.Errorf("secret err = %v", r.Err)
+	}
+	if _, ok := got[ts.URL+"/a/"]; ok {
+		t.Error("/a/ should be deduplicated with /a")
+	}
+}
@@ -0,0 +1,59 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Scope 限定要跟随的链接。域名规则匹配域名本身和它的所有子域名，
+// 路径规则是前缀匹配，其中的 "*" 匹配任意字符，结尾的 "$" 表示必须匹配到末尾（和 robots.txt 相同）。
+// 拒绝规则优先；某类允许规则为空时不做这一类限制。
+type Scope struct {
+	AllowDomains []string
+	DenyDomains  []string
+	AllowPaths   []string
+	DenyPaths    []string
+}
+
+// Contains 判断 rawURL 是否在范围内
+func (s *Scope) Contains(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	p := u.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+
+	if matchDomain(s.DenyDomains, host) || matchPath(s.DenyPaths, p) {
+		return false
+	}
+	if len(s.AllowDomains) > 0 && !matchDomain(s.AllowDomains, host) {
+		return false
+	}
+	if len(s.AllowPaths) > 0 && !matchPath(s.AllowPaths, p) {
+		return false
+	}
+	return true
+}
+
+func matchDomain(domains []string, host string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(d, "."))
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPath(patterns []string, p string) bool {
+	for _, pat := range patterns {
+		if matchRobots(pat, p) {
+			return true
+		}
+	}
+	return false
+}
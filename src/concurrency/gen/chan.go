@@ -0,0 +1,160 @@
+package gen
+
+import (
+	"context"
+	"iter"
+)
+
+// Pair 是 ZipChan 产生的一对值
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// send 把 v 发送到 ch，ctx 结束时返回 false
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv 从 ch 接收一个值，ch 关闭或 ctx 结束时返回 false
+func recv[T any](ctx context.Context, ch <-chan T) (T, bool) {
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// Chan 在一个 Go 程中遍历 seq，把值发送到返回的信道。
+// seq 结束或 ctx 结束时关闭信道，后一种情况下 seq 会像 break 一样被停止。
+func Chan[T any](ctx context.Context, seq iter.Seq[T]) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for v := range seq {
+			if !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Values 把信道变成迭代器，遍历到信道关闭为止。
+// 提前 break 不会通知发送方，需要由发送方的 ctx 来停止它。
+func Values[T any](ch <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range ch {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// FibonacciChan 是信道形式的 Fibonacci，ctx 结束时停止
+func FibonacciChan(ctx context.Context) <-chan int {
+	return Chan(ctx, Fibonacci())
+}
+
+// TakeChan 转发 in 的前 n 个值，然后关闭输出
+func TakeChan[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for range n {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// FilterChan 只转发 in 中满足 keep 的值
+func FilterChan[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			if keep(v) && !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// MapChan 对 in 中的每个值调用 f
+func MapChan[In, Out any](ctx context.Context, in <-chan In, f func(In) Out) <-chan Out {
+	out := make(chan Out)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, f(v)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// ZipChan 把两个信道的值一一配对，任何一个关闭时停止
+func ZipChan[A, B any](ctx context.Context, a <-chan A, b <-chan B) <-chan Pair[A, B] {
+	out := make(chan Pair[A, B])
+	go func() {
+		defer close(out)
+		for {
+			va, ok := recv(ctx, a)
+			if !ok {
+				return
+			}
+			vb, ok := recv(ctx, b)
+			if !ok || !send(ctx, out, Pair[A, B]{va, vb}) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// ChunkChan 把 in 的值每 n 个分成一组，最后一组可能不满
+func ChunkChan[T any](ctx context.Context, in <-chan T, n int) <-chan []T {
+	if n <= 0 {
+		panic("gen: ChunkChan size must be positive")
+	}
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		var chunk []T
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				break
+			}
+			if chunk = append(chunk, v); len(chunk) == n {
+				if !send(ctx, out, chunk) {
+					return
+				}
+				chunk = nil
+			}
+		}
+		if len(chunk) > 0 && ctx.Err() == nil {
+			send(ctx, out, chunk)
+		}
+	}()
+	return out
+}
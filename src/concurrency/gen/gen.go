@@ -0,0 +1,111 @@
+// Package gen 提供可以取消的生成器和组合函数，有两种形式：
+//
+//   - 迭代器（iter.Seq），用 range-over-func 遍历，不需要 Go 程，提前 break 即可停止；
+//   - 信道，每个阶段是一个 Go 程，ctx 结束时所有 Go 程都会退出，组合函数的名字以 Chan 结尾。
+//
+// 两种形式可以用 Chan 和 Values 互相转换。使用信道形式时，消费者不再读取之后要取消 ctx，
+// 否则上游的 Go 程会阻塞在发送上，这代替了练习中手写的 quit 信道。
+package gen
+
+import "iter"
+
+// Fibonacci 产生斐波纳契数列 0, 1, 1, 2, 3, 5, ...，第 93 项之后会溢出 int
+func Fibonacci() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		x, y := 0, 1
+		for yield(x) {
+			x, y = y, x+y
+		}
+	}
+}
+
+// Count 产生无限的序列 start, start+step, start+2*step, ...
+func Count(start, step int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for v := start; yield(v); v += step {
+		}
+	}
+}
+
+// FromFunc 把每次调用返回下一个值的闭包变成无限的迭代器
+func FromFunc[T any](next func() T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for yield(next()) {
+		}
+	}
+}
+
+// Take 只产生 seq 的前 n 个值
+func Take[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for v := range seq {
+			if !yield(v) {
+				return
+			}
+			if i++; i == n {
+				return
+			}
+		}
+	}
+}
+
+// Filter 只产生 seq 中满足 keep 的值
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Map 对 seq 中的每个值调用 f
+func Map[In, Out any](seq iter.Seq[In], f func(In) Out) iter.Seq[Out] {
+	return func(yield func(Out) bool) {
+		for v := range seq {
+			if !yield(f(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Zip 把两个序列一一配对，较短的序列结束时停止
+func Zip[A, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+		for va := range a {
+			vb, ok := next()
+			if !ok || !yield(va, vb) {
+				return
+			}
+		}
+	}
+}
+
+// Chunk 把序列每 n 个值分成一组，最后一组可能不满。每一组都是新的切片。
+func Chunk[T any](seq iter.Seq[T], n int) iter.Seq[[]T] {
+	if n <= 0 {
+		panic("gen: Chunk size must be positive")
+	}
+	return func(yield func([]T) bool) {
+		var chunk []T
+		for v := range seq {
+			if chunk = append(chunk, v); len(chunk) == n {
+				if !yield(chunk) {
+					return
+				}
+				chunk = nil
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package gen
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+var fib10 = []int{0, 1, 1, 2, 3, 5, 8, 13, 21, 34}
+
+func isEven(v int) bool { return v%2 == 0 }
+
+// noLeak 检查测试结束后没有遗留的 Go 程
+func noLeak(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := runtime.NumGoroutine(); n > before {
+			t.Errorf("goroutines leaked: %d before, %d after", before, n)
+		}
+	})
+}
+
+func collect[T any](ch <-chan T) []T {
+	var out []T
+	for v := range ch {
+		out = append(out, v)
+	}
+	return out
+}
+
+func Test_Seq(t *testing.T) {
+	if got := slices.Collect(Take(Fibonacci(), 10)); !slices.Equal(got, fib10) {
+		t.Errorf("Take(Fibonacci) = %v", got)
+	}
+	// 练习中的闭包
+	a, b := 0, 1
+	closure := func() int {
+		c := a
+		a, b = b, a+b
+		return c
+	}
+	if got := slices.Collect(Take(FromFunc(closure), 10)); !slices.Equal(got, fib10) {
+		t.Errorf("Take(FromFunc) = %v", got)
+	}
+	if got := slices.Collect(Take(Filter(Count(1, 1), isEven), 3)); !slices.Equal(got, []int{2, 4, 6}) {
+		t.Errorf("Filter = %v", got)
+	}
+	sq := Map(Take(Count(0, 1), 4), func(v int) string { return string(rune('a' + v)) })
+	if got := slices.Collect(sq); !slices.Equal(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("Map = %v", got)
+	}
+	var pairs []string
+	for n, s := range Zip(Count(1, 1), slices.Values([]string{"x", "y"})) {
+		pairs = append(pairs, string(rune('0'+n))+s)
+	}
+	if !slices.Equal(pairs, []string{"1x", "2y"}) {
+		t.Errorf("Zip = %v", pairs)
+	}
+	chunks := slices.Collect(Chunk(Take(Count(0, 1), 7), 3))
+	if len(chunks) != 3 || !slices.Equal(chunks[2], []int{6}) {
+		t.Errorf("Chunk = %v", chunks)
+	}
+	if got := slices.Collect(Take(Count(0, 1), 0)); len(got) != 0 {
+		t.Errorf("Take(0) = %v", got)
+	}
+}
+
+func Test_SeqEarlyBreak(t *testing.T) {
+	// Zip 用 iter.Pull 读取第二个序列，提前 break 后要停止它
+	stopped := false
+	inner := func(yield func(int) bool) {
+		defer func() { stopped = true }()
+		for i := 0; yield(i); i++ {
+		}
+	}
+	for range Zip(Count(0, 1), inner) {
+		break
+	}
+	if !stopped {
+		t.Error("Zip did not stop the pulled sequence")
+	}
+	for c := range Chunk(Count(0, 1), 2) {
+		if len(c) != 2 {
+			t.Errorf("chunk %v", c)
+		}
+		break
+	}
+}
+
+func Test_Chan(t *testing.T) {
+	noLeak(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if got := collect(TakeChan(ctx, FibonacciChan(ctx), 10)); !slices.Equal(got, fib10) {
+		t.Errorf("TakeChan(FibonacciChan) = %v", got)
+	}
+	evens := FilterChan(ctx, Chan(ctx, Count(0, 1)), isEven)
+	strs := MapChan(ctx, evens, func(v int) string { return string(rune('a' + v)) })
+	var got []Pair[string, int]
+	for p := range TakeChan(ctx, ZipChan(ctx, strs, FibonacciChan(ctx)), 3) {
+		got = append(got, p)
+	}
+	want := []Pair[string, int]{{"a", 0}, {"c", 1}, {"e", 1}}
+	if !slices.Equal(got, want) {
+		t.Errorf("ZipChan = %v", got)
+	}
+	chunks := collect(ChunkChan(ctx, Chan(ctx, slices.Values([]int{1, 2, 3, 4, 5})), 2))
+	if len(chunks) != 3 || !slices.Equal(chunks[2], []int{5}) {
+		t.Errorf("ChunkChan = %v", chunks)
+	}
+	if got := slices.Collect(Take(Values(TakeChan(ctx, FibonacciChan(ctx), 20)), 5)); !slices.Equal(got, fib10[:5]) {
+		t.Errorf("Values = %v", got)
+	}
+	// 上面的无限生成器还阻塞在发送上，cancel 之后 noLeak 检查它们都退出了
+}
+
+func Test_ChanCancel(t *testing.T) {
+	noLeak(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := MapChan(ctx, FibonacciChan(ctx), func(v int) int { return v * 2 })
+	<-ch
+	<-ch
+	cancel()
+	// 取消后输出最终会关闭
+	for range ch {
+	}
+}
@@ -0,0 +1,135 @@
+package ordmap
+
+import "cmp"
+
+// AVL 是 AVL 树实现的 Map：任意节点左右子树的高度差不超过 1。
+// 查找比红黑树略快，插入和删除时旋转略多。
+type AVL[K, V any] struct {
+	base[K, V]
+}
+
+// NewAVL 创建一个按 < 排序的 AVL 树
+func NewAVL[K cmp.Ordered, V any]() *AVL[K, V] {
+	return NewAVLFunc[K, V](cmp.Compare[K])
+}
+
+// NewAVLFunc 创建一个按 compare 排序的 AVL 树
+func NewAVLFunc[K, V any](compare func(a, b K) int) *AVL[K, V] {
+	return &AVL[K, V]{base[K, V]{cmp: compare}}
+}
+
+func height[K, V any](n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (t *AVL[K, V]) fix(n *node[K, V]) {
+	n.height = 1 + max(height(n.left), height(n.right))
+	n.size = 1 + size(n.left) + size(n.right)
+}
+
+func (t *AVL[K, V]) rotateRight(n *node[K, V]) *node[K, V] {
+	l := n.left
+	n.left, l.right = l.right, n
+	t.fix(n)
+	t.fix(l)
+	return l
+}
+
+func (t *AVL[K, V]) rotateLeft(n *node[K, V]) *node[K, V] {
+	r := n.right
+	n.right, r.left = r.left, n
+	t.fix(n)
+	t.fix(r)
+	return r
+}
+
+// balance 在子树改变后重新计算 n 的高度，必要时旋转，返回新的子树根
+func (t *AVL[K, V]) balance(n *node[K, V]) *node[K, V] {
+	t.fix(n)
+	switch bf := height(n.left) - height(n.right); {
+	case bf > 1:
+		if height(n.left.left) < height(n.left.right) {
+			n.left = t.rotateLeft(n.left)
+		}
+		return t.rotateRight(n)
+	case bf < -1:
+		if height(n.right.right) < height(n.right.left) {
+			n.right = t.rotateRight(n.right)
+		}
+		return t.rotateLeft(n)
+	}
+	return n
+}
+
+func (t *AVL[K, V]) Put(k K, v V) bool {
+	var replaced bool
+	t.root = t.put(t.root, k, v, &replaced)
+	return replaced
+}
+
+func (t *AVL[K, V]) put(n *node[K, V], k K, v V, replaced *bool) *node[K, V] {
+	if n == nil {
+		return &node[K, V]{key: k, val: v, size: 1, height: 1}
+	}
+	switch c := t.cmp(k, n.key); {
+	case c < 0:
+		n.left = t.put(n.left, k, v, replaced)
+	case c > 0:
+		n.right = t.put(n.right, k, v, replaced)
+	default:
+		n.val = v
+		*replaced = true
+		return n
+	}
+	return t.balance(n)
+}
+
+func (t *AVL[K, V]) Delete(k K) bool {
+	var deleted bool
+	t.root = t.delete(t.root, k, &deleted)
+	return deleted
+}
+
+func (t *AVL[K, V]) delete(n *node[K, V], k K, deleted *bool) *node[K, V] {
+	if n == nil {
+		return nil
+	}
+	switch c := t.cmp(k, n.key); {
+	case c < 0:
+		n.left = t.delete(n.left, k, deleted)
+	case c > 0:
+		n.right = t.delete(n.right, k, deleted)
+	default:
+		*deleted = true
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		// 用右子树中最小的节点替换 n
+		var m *node[K, V]
+		right := t.deleteMin(n.right, &m)
+		m.left, m.right = n.left, right
+		return t.balance(m)
+	}
+	return t.balance(n)
+}
+
+// deleteMin 从子树中摘下最小的节点放到 *out，返回新的子树根
+func (t *AVL[K, V]) deleteMin(n *node[K, V], out **node[K, V]) *node[K, V] {
+	if n.left == nil {
+		*out = n
+		return n.right
+	}
+	n.left = t.deleteMin(n.left, out)
+	return t.balance(n)
+}
+
+// Height 返回树的高度，用于测试和比较
+func (t *AVL[K, V]) Height() int {
+	return height(t.root)
+}
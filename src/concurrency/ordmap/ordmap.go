@@ -0,0 +1,206 @@
+// Package ordmap 提供按键排序的映射，AVL 树和红黑树两种实现的所有操作都是 O(log n)。
+//
+// 和 concurrency/tree 中不做平衡的二叉查找树不同，按顺序插入不会让树退化成链表。
+package ordmap
+
+import "iter"
+
+// Map 是按键排序的映射
+type Map[K, V any] interface {
+	// Get 返回键 k 对应的值
+	Get(k K) (V, bool)
+	// Put 设置键 k 的值，k 已经存在时返回 true
+	Put(k K, v V) bool
+	// Delete 删除键 k，k 不存在时返回 false
+	Delete(k K) bool
+	// Len 返回键的个数
+	Len() int
+
+	// Min 和 Max 返回最小和最大的键
+	Min() (K, V, bool)
+	Max() (K, V, bool)
+	// Floor 返回小于等于 k 的最大的键
+	Floor(k K) (K, V, bool)
+	// Ceiling 返回大于等于 k 的最小的键
+	Ceiling(k K) (K, V, bool)
+
+	// Rank 返回小于 k 的键的个数
+	Rank(k K) int
+	// Select 返回第 i 小的键（从 0 开始）
+	Select(i int) (K, V, bool)
+
+	// All 按键从小到大遍历
+	All() iter.Seq2[K, V]
+	// Range 按从小到大遍历 lo <= 键 < hi 的部分
+	Range(lo, hi K) iter.Seq2[K, V]
+}
+
+// node 是两种树共用的节点，AVL 树使用 height，红黑树使用 red。
+// size 是以这个节点为根的子树的节点数，用来实现 Rank 和 Select。
+type node[K, V any] struct {
+	key         K
+	val         V
+	left, right *node[K, V]
+	size        int
+	height      int
+	red         bool
+}
+
+func size[K, V any](n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// base 实现了两种树共用的、不修改树的操作
+type base[K, V any] struct {
+	root *node[K, V]
+	cmp  func(a, b K) int
+}
+
+func (t *base[K, V]) Len() int {
+	return size(t.root)
+}
+
+func (t *base[K, V]) Get(k K) (V, bool) {
+	n := t.root
+	for n != nil {
+		switch c := t.cmp(k, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.val, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func result[K, V any](n *node[K, V]) (K, V, bool) {
+	if n == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	return n.key, n.val, true
+}
+
+func (t *base[K, V]) Min() (K, V, bool) {
+	n := t.root
+	for n != nil && n.left != nil {
+		n = n.left
+	}
+	return result(n)
+}
+
+func (t *base[K, V]) Max() (K, V, bool) {
+	n := t.root
+	for n != nil && n.right != nil {
+		n = n.right
+	}
+	return result(n)
+}
+
+func (t *base[K, V]) Floor(k K) (K, V, bool) {
+	var best *node[K, V]
+	n := t.root
+	for n != nil {
+		switch c := t.cmp(k, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			best, n = n, n.right
+		default:
+			return result(n)
+		}
+	}
+	return result(best)
+}
+
+func (t *base[K, V]) Ceiling(k K) (K, V, bool) {
+	var best *node[K, V]
+	n := t.root
+	for n != nil {
+		switch c := t.cmp(k, n.key); {
+		case c < 0:
+			best, n = n, n.left
+		case c > 0:
+			n = n.right
+		default:
+			return result(n)
+		}
+	}
+	return result(best)
+}
+
+func (t *base[K, V]) Rank(k K) int {
+	r := 0
+	n := t.root
+	for n != nil {
+		switch c := t.cmp(k, n.key); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			r += size(n.left) + 1
+			n = n.right
+		default:
+			return r + size(n.left)
+		}
+	}
+	return r
+}
+
+func (t *base[K, V]) Select(i int) (K, V, bool) {
+	n := t.root
+	for n != nil {
+		switch l := size(n.left); {
+		case i < l:
+			n = n.left
+		case i > l:
+			i -= l + 1
+			n = n.right
+		default:
+			return result(n)
+		}
+	}
+	return result(n)
+}
+
+func (t *base[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.walk(t.root, nil, nil, yield)
+	}
+}
+
+func (t *base[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.walk(t.root, &lo, &hi, yield)
+	}
+}
+
+// walk 中序遍历 [lo, hi) 范围内的节点，lo 或 hi 为 nil 表示不限制，返回 false 表示 yield 要求停止
+func (t *base[K, V]) walk(n *node[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	aboveLo := lo == nil || t.cmp(n.key, *lo) >= 0
+	belowHi := hi == nil || t.cmp(n.key, *hi) < 0
+	if aboveLo && !t.walk(n.left, lo, hi, yield) {
+		return false
+	}
+	if aboveLo && belowHi && !yield(n.key, n.val) {
+		return false
+	}
+	if belowHi {
+		return t.walk(n.right, lo, hi, yield)
+	}
+	return true
+}
+
+var (
+	_ Map[int, int] = (*AVL[int, int])(nil)
+	_ Map[int, int] = (*RedBlack[int, int])(nil)
+)
@@ -0,0 +1,247 @@
+package ordmap
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"concurrency/tree"
+)
+
+var impls = []struct {
+	name string
+	new  func() Map[int, string]
+}{
+	{"AVL", func() Map[int, string] { return NewAVL[int, string]() }},
+	{"RedBlack", func() Map[int, string] { return NewRedBlack[int, string]() }},
+}
+
+// check 检查树的结构性质：有序、size 正确、AVL 平衡因子、红黑树的黑高度和左倾
+func check[K, V any](t *testing.T, m Map[K, V]) {
+	t.Helper()
+	var root *node[K, V]
+	var cmp func(a, b K) int
+	var avl bool
+	switch m := m.(type) {
+	case *AVL[K, V]:
+		root, cmp, avl = m.root, m.cmp, true
+	case *RedBlack[K, V]:
+		root, cmp = m.root, m.cmp
+		if isRed(root) {
+			t.Fatal("red root")
+		}
+	}
+	var walk func(n *node[K, V], lo, hi *K) (h, black int)
+	walk = func(n *node[K, V], lo, hi *K) (int, int) {
+		if n == nil {
+			return 0, 0
+		}
+		if lo != nil && cmp(n.key, *lo) <= 0 || hi != nil && cmp(n.key, *hi) >= 0 {
+			t.Fatalf("order violated at %v", n.key)
+		}
+		lh, lb := walk(n.left, lo, &n.key)
+		rh, rb := walk(n.right, &n.key, hi)
+		if n.size != 1+size(n.left)+size(n.right) {
+			t.Fatalf("bad size at %v", n.key)
+		}
+		if avl {
+			if n.height != 1+max(lh, rh) || lh-rh > 1 || rh-lh > 1 {
+				t.Fatalf("AVL unbalanced at %v", n.key)
+			}
+		} else {
+			if isRed(n.right) || isRed(n) && isRed(n.left) {
+				t.Fatalf("red link violation at %v", n.key)
+			}
+			if lb != rb {
+				t.Fatalf("black height %d != %d at %v", lb, rb, n.key)
+			}
+			if !n.red {
+				lb++
+			}
+		}
+		return 1 + max(lh, rh), lb
+	}
+	walk(root, nil, nil)
+}
+
+func Test_RandomOps(t *testing.T) {
+	for _, impl := range impls {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.new()
+			ref := map[int]string{}
+			r := rand.New(rand.NewSource(42))
+			for i := 0; i < 5000; i++ {
+				k := r.Intn(500)
+				if r.Intn(3) == 0 {
+					_, had := ref[k]
+					if m.Delete(k) != had {
+						t.Fatalf("Delete(%d) != %v", k, had)
+					}
+					delete(ref, k)
+				} else {
+					_, had := ref[k]
+					v := string(rune('a' + r.Intn(26)))
+					if m.Put(k, v) != had {
+						t.Fatalf("Put(%d) != %v", k, had)
+					}
+					ref[k] = v
+				}
+				if i%250 == 0 {
+					check(t, m)
+				}
+			}
+			check(t, m)
+
+			keys := make([]int, 0, len(ref))
+			for k := range ref {
+				keys = append(keys, k)
+			}
+			sort.Ints(keys)
+			if m.Len() != len(keys) {
+				t.Fatalf("Len = %d, want %d", m.Len(), len(keys))
+			}
+			i := 0
+			for k, v := range m.All() {
+				if k != keys[i] || v != ref[k] {
+					t.Fatalf("All()[%d] = %d:%s", i, k, v)
+				}
+				i++
+			}
+			for i, k := range keys {
+				if got, _, _ := m.Select(i); got != k {
+					t.Fatalf("Select(%d) = %d, want %d", i, got, k)
+				}
+				if m.Rank(k) != i {
+					t.Fatalf("Rank(%d) = %d, want %d", k, m.Rank(k), i)
+				}
+			}
+		})
+	}
+}
+
+func Test_Navigation(t *testing.T) {
+	for _, impl := range impls {
+		t.Run(impl.name, func(t *testing.T) {
+			m := impl.new()
+			if _, _, ok := m.Min(); ok {
+				t.Error("Min of empty map")
+			}
+			for _, k := range []int{10, 20, 30, 40, 50} {
+				m.Put(k, "")
+			}
+			cases := []struct {
+				k, floor, ceil int
+				fok, cok       bool
+			}{
+				{5, 0, 10, false, true},
+				{10, 10, 10, true, true},
+				{25, 20, 30, true, true},
+				{55, 50, 0, true, false},
+			}
+			for _, c := range cases {
+				if f, _, ok := m.Floor(c.k); ok != c.fok || f != c.floor {
+					t.Errorf("Floor(%d) = %d, %v", c.k, f, ok)
+				}
+				if cl, _, ok := m.Ceiling(c.k); ok != c.cok || cl != c.ceil {
+					t.Errorf("Ceiling(%d) = %d, %v", c.k, cl, ok)
+				}
+			}
+			var got []int
+			for k := range m.Range(15, 40) {
+				got = append(got, k)
+			}
+			if len(got) != 2 || got[0] != 20 || got[1] != 30 {
+				t.Errorf("Range(15, 40) = %v", got)
+			}
+			if m.Rank(35) != 3 || m.Rank(0) != 0 || m.Rank(99) != 5 {
+				t.Errorf("Rank = %d %d %d", m.Rank(35), m.Rank(0), m.Rank(99))
+			}
+			if _, _, ok := m.Select(5); ok {
+				t.Error("Select(5) out of range")
+			}
+			if lo, _, _ := m.Min(); lo != 10 {
+				t.Errorf("Min = %d", lo)
+			}
+			if hi, _, _ := m.Max(); hi != 50 {
+				t.Errorf("Max = %d", hi)
+			}
+		})
+	}
+}
+
+func Test_SortedInsertHeight(t *testing.T) {
+	avl, rb := NewAVL[int, int](), NewRedBlack[int, int]()
+	for i := 0; i < 1<<12; i++ {
+		avl.Put(i, i)
+		rb.Put(i, i)
+	}
+	// n = 4096 时 AVL 最高约 1.44*log2(n)，红黑树最高 2*log2(n)
+	if h := avl.Height(); h > 18 {
+		t.Errorf("AVL height %d", h)
+	}
+	if h := rb.Height(); h > 24 {
+		t.Errorf("RedBlack height %d", h)
+	}
+}
+
+const benchN = 1 << 12
+
+func benchKeys(sorted bool) []int {
+	keys := rand.New(rand.NewSource(1)).Perm(benchN)
+	if sorted {
+		sort.Ints(keys)
+	}
+	return keys
+}
+
+func benchmarkPut(b *testing.B, sorted bool) {
+	keys := benchKeys(sorted)
+	for _, impl := range impls {
+		b.Run(impl.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := impl.new()
+				for _, k := range keys {
+					m.Put(k, "")
+				}
+			}
+		})
+	}
+	b.Run("Unbalanced", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bst := tree.NewOrdered[int]()
+			for _, k := range keys {
+				bst.Insert(k)
+			}
+		}
+	})
+}
+
+func Benchmark_PutRandom(b *testing.B) { benchmarkPut(b, false) }
+func Benchmark_PutSorted(b *testing.B) { benchmarkPut(b, true) }
+
+func benchmarkGet(b *testing.B, sorted bool) {
+	keys := benchKeys(sorted)
+	for _, impl := range impls {
+		m := impl.new()
+		for _, k := range keys {
+			m.Put(k, "")
+		}
+		b.Run(impl.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.Get(keys[i%benchN])
+			}
+		})
+	}
+	bst := tree.NewOrdered[int]()
+	for _, k := range keys {
+		bst.Insert(k)
+	}
+	b.Run("Unbalanced", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			bst.Contains(keys[i%benchN])
+		}
+	})
+}
+
+func Benchmark_GetRandom(b *testing.B) { benchmarkGet(b, false) }
+func Benchmark_GetSorted(b *testing.B) { benchmarkGet(b, true) }
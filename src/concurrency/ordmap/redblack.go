@@ -0,0 +1,175 @@
+package ordmap
+
+import "cmp"
+
+// RedBlack 是左倾红黑树（LLRB）实现的 Map，红色链接只出现在左边，
+// 它和 2-3 树一一对应，最长路径不超过最短路径的两倍。插入和删除时的旋转比 AVL 树少。
+type RedBlack[K, V any] struct {
+	base[K, V]
+}
+
+// NewRedBlack 创建一个按 < 排序的红黑树
+func NewRedBlack[K cmp.Ordered, V any]() *RedBlack[K, V] {
+	return NewRedBlackFunc[K, V](cmp.Compare[K])
+}
+
+// NewRedBlackFunc 创建一个按 compare 排序的红黑树
+func NewRedBlackFunc[K, V any](compare func(a, b K) int) *RedBlack[K, V] {
+	return &RedBlack[K, V]{base[K, V]{cmp: compare}}
+}
+
+func isRed[K, V any](n *node[K, V]) bool {
+	return n != nil && n.red
+}
+
+func rbRotateLeft[K, V any](n *node[K, V]) *node[K, V] {
+	r := n.right
+	n.right, r.left = r.left, n
+	r.red, n.red = n.red, true
+	r.size = n.size
+	n.size = 1 + size(n.left) + size(n.right)
+	return r
+}
+
+func rbRotateRight[K, V any](n *node[K, V]) *node[K, V] {
+	l := n.left
+	n.left, l.right = l.right, n
+	l.red, n.red = n.red, true
+	l.size = n.size
+	n.size = 1 + size(n.left) + size(n.right)
+	return l
+}
+
+func flipColors[K, V any](n *node[K, V]) {
+	n.red = !n.red
+	n.left.red = !n.left.red
+	n.right.red = !n.right.red
+}
+
+// fixUp 恢复左倾红黑树的性质并更新 size
+func fixUp[K, V any](n *node[K, V]) *node[K, V] {
+	if isRed(n.right) && !isRed(n.left) {
+		n = rbRotateLeft(n)
+	}
+	if isRed(n.left) && isRed(n.left.left) {
+		n = rbRotateRight(n)
+	}
+	if isRed(n.left) && isRed(n.right) {
+		flipColors(n)
+	}
+	n.size = 1 + size(n.left) + size(n.right)
+	return n
+}
+
+func (t *RedBlack[K, V]) Put(k K, v V) bool {
+	var replaced bool
+	t.root = t.put(t.root, k, v, &replaced)
+	t.root.red = false
+	return replaced
+}
+
+func (t *RedBlack[K, V]) put(n *node[K, V], k K, v V, replaced *bool) *node[K, V] {
+	if n == nil {
+		return &node[K, V]{key: k, val: v, size: 1, red: true}
+	}
+	switch c := t.cmp(k, n.key); {
+	case c < 0:
+		n.left = t.put(n.left, k, v, replaced)
+	case c > 0:
+		n.right = t.put(n.right, k, v, replaced)
+	default:
+		n.val = v
+		*replaced = true
+	}
+	return fixUp(n)
+}
+
+// moveRedLeft 假设 n 是红色且 n.left 和 n.left.left 都是黑色，把 n.left 或它的一个子节点变红
+func moveRedLeft[K, V any](n *node[K, V]) *node[K, V] {
+	flipColors(n)
+	if isRed(n.right.left) {
+		n.right = rbRotateRight(n.right)
+		n = rbRotateLeft(n)
+		flipColors(n)
+	}
+	return n
+}
+
+// moveRedRight 假设 n 是红色且 n.right 和 n.right.left 都是黑色，把 n.right 或它的一个子节点变红
+func moveRedRight[K, V any](n *node[K, V]) *node[K, V] {
+	flipColors(n)
+	if isRed(n.left.left) {
+		n = rbRotateRight(n)
+		flipColors(n)
+	}
+	return n
+}
+
+func rbDeleteMin[K, V any](n *node[K, V]) *node[K, V] {
+	if n.left == nil {
+		return nil
+	}
+	if !isRed(n.left) && !isRed(n.left.left) {
+		n = moveRedLeft(n)
+	}
+	n.left = rbDeleteMin(n.left)
+	return fixUp(n)
+}
+
+func (t *RedBlack[K, V]) Delete(k K) bool {
+	if _, ok := t.Get(k); !ok {
+		return false
+	}
+	if !isRed(t.root.left) && !isRed(t.root.right) {
+		t.root.red = true
+	}
+	t.root = t.delete(t.root, k)
+	if t.root != nil {
+		t.root.red = false
+	}
+	return true
+}
+
+// delete 删除一个确定存在的键
+func (t *RedBlack[K, V]) delete(n *node[K, V], k K) *node[K, V] {
+	if t.cmp(k, n.key) < 0 {
+		if !isRed(n.left) && !isRed(n.left.left) {
+			n = moveRedLeft(n)
+		}
+		n.left = t.delete(n.left, k)
+		return fixUp(n)
+	}
+	if isRed(n.left) {
+		n = rbRotateRight(n)
+	}
+	if t.cmp(k, n.key) == 0 && n.right == nil {
+		return nil
+	}
+	if !isRed(n.right) && !isRed(n.right.left) {
+		n = moveRedRight(n)
+	}
+	if t.cmp(k, n.key) == 0 {
+		// 用右子树中最小的节点替换 n
+		m := n.right
+		for m.left != nil {
+			m = m.left
+		}
+		n.key, n.val = m.key, m.val
+		n.right = rbDeleteMin(n.right)
+	} else {
+		n.right = t.delete(n.right, k)
+	}
+	return fixUp(n)
+}
+
+// Height 返回树的高度，用于测试和比较
+func (t *RedBlack[K, V]) Height() int {
+	var h func(n *node[K, V]) int
+	h = func(n *node[K, V]) int {
+		if n == nil {
+			return 0
+		}
+		return 1 + max(h(n.left), h(n.right))
+	}
+	return h(t.root)
+}
@@ -0,0 +1,93 @@
+// Package pipeline 用信道搭建扇出/扇入的流水线。
+//
+// 一条流水线由若干阶段组成，每个阶段从上一个阶段的信道读取、向自己的输出信道写入，
+// 阶段之间的信道缓冲大小决定了快的阶段能领先慢的阶段多少（背压）。
+// 任何一个阶段出错都会取消整条流水线的 context，所有阶段随之退出，Wait 返回第一个错误。
+//
+//	p := pipeline.New(ctx)
+//	urls := pipeline.FromSlice(p, list, 0)
+//	pages := pipeline.Map(p, urls, fetch, pipeline.Options{Workers: 8})
+//	sizes, err := pipeline.Collect(p, pipeline.Map(p, pages, size, pipeline.Options{}))
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline 管理流水线中所有阶段的 Go 程，零值不可用，需要用 New 创建
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// New 创建一条流水线，ctx 被取消时流水线也会被取消
+func New(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Pipeline{ctx: ctx, cancel: cancel}
+}
+
+// Context 返回流水线的 context，出错或被取消后它会结束
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Go 在流水线中启动一个 Go 程，f 返回的错误会取消整条流水线
+func (p *Pipeline) Go(f func(ctx context.Context) error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := f(p.ctx); err != nil {
+			p.Fail(err)
+		}
+	}()
+}
+
+// Fail 记录错误并取消流水线，只有第一个错误会被 Wait 返回
+func (p *Pipeline) Fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+	p.cancel(err)
+}
+
+// Wait 等待所有阶段结束，返回第一个错误。
+// 没有阶段出错但外层的 ctx 被取消时返回 ctx 的错误。
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.mu.Lock()
+	err := p.err
+	p.mu.Unlock()
+	if err == nil {
+		err = p.ctx.Err()
+	}
+	p.cancel(nil)
+	return err
+}
+
+// send 把 v 发送到 ch，流水线被取消时返回 false
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv 从 ch 接收一个值，ch 关闭或流水线被取消时返回 false
+func recv[T any](ctx context.Context, ch <-chan T) (T, bool) {
+	select {
+	case v, ok := <-ch:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
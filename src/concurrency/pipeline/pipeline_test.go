@@ -0,0 +1,216 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func square(_ context.Context, v int) (int, error) {
+	return v * v, nil
+}
+
+func ints(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
+
+// noLeak 检查测试结束后没有遗留的 Go 程
+func noLeak(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := runtime.NumGoroutine(); n > before {
+			t.Errorf("goroutines leaked: %d before, %d after", before, n)
+		}
+	})
+}
+
+// storeMax 记录并发数的峰值
+func storeMax(peak *atomic.Int64, n int64) {
+	for {
+		old := peak.Load()
+		if n <= old || peak.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+func Test_Map(t *testing.T) {
+	noLeak(t)
+	p := New(context.Background())
+	in := FromSlice(p, ints(100), 0)
+	even := Filter(p, in, func(_ context.Context, v int) (bool, error) { return v%2 == 0, nil }, Options{Workers: 3})
+	got, err := Collect(p, Map(p, even, square, Options{Workers: 4, Buffer: 8}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(got)
+	if len(got) != 50 || got[0] != 0 || got[1] != 4 || got[49] != 98*98 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func Test_MapOrdered(t *testing.T) {
+	noLeak(t)
+	p := New(context.Background())
+	in := FromSlice(p, ints(200), 0)
+	// 让前面的值处理得更慢，检查输出仍然按顺序
+	slow := func(ctx context.Context, v int) (int, error) {
+		if v%10 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		return v * 2, nil
+	}
+	got, err := Collect(p, MapOrdered(p, in, slow, Options{Workers: 8, Buffer: 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range got {
+		if v != i*2 {
+			t.Fatalf("got[%d] = %d", i, v)
+		}
+	}
+	if len(got) != 200 {
+		t.Errorf("len = %d", len(got))
+	}
+}
+
+func Test_MapOrderedBounded(t *testing.T) {
+	noLeak(t)
+	var inFlight, peak atomic.Int64
+	f := func(ctx context.Context, v int) (int, error) {
+		storeMax(&peak, inFlight.Add(1))
+		time.Sleep(time.Millisecond)
+		inFlight.Add(-1)
+		return v, nil
+	}
+	p := New(context.Background())
+	if _, err := Collect(p, MapOrdered(p, FromSlice(p, ints(50), 0), f, Options{Workers: 3})); err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() > 3 {
+		t.Errorf("peak concurrency %d > 3 workers", peak.Load())
+	}
+}
+
+func Test_ErrorCancels(t *testing.T) {
+	noLeak(t)
+	boom := errors.New("boom")
+	var calls atomic.Int64
+	p := New(context.Background())
+	// 无限的源头，只有取消才能让它停下来
+	src := Generate(p, func(ctx context.Context) (int, bool, error) {
+		return int(calls.Add(1)), true, nil
+	}, 0)
+	mapped := Map(p, src, func(_ context.Context, v int) (int, error) {
+		if v == 20 {
+			return 0, boom
+		}
+		return v, nil
+	}, Options{Workers: 2})
+	_, err := Collect(p, MapOrdered(p, mapped, square, Options{Workers: 2}))
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if p.Context().Err() == nil {
+		t.Error("context not canceled")
+	}
+}
+
+func Test_ParentCancel(t *testing.T) {
+	noLeak(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx)
+	src := Generate(p, func(ctx context.Context) (int, bool, error) { return 1, true, nil }, 0)
+	n := 0
+	err := ForEach(p, src, func(context.Context, int) error {
+		if n++; n == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_BatchMerge(t *testing.T) {
+	noLeak(t)
+	p := New(context.Background())
+	a := FromSlice(p, []int{1, 2, 3}, 0)
+	b := FromSeq(p, slices.Values([]int{4, 5, 6, 7}), 0)
+	batches, err := Collect(p, Batch(p, Merge(p, a, b), 3, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all []int
+	for _, b := range batches {
+		all = append(all, b...)
+	}
+	sort.Ints(all)
+	if len(batches) != 3 || len(batches[2]) != 1 || !slices.Equal(all, []int{1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("batches = %v", batches)
+	}
+}
+
+func Test_Pool(t *testing.T) {
+	noLeak(t)
+	pl := NewPool(context.Background(), 4)
+	var running, peak, done atomic.Int64
+	for range 40 {
+		err := pl.Submit(func(ctx context.Context) error {
+			storeMax(&peak, running.Add(1))
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pl.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if done.Load() != 40 || peak.Load() > 4 {
+		t.Errorf("done = %d, peak = %d", done.Load(), peak.Load())
+	}
+
+	boom := errors.New("boom")
+	pl = NewPool(context.Background(), 2)
+	pl.Submit(func(context.Context) error { return boom })
+	<-pl.Context().Done()
+	if err := pl.Submit(func(context.Context) error { return nil }); !errors.Is(err, boom) {
+		t.Errorf("Submit after failure = %v", err)
+	}
+	if err := pl.Wait(); !errors.Is(err, boom) {
+		t.Errorf("Wait = %v", err)
+	}
+}
+
+// 背压：缓冲越大，快的源头能领先慢的消费者越多
+func Benchmark_Buffer(b *testing.B) {
+	for _, buf := range []int{0, 16, 256} {
+		b.Run("Buffer"+strconv.Itoa(buf), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p := New(context.Background())
+				in := FromSlice(p, ints(1000), buf)
+				Collect(p, Map(p, in, square, Options{Workers: 4, Buffer: buf}))
+			}
+		})
+	}
+}
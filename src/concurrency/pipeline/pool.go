@@ -0,0 +1,45 @@
+package pipeline
+
+import "context"
+
+// Pool 是有上限的工作池：同时最多运行 n 个任务，池满时 Submit 会阻塞，
+// 从而把压力传回提交任务的一方。任何一个任务出错都会取消其余的任务。
+type Pool struct {
+	p   *Pipeline
+	sem chan struct{}
+}
+
+// NewPool 创建一个最多同时运行 n 个任务的工作池，n <= 0 时为 1
+func NewPool(ctx context.Context, n int) *Pool {
+	return &Pool{p: New(ctx), sem: make(chan struct{}, max(n, 1))}
+}
+
+// Context 返回工作池的 context，任务出错或被取消后它会结束
+func (pl *Pool) Context() context.Context {
+	return pl.p.Context()
+}
+
+// Submit 提交一个任务，池满时等待有任务结束。
+// 工作池已经被取消时不会运行 f，返回取消的原因。
+func (pl *Pool) Submit(f func(ctx context.Context) error) error {
+	ctx := pl.p.Context()
+	// 池没满时 select 也可能选中 sem，所以先单独检查是否已经取消
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	select {
+	case pl.sem <- struct{}{}:
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+	pl.p.Go(func(ctx context.Context) error {
+		defer func() { <-pl.sem }()
+		return f(ctx)
+	})
+	return nil
+}
+
+// Wait 等待所有已提交的任务结束，返回第一个错误
+func (pl *Pool) Wait() error {
+	return pl.p.Wait()
+}
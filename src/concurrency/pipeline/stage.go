@@ -0,0 +1,254 @@
+package pipeline
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// Options 是一个阶段的并发度和输出缓冲
+type Options struct {
+	Workers int // 并发的 Go 程数，<= 0 时为 1
+	Buffer  int // 输出信道的缓冲大小
+}
+
+func (o Options) workers() int {
+	return max(o.Workers, 1)
+}
+
+// FromSlice 是流水线的源头，依次发送 items 中的值
+func FromSlice[T any](p *Pipeline, items []T, buffer int) <-chan T {
+	out := make(chan T, buffer)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for _, v := range items {
+			if !send(ctx, out, v) {
+				break
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// FromSeq 是流水线的源头，依次发送 seq 产生的值
+func FromSeq[T any](p *Pipeline, seq iter.Seq[T], buffer int) <-chan T {
+	out := make(chan T, buffer)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for v := range seq {
+			if !send(ctx, out, v) {
+				break
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Generate 是流水线的源头，反复调用 f 直到它返回 false 或出错
+func Generate[T any](p *Pipeline, f func(ctx context.Context) (T, bool, error), buffer int) <-chan T {
+	out := make(chan T, buffer)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for {
+			v, ok, err := f(ctx)
+			if err != nil {
+				return err
+			}
+			if !ok || !send(ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// workers 启动 n 个 Go 程执行 f，全部结束后关闭 out
+func workers[T any](p *Pipeline, n int, out chan T, f func(ctx context.Context) error) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for range n {
+		p.Go(func(ctx context.Context) error {
+			defer wg.Done()
+			return f(ctx)
+		})
+	}
+	p.Go(func(context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+}
+
+// Map 用 opts.Workers 个 Go 程并发地对每个值调用 f，输出的顺序不确定。
+// f 返回错误时取消整条流水线。
+func Map[In, Out any](p *Pipeline, in <-chan In, f func(ctx context.Context, v In) (Out, error), opts Options) <-chan Out {
+	out := make(chan Out, opts.Buffer)
+	workers(p, opts.workers(), out, func(ctx context.Context) error {
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			r, err := f(ctx, v)
+			if err != nil {
+				return err
+			}
+			if !send(ctx, out, r) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// Filter 并发地对每个值调用 keep，只输出返回 true 的值，输出的顺序不确定
+func Filter[T any](p *Pipeline, in <-chan T, keep func(ctx context.Context, v T) (bool, error), opts Options) <-chan T {
+	out := make(chan T, opts.Buffer)
+	workers(p, opts.workers(), out, func(ctx context.Context) error {
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			k, err := keep(ctx, v)
+			if err != nil {
+				return err
+			}
+			if k && !send(ctx, out, v) {
+				return nil
+			}
+		}
+	})
+	return out
+}
+
+// MapOrdered 和 Map 一样并发地调用 f，但按输入的顺序输出。
+// 最多有 Workers+Buffer 个值在处理中或等待输出，一个慢的值会挡住它后面已经算好的值。
+func MapOrdered[In, Out any](p *Pipeline, in <-chan In, f func(ctx context.Context, v In) (Out, error), opts Options) <-chan Out {
+	type job struct {
+		v      In
+		result chan Out
+	}
+	n := opts.workers()
+	jobs := make(chan job)
+	// pending 按输入顺序保存每个值的结果信道，它的容量限制了处理中的值的个数
+	pending := make(chan chan Out, n+opts.Buffer)
+	out := make(chan Out, opts.Buffer)
+
+	p.Go(func(ctx context.Context) error {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			j := job{v, make(chan Out, 1)}
+			if !send(ctx, pending, j.result) || !send(ctx, jobs, j) {
+				return nil
+			}
+		}
+	})
+	for range n {
+		p.Go(func(ctx context.Context) error {
+			for j := range jobs {
+				r, err := f(ctx, j.v)
+				if err != nil {
+					return err
+				}
+				j.result <- r
+			}
+			return nil
+		})
+	}
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		for result := range pending {
+			r, ok := recv(ctx, result)
+			if !ok || !send(ctx, out, r) {
+				return nil
+			}
+		}
+		return nil
+	})
+	return out
+}
+
+// Batch 把输入的值每 size 个分成一组，最后一组可能不满
+func Batch[T any](p *Pipeline, in <-chan T, size int, buffer int) <-chan []T {
+	out := make(chan []T, buffer)
+	p.Go(func(ctx context.Context) error {
+		defer close(out)
+		var batch []T
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				break
+			}
+			if batch = append(batch, v); len(batch) == size {
+				if !send(ctx, out, batch) {
+					return nil
+				}
+				batch = nil
+			}
+		}
+		if len(batch) > 0 && ctx.Err() == nil {
+			send(ctx, out, batch)
+		}
+		return nil
+	})
+	return out
+}
+
+// Merge 把多个信道合并成一个（扇入），输出的顺序不确定
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		p.Go(func(ctx context.Context) error {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return nil
+				}
+			}
+		})
+	}
+	p.Go(func(context.Context) error {
+		wg.Wait()
+		close(out)
+		return nil
+	})
+	return out
+}
+
+// ForEach 是流水线的终点，依次对每个值调用 f，然后等待整条流水线结束
+func ForEach[T any](p *Pipeline, in <-chan T, f func(ctx context.Context, v T) error) error {
+	p.Go(func(ctx context.Context) error {
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return nil
+			}
+			if err := f(ctx, v); err != nil {
+				return err
+			}
+		}
+	})
+	return p.Wait()
+}
+
+// Collect 是流水线的终点，收集所有的值，然后等待整条流水线结束。
+// 出错时返回已经收集到的值和第一个错误。
+func Collect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var out []T
+	err := ForEach(p, in, func(_ context.Context, v T) error {
+		out = append(out, v)
+		return nil
+	})
+	return out, err
+}
@@ -0,0 +1,115 @@
+// Package ratelimit 提供令牌桶和滑动窗口两种限流器，以及一个类似 cron 的定时任务调度器。
+//
+// 所有和时间有关的行为都通过 Clock 接口完成，测试中用 Fake 代替真实的时钟，
+// 调用 Advance 推进时间，不需要真的睡眠。
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock 是时间的来源
+type Clock interface {
+	Now() time.Time
+	// After 在 d 之后向返回的信道发送当时的时间
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real 是使用 time 包的真实时钟
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func clockOrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// Fake 是测试用的时钟，时间只在调用 Advance 或 Set 时前进
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+	changed chan struct{} // 每次增加等待者时关闭并替换，用于 BlockUntil
+}
+
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFake 创建一个当前时间为 now 的假时钟
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := make(chan time.Time, 1)
+	at := f.now.Add(d)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.waiters = append(f.waiters, waiter{at, c})
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+	close(f.changed)
+	f.changed = make(chan struct{})
+	return c
+}
+
+// Advance 把时间向前推进 d，触发所有到期的 After
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set 把时间设置为 t，触发所有到期的 After。时间不会后退。
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t.After(f.now) {
+		f.now = t
+	}
+	n := 0
+	for n < len(f.waiters) && !f.waiters[n].at.After(f.now) {
+		f.waiters[n].c <- f.now
+		n++
+	}
+	f.waiters = f.waiters[n:]
+}
+
+// Waiters 返回还没有到期的 After 的个数
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil 等待直到至少有 n 个未到期的 After。
+// 测试用它确认被测的 Go 程已经开始等待，然后再推进时间。
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		if len(f.waiters) >= n {
+			f.mu.Unlock()
+			return
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		<-changed
+	}
+}
@@ -0,0 +1,214 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter 是限流器
+type Limiter interface {
+	// Allow 在允许一个事件时返回 true，不等待
+	Allow() bool
+	// Wait 等待直到允许一个事件，ctx 结束时返回 ctx 的错误
+	Wait(ctx context.Context) error
+}
+
+// sleep 用 clock 等待 d，ctx 结束时返回 false
+func sleep(ctx context.Context, clock Clock, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-clock.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// TokenBucket 是令牌桶限流器：桶中最多有 burst 个令牌，每秒补充 rate 个，
+// 每个事件消耗一个令牌。长期的速率不超过 rate，短时间内允许 burst 个事件的突发。
+type TokenBucket struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket 创建一个装满令牌的令牌桶，clock 为 nil 时使用真实的时钟
+func NewTokenBucket(rate float64, burst int, clock Clock) *TokenBucket {
+	if rate <= 0 {
+		panic("ratelimit: non-positive rate for NewTokenBucket")
+	}
+	clock = clockOrReal(clock)
+	return &TokenBucket{
+		clock:  clock,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// refill 按经过的时间补充令牌，调用者需要持有锁
+func (b *TokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// Tokens 返回桶中当前的令牌数，预订了未来的令牌时可能是负数
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	return b.tokens
+}
+
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN 在桶中至少有 n 个令牌时消耗它们并返回 true
+func (b *TokenBucket) AllowN(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// Reserve 预订 n 个令牌，返回需要等待多久才能使用它们。
+// 令牌立即被扣除（桶可能变成负数），所以后来的调用者会排在后面。
+func (b *TokenBucket) Reserve(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 归还预订了却没有使用的令牌
+func (b *TokenBucket) cancel(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clock.Now())
+	b.tokens = min(b.burst, b.tokens+float64(n))
+}
+
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN 等待直到可以使用 n 个令牌，ctx 结束时归还预订的令牌并返回 ctx 的错误
+func (b *TokenBucket) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !sleep(ctx, b.clock, b.Reserve(n)) {
+		b.cancel(n)
+		return ctx.Err()
+	}
+	return nil
+}
+
+// SlidingWindow 是滑动窗口限流器：任意长度为 window 的时间段内最多允许 limit 个事件。
+// 它记录最近 limit 个事件的时间，比令牌桶精确，但不允许超过 limit 的突发。
+type SlidingWindow struct {
+	mu     sync.Mutex
+	clock  Clock
+	window time.Duration
+	times  []time.Time // 环形缓冲，保存最近 limit 个事件的时间
+	next   int         // 最早的事件在 times 中的下标
+}
+
+// NewSlidingWindow 创建一个滑动窗口限流器，clock 为 nil 时使用真实的时钟
+func NewSlidingWindow(limit int, window time.Duration, clock Clock) *SlidingWindow {
+	if limit <= 0 {
+		panic("ratelimit: non-positive limit for NewSlidingWindow")
+	}
+	return &SlidingWindow{
+		clock:  clockOrReal(clock),
+		window: window,
+		times:  make([]time.Time, 0, limit),
+	}
+}
+
+// delay 返回现在还要等多久才能允许下一个事件，调用者需要持有锁
+func (w *SlidingWindow) delay(now time.Time) time.Duration {
+	if len(w.times) < cap(w.times) {
+		return 0
+	}
+	return w.times[w.next].Add(w.window).Sub(now)
+}
+
+// record 记录一个发生在 t 的事件，调用者需要持有锁
+func (w *SlidingWindow) record(t time.Time) {
+	if len(w.times) < cap(w.times) {
+		w.times = append(w.times, t)
+		return
+	}
+	w.times[w.next] = t
+	w.next = (w.next + 1) % len(w.times)
+}
+
+// Count 返回当前窗口内的事件数
+func (w *SlidingWindow) Count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	start := w.clock.Now().Add(-w.window)
+	n := 0
+	for _, t := range w.times {
+		if t.After(start) {
+			n++
+		}
+	}
+	return n
+}
+
+func (w *SlidingWindow) Allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.clock.Now()
+	if w.delay(now) > 0 {
+		return false
+	}
+	w.record(now)
+	return true
+}
+
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w.mu.Lock()
+		now := w.clock.Now()
+		d := w.delay(now)
+		if d <= 0 {
+			w.record(now)
+			w.mu.Unlock()
+			return nil
+		}
+		w.mu.Unlock()
+		// 醒来后可能被别的 Go 程抢先，所以重新检查
+		if !sleep(ctx, w.clock, d) {
+			return ctx.Err()
+		}
+	}
+}
+
+var (
+	_ Limiter = (*TokenBucket)(nil)
+	_ Limiter = (*SlidingWindow)(nil)
+)
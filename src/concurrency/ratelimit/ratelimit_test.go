@@ -0,0 +1,224 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func Test_Fake(t *testing.T) {
+	f := NewFake(epoch)
+	a := f.After(2 * time.Second)
+	b := f.After(time.Second)
+	f.Advance(time.Second)
+	select {
+	case got := <-b:
+		if !got.Equal(epoch.Add(time.Second)) {
+			t.Errorf("b fired at %v", got)
+		}
+	default:
+		t.Fatal("b did not fire")
+	}
+	select {
+	case <-a:
+		t.Fatal("a fired early")
+	default:
+	}
+	if f.Waiters() != 1 {
+		t.Errorf("Waiters = %d", f.Waiters())
+	}
+	f.Advance(time.Second)
+	<-a
+}
+
+func Test_TokenBucket(t *testing.T) {
+	f := NewFake(epoch)
+	b := NewTokenBucket(2, 3, f) // 每秒 2 个，突发 3 个
+	for i := range 3 {
+		if !b.Allow() {
+			t.Fatalf("Allow #%d failed", i)
+		}
+	}
+	if b.Allow() {
+		t.Fatal("Allow succeeded with empty bucket")
+	}
+	f.Advance(500 * time.Millisecond)
+	if !b.Allow() || b.Allow() {
+		t.Fatal("expected exactly one token after 0.5s")
+	}
+	f.Advance(time.Hour)
+	if got := b.Tokens(); got != 3 {
+		t.Errorf("Tokens = %v, want burst 3", got)
+	}
+	if b.AllowN(4) {
+		t.Error("AllowN(4) > burst")
+	}
+
+	// 预订会排队：3 个令牌用完之后，第 4、5 个分别要等 0.5s 和 1s
+	b.AllowN(3)
+	if d := b.Reserve(1); d != 500*time.Millisecond {
+		t.Errorf("Reserve = %v", d)
+	}
+	if d := b.Reserve(1); d != time.Second {
+		t.Errorf("Reserve = %v", d)
+	}
+}
+
+func Test_TokenBucketWait(t *testing.T) {
+	f := NewFake(epoch)
+	b := NewTokenBucket(1, 1, f)
+	b.Allow()
+
+	done := make(chan error)
+	go func() { done <- b.Wait(context.Background()) }()
+	f.BlockUntil(1)
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("Wait returned early")
+	default:
+	}
+	f.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// 取消时归还预订的令牌
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- b.Wait(ctx) }()
+	f.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v", err)
+	}
+	if got := b.Tokens(); got != 0 {
+		t.Errorf("Tokens after cancel = %v, want 0", got)
+	}
+}
+
+func Test_SlidingWindow(t *testing.T) {
+	f := NewFake(epoch)
+	w := NewSlidingWindow(3, time.Minute, f)
+	for i := range 3 {
+		if !w.Allow() {
+			t.Fatalf("Allow #%d failed", i)
+		}
+		f.Advance(10 * time.Second)
+	}
+	// 窗口中已经有 3 个事件（0s, 10s, 20s），现在是 30s
+	if w.Allow() {
+		t.Fatal("fourth event allowed")
+	}
+	if w.Count() != 3 {
+		t.Errorf("Count = %d", w.Count())
+	}
+	f.Set(epoch.Add(time.Minute))
+	if !w.Allow() || w.Allow() {
+		t.Fatal("expected exactly one slot at 60s")
+	}
+
+	done := make(chan error)
+	go func() { done <- w.Wait(context.Background()) }()
+	f.BlockUntil(1)
+	f.Set(epoch.Add(70 * time.Second))
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ParseCron(t *testing.T) {
+	at := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	cases := []struct {
+		spec, from, want string
+	}{
+		{"*/15 * * * *", "2024-01-01 00:07", "2024-01-01 00:15"},
+		{"0 9 * * mon-fri", "2024-01-05 09:00", "2024-01-08 09:00"}, // 周五之后是下周一
+		{"30 2 1 * *", "2024-01-15 00:00", "2024-02-01 02:30"},
+		{"0 0 29 feb *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 12 13 * 5", "2024-01-01 00:00", "2024-01-05 12:00"}, // 13 号或周五
+		{"@hourly", "2024-01-01 10:59", "2024-01-01 11:00"},
+		{"5,10-12 * * * *", "2024-01-01 10:10", "2024-01-01 10:11"},
+		{"0 0 * * 7", "2024-01-01 00:00", "2024-01-07 00:00"},
+	}
+	for _, c := range cases {
+		s, err := ParseCron(c.spec)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", c.spec, err)
+			continue
+		}
+		if got := s.Next(at(c.from)); !got.Equal(at(c.want)) {
+			t.Errorf("%q.Next(%s) = %v, want %s", c.spec, c.from, got, c.want)
+		}
+	}
+	if s, _ := ParseCron("0 0 30 2 *"); !s.Next(epoch).IsZero() {
+		t.Error("Feb 30 should never match")
+	}
+	if s, err := ParseCron("@every 90s"); err != nil || s != Every(90*time.Second) {
+		t.Errorf("@every = %v, %v", s, err)
+	}
+	for _, bad := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "0 0 * foo *", "@every -1s"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", bad)
+		}
+	}
+}
+
+func Test_Scheduler(t *testing.T) {
+	f := NewFake(epoch)
+	var mu sync.Mutex
+	var runs []time.Time
+	var errs []string
+	s := &Scheduler{
+		Clock:   f,
+		Rand:    func(n int64) int64 { return n / 2 },
+		OnError: func(job string, err error) { mu.Lock(); errs = append(errs, job); mu.Unlock() },
+	}
+	s.Add(Job{Name: "tick", Schedule: Every(10 * time.Second), Jitter: 2 * time.Second, Run: func(context.Context) error {
+		mu.Lock()
+		runs = append(runs, f.Now())
+		mu.Unlock()
+		return nil
+	}})
+	s.Add(Job{Name: "fail", Schedule: Every(time.Minute), Run: func(context.Context) error {
+		return errors.New("boom")
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	// 每一步推进 1 秒，保证每次等待都已经开始
+	for range 60 {
+		f.BlockUntil(2)
+		f.Advance(time.Second)
+	}
+	f.BlockUntil(2)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// 间隔 10s，加上固定的 1s 抖动：11s, 22s, 33s, 44s, 55s
+	if len(runs) != 5 {
+		t.Fatalf("runs = %v", runs)
+	}
+	for i, r := range runs {
+		if want := epoch.Add(time.Duration(11*(i+1)) * time.Second); !r.Equal(want) {
+			t.Errorf("run %d at %v, want %v", i, r, want)
+		}
+	}
+	if len(errs) != 1 || errs[0] != "fail" {
+		t.Errorf("errors = %v", errs)
+	}
+}
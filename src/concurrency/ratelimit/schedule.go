@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Job 是调度器中的一个任务
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Jitter 是随机推迟的上限，每次运行都在计划的时间之后再推迟 [0, Jitter) 的随机时间，
+	// 避免很多任务（或很多进程中的同一个任务）在同一时刻一起运行
+	Jitter time.Duration
+	Run    func(ctx context.Context) error
+}
+
+// Scheduler 按计划周期性地运行任务。同一个任务的两次运行不会重叠，
+// 如果一次运行超过了下一次的计划时间，会从运行结束的时刻重新计算下一次。
+type Scheduler struct {
+	Clock Clock // 为 nil 时使用真实的时钟
+	// OnError 在任务返回错误时被调用，为 nil 时忽略错误
+	OnError func(job string, err error)
+	// Rand 返回 [0, n) 之间的随机数，为 nil 时使用 math/rand/v2，测试时可以固定
+	Rand func(n int64) int64
+
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// Add 添加一个任务，需要在 Run 之前调用
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	s.jobs = append(s.jobs, job)
+	s.mu.Unlock()
+}
+
+// Run 运行所有任务直到 ctx 结束，然后等待正在运行的任务返回
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	jobs := append([]Job(nil), s.jobs...)
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (s *Scheduler) jitter(limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	if s.Rand != nil {
+		return time.Duration(s.Rand(int64(limit)))
+	}
+	return time.Duration(rand.Int64N(int64(limit)))
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	clock := clockOrReal(s.Clock)
+	for {
+		now := clock.Now()
+		next := job.Schedule.Next(now)
+		if next.IsZero() {
+			return
+		}
+		if !sleep(ctx, clock, next.Sub(now)+s.jitter(job.Jitter)) {
+			return
+		}
+		if err := job.Run(ctx); err != nil && s.OnError != nil {
+			s.OnError(job.Name, err)
+		}
+	}
+}
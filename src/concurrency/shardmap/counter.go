@@ -0,0 +1,90 @@
+package shardmap
+
+import (
+	"iter"
+	"sync/atomic"
+)
+
+// Counter 是分片的并发计数器，用来代替 SafeCounter。
+// 键第一次出现时才需要写锁，之后的计数只在读锁下做原子加法。
+type Counter[K comparable] struct {
+	m *Map[K, *atomic.Int64]
+}
+
+// NewCounter 创建一个分片数为 GOMAXPROCS 的 4 倍的计数器
+func NewCounter[K comparable]() *Counter[K] {
+	return &Counter[K]{New[K, *atomic.Int64]()}
+}
+
+// NewCounterShards 创建一个至少有 n 个分片的计数器
+func NewCounterShards[K comparable](n int) *Counter[K] {
+	return &Counter[K]{NewShards[K, *atomic.Int64](n)}
+}
+
+func (c *Counter[K]) get(k K) *atomic.Int64 {
+	if n, ok := c.m.Load(k); ok {
+		return n
+	}
+	n, _ := c.m.LoadOrStore(k, new(atomic.Int64))
+	return n
+}
+
+// Inc 把 k 的计数加一
+func (c *Counter[K]) Inc(k K) {
+	c.get(k).Add(1)
+}
+
+// Add 把 k 的计数加上 delta，返回新的计数
+func (c *Counter[K]) Add(k K, delta int64) int64 {
+	return c.get(k).Add(delta)
+}
+
+// Value 返回 k 的计数，不存在的键计数为 0
+func (c *Counter[K]) Value(k K) int64 {
+	if n, ok := c.m.Load(k); ok {
+		return n.Load()
+	}
+	return 0
+}
+
+// Reset 把 k 的计数清零并返回清零前的值
+func (c *Counter[K]) Reset(k K) int64 {
+	if n, ok := c.m.Load(k); ok {
+		return n.Swap(0)
+	}
+	return 0
+}
+
+// Len 返回计数过的键的个数
+func (c *Counter[K]) Len() int {
+	return c.m.Len()
+}
+
+// Total 返回所有计数之和
+func (c *Counter[K]) Total() int64 {
+	var sum int64
+	for _, n := range c.m.All() {
+		sum += n.Load()
+	}
+	return sum
+}
+
+// Snapshot 返回所有计数的一个拷贝
+func (c *Counter[K]) Snapshot() map[K]int64 {
+	out := make(map[K]int64)
+	for k, n := range c.m.All() {
+		out[k] = n.Load()
+	}
+	return out
+}
+
+// All 遍历所有的计数，顺序不确定
+func (c *Counter[K]) All() iter.Seq2[K, int64] {
+	return func(yield func(K, int64) bool) {
+		for k, n := range c.m.All() {
+			if !yield(k, n.Load()) {
+				return
+			}
+		}
+	}
+}
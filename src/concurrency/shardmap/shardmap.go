@@ -0,0 +1,180 @@
+// Package shardmap 是分片的并发安全映射，从练习中的 SafeCounter 扩展而来。
+//
+// SafeCounter 用一把 sync.Mutex 保护整个 map，所有 Go 程都在同一把锁上排队。
+// Map 按键的哈希把数据分到多个分片，每个分片有自己的 sync.RWMutex，
+// 不同分片上的操作互不阻塞，读操作之间也不互斥。
+package shardmap
+
+import (
+	"hash/maphash"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// shard 是 Map 的一个分片。在 64 位平台上 RWMutex 和 map 指针一共 32 字节，
+// 再填充 32 字节凑满一个缓存行，避免相邻分片的锁互相干扰（伪共享）。
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+	_  [32]byte
+}
+
+// Map 是分片的并发安全映射，零值不可用，需要用 New 或 NewShards 创建
+type Map[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []shard[K, V]
+	mask   uint64
+}
+
+// New 创建一个分片数为 GOMAXPROCS 的 4 倍的 Map
+func New[K comparable, V any]() *Map[K, V] {
+	return NewShards[K, V](4 * runtime.GOMAXPROCS(0))
+}
+
+// NewShards 创建一个至少有 n 个分片的 Map，分片数会向上取整到 2 的幂
+func NewShards[K comparable, V any](n int) *Map[K, V] {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	m := &Map[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]shard[K, V], size),
+		mask:   uint64(size - 1),
+	}
+	for i := range m.shards {
+		m.shards[i].m = make(map[K]V)
+	}
+	return m
+}
+
+func (m *Map[K, V]) shard(k K) *shard[K, V] {
+	return &m.shards[maphash.Comparable(m.seed, k)&m.mask]
+}
+
+// Shards 返回分片数
+func (m *Map[K, V]) Shards() int {
+	return len(m.shards)
+}
+
+// Load 返回键 k 对应的值
+func (m *Map[K, V]) Load(k K) (V, bool) {
+	s := m.shard(k)
+	s.mu.RLock()
+	v, ok := s.m[k]
+	s.mu.RUnlock()
+	return v, ok
+}
+
+// Store 设置键 k 的值
+func (m *Map[K, V]) Store(k K, v V) {
+	s := m.shard(k)
+	s.mu.Lock()
+	s.m[k] = v
+	s.mu.Unlock()
+}
+
+// LoadOrStore 在 k 存在时返回已有的值和 true，否则存入 v 并返回 v 和 false
+func (m *Map[K, V]) LoadOrStore(k K, v V) (V, bool) {
+	s := m.shard(k)
+	s.mu.RLock()
+	old, ok := s.m[k]
+	s.mu.RUnlock()
+	if ok {
+		return old, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 两次加锁之间可能有别的 Go 程存入了 k
+	if old, ok := s.m[k]; ok {
+		return old, true
+	}
+	s.m[k] = v
+	return v, false
+}
+
+// Delete 删除键 k，返回被删除的值
+func (m *Map[K, V]) Delete(k K) (V, bool) {
+	s := m.shard(k)
+	s.mu.Lock()
+	v, ok := s.m[k]
+	delete(s.m, k)
+	s.mu.Unlock()
+	return v, ok
+}
+
+// Update 在持有分片锁的情况下用 f 计算 k 的新值，f 的参数是旧值和 k 是否存在。
+// f 不能再访问同一个 Map，否则可能死锁。
+func (m *Map[K, V]) Update(k K, f func(old V, ok bool) V) V {
+	s := m.shard(k)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.m[k]
+	v := f(old, ok)
+	s.m[k] = v
+	return v
+}
+
+// Len 返回键的个数。并发修改时结果只是一个近似值。
+func (m *Map[K, V]) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Clear 删除所有的键
+func (m *Map[K, V]) Clear() {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.Lock()
+		clear(s.m)
+		s.mu.Unlock()
+	}
+}
+
+// Snapshot 返回所有键值的一个拷贝。
+// 每个分片各自是一致的，但不同分片是在不同时刻复制的，整体不是一个原子快照。
+func (m *Map[K, V]) Snapshot() map[K]V {
+	out := make(map[K]V, m.Len())
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		for k, v := range s.m {
+			out[k] = v
+		}
+		s.mu.RUnlock()
+	}
+	return out
+}
+
+// All 遍历所有的键值，顺序不确定。
+// 每次只复制一个分片，遍历时不持有锁，所以循环体中可以修改 Map。
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		type kv struct {
+			k K
+			v V
+		}
+		var buf []kv
+		for i := range m.shards {
+			s := &m.shards[i]
+			s.mu.RLock()
+			buf = buf[:0]
+			for k, v := range s.m {
+				buf = append(buf, kv{k, v})
+			}
+			s.mu.RUnlock()
+			for _, e := range buf {
+				if !yield(e.k, e.v) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package shardmap
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func Test_ShardSize(t *testing.T) {
+	if n := NewShards[int, int](5).Shards(); n != 8 {
+		t.Errorf("Shards = %d, want 8", n)
+	}
+	// 填充是按 64 位平台上 RWMutex 和指针的大小算的
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("not a 64-bit platform")
+	}
+	if n := unsafe.Sizeof(shard[string, int]{}); n != 64 {
+		t.Errorf("shard size = %d, want 64", n)
+	}
+}
+
+func Test_Map(t *testing.T) {
+	m := NewShards[string, int](4)
+	if _, ok := m.Load("a"); ok {
+		t.Error("Load on empty map")
+	}
+	m.Store("a", 1)
+	if v, ok := m.LoadOrStore("a", 2); !ok || v != 1 {
+		t.Errorf("LoadOrStore(a) = %d, %v", v, ok)
+	}
+	if v, ok := m.LoadOrStore("b", 2); ok || v != 2 {
+		t.Errorf("LoadOrStore(b) = %d, %v", v, ok)
+	}
+	if v := m.Update("a", func(old int, ok bool) int { return old + 10 }); v != 11 {
+		t.Errorf("Update(a) = %d", v)
+	}
+	if v, ok := m.Delete("b"); !ok || v != 2 {
+		t.Errorf("Delete(b) = %d, %v", v, ok)
+	}
+	if _, ok := m.Delete("b"); ok {
+		t.Error("Delete(b) twice")
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len = %d", m.Len())
+	}
+	m.Clear()
+	if m.Len() != 0 {
+		t.Errorf("Len after Clear = %d", m.Len())
+	}
+}
+
+func Test_Concurrent(t *testing.T) {
+	m := New[int, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Update(i, func(old int, _ bool) int { return old + 1 })
+			}
+		}()
+	}
+	// 写的同时遍历，不能死锁也不能有数据竞争
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 10 {
+			for k, v := range m.All() {
+				if v > 8 {
+					t.Errorf("m[%d] = %d", k, v)
+				}
+				m.Load(k)
+			}
+		}
+	}()
+	wg.Wait()
+
+	snap := m.Snapshot()
+	if len(snap) != 1000 {
+		t.Fatalf("len(Snapshot) = %d", len(snap))
+	}
+	for k, v := range snap {
+		if v != 8 {
+			t.Errorf("m[%d] = %d, want 8", k, v)
+		}
+	}
+	n := 0
+	for range m.All() {
+		if n++; n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("break after %d", n)
+	}
+}
+
+func Test_Counter(t *testing.T) {
+	c := NewCounter[string]()
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Inc("somekey")
+			c.Add("k"+strconv.Itoa(i%10), 2)
+		}()
+	}
+	wg.Wait()
+	if v := c.Value("somekey"); v != 1000 {
+		t.Errorf("Value(somekey) = %d", v)
+	}
+	if v := c.Value("missing"); v != 0 {
+		t.Errorf("Value(missing) = %d", v)
+	}
+	if c.Len() != 11 || c.Total() != 3000 {
+		t.Errorf("Len = %d, Total = %d", c.Len(), c.Total())
+	}
+	snap := c.Snapshot()
+	if snap["k3"] != 200 {
+		t.Errorf("Snapshot[k3] = %d", snap["k3"])
+	}
+	if old := c.Reset("k3"); old != 200 || c.Value("k3") != 0 {
+		t.Errorf("Reset(k3) = %d, then %d", old, c.Value("k3"))
+	}
+}
+
+// mutexCounter 是练习中的 SafeCounter，作为基准测试的对照
+type mutexCounter struct {
+	v   map[string]int
+	mux sync.Mutex
+}
+
+func (c *mutexCounter) Inc(key string) {
+	c.mux.Lock()
+	c.v[key]++
+	c.mux.Unlock()
+}
+
+func (c *mutexCounter) Value(key string) int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.v[key]
+}
+
+var benchKeys = func() []string {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+	return keys
+}()
+
+// benchmarkCounter 用 b.RunParallel 模拟竞争：每 readEvery 次操作中有一次写，其余是读
+func benchmarkCounter(b *testing.B, readEvery int, inc func(string), value func(string)) {
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			k := benchKeys[i%len(benchKeys)]
+			if i%readEvery == 0 {
+				inc(k)
+			} else {
+				value(k)
+			}
+			i++
+		}
+	})
+}
+
+func benchmarkAll(b *testing.B, readEvery int) {
+	b.Run("Mutex", func(b *testing.B) {
+		c := &mutexCounter{v: make(map[string]int)}
+		benchmarkCounter(b, readEvery, c.Inc, func(k string) { c.Value(k) })
+	})
+	b.Run("Sharded", func(b *testing.B) {
+		c := NewCounter[string]()
+		benchmarkCounter(b, readEvery, c.Inc, func(k string) { c.Value(k) })
+	})
+	b.Run("ShardedMap", func(b *testing.B) {
+		m := New[string, int]()
+		inc := func(k string) { m.Update(k, func(old int, _ bool) int { return old + 1 }) }
+		benchmarkCounter(b, readEvery, inc, func(k string) { m.Load(k) })
+	})
+}
+
+func Benchmark_WriteHeavy(b *testing.B) { benchmarkAll(b, 1) }
+func Benchmark_ReadHeavy(b *testing.B)  { benchmarkAll(b, 10) }
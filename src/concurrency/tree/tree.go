@@ -0,0 +1,241 @@
+// Package tree 是一个泛型的二叉查找树，用来代替练习中的 golang.org/x/tour/tree。
+//
+// 树中的值不重复；树没有做平衡，插入顺序决定了树的形状。
+// 遍历既可以用 range-over-func 的迭代器（InOrder 等），也可以用信道（Walk）。
+package tree
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"math/rand"
+	"strings"
+)
+
+// Node 是树中的一个节点
+type Node[T any] struct {
+	Left  *Node[T]
+	Value T
+	Right *Node[T]
+}
+
+// Tree 是二叉查找树，零值不可用，需要用 NewOrdered 或 NewFunc 创建
+type Tree[T any] struct {
+	root *Node[T]
+	cmp  func(a, b T) int
+	size int
+}
+
+// NewOrdered 创建一个按 < 排序的空树
+func NewOrdered[T cmp.Ordered]() *Tree[T] {
+	return NewFunc(cmp.Compare[T])
+}
+
+// NewFunc 创建一个按 compare 排序的空树，compare 的约定和 cmp.Compare 相同
+func NewFunc[T any](compare func(a, b T) int) *Tree[T] {
+	return &Tree[T]{cmp: compare}
+}
+
+// New 和 tour 的 tree.New 一样：返回一个随机形状的树，保存 k, 2k, ..., 10k
+func New(k int) *Tree[int] {
+	t := NewOrdered[int]()
+	for _, v := range rand.Perm(10) {
+		t.Insert((1 + v) * k)
+	}
+	return t
+}
+
+// Root 返回根节点，空树返回 nil
+func (t *Tree[T]) Root() *Node[T] {
+	return t.root
+}
+
+// Len 返回树中值的个数
+func (t *Tree[T]) Len() int {
+	return t.size
+}
+
+// Insert 插入 v，v 已经存在时返回 false
+func (t *Tree[T]) Insert(v T) bool {
+	p := &t.root
+	for *p != nil {
+		switch c := t.cmp(v, (*p).Value); {
+		case c < 0:
+			p = &(*p).Left
+		case c > 0:
+			p = &(*p).Right
+		default:
+			return false
+		}
+	}
+	*p = &Node[T]{Value: v}
+	t.size++
+	return true
+}
+
+// Search 返回和 v 相等的值
+func (t *Tree[T]) Search(v T) (T, bool) {
+	n := t.root
+	for n != nil {
+		switch c := t.cmp(v, n.Value); {
+		case c < 0:
+			n = n.Left
+		case c > 0:
+			n = n.Right
+		default:
+			return n.Value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Contains 判断 v 是否在树中
+func (t *Tree[T]) Contains(v T) bool {
+	_, ok := t.Search(v)
+	return ok
+}
+
+// Delete 删除 v，v 不存在时返回 false
+func (t *Tree[T]) Delete(v T) bool {
+	p := &t.root
+	for *p != nil {
+		c := t.cmp(v, (*p).Value)
+		if c == 0 {
+			break
+		}
+		if c < 0 {
+			p = &(*p).Left
+		} else {
+			p = &(*p).Right
+		}
+	}
+	n := *p
+	if n == nil {
+		return false
+	}
+	switch {
+	case n.Left == nil:
+		*p = n.Right
+	case n.Right == nil:
+		*p = n.Left
+	default:
+		// 两个子节点：用右子树中最小的节点（中序后继）替换
+		s := &n.Right
+		for (*s).Left != nil {
+			s = &(*s).Left
+		}
+		succ := *s
+		*s = succ.Right
+		succ.Left, succ.Right = n.Left, n.Right
+		*p = succ
+	}
+	t.size--
+	return true
+}
+
+// Min 返回最小的值
+func (t *Tree[T]) Min() (T, bool) {
+	var zero T
+	n := t.root
+	if n == nil {
+		return zero, false
+	}
+	for n.Left != nil {
+		n = n.Left
+	}
+	return n.Value, true
+}
+
+// Max 返回最大的值
+func (t *Tree[T]) Max() (T, bool) {
+	var zero T
+	n := t.root
+	if n == nil {
+		return zero, false
+	}
+	for n.Right != nil {
+		n = n.Right
+	}
+	return n.Value, true
+}
+
+// Height 返回树的高度，空树为 0
+func (t *Tree[T]) Height() int {
+	var h func(n *Node[T]) int
+	h = func(n *Node[T]) int {
+		if n == nil {
+			return 0
+		}
+		return 1 + max(h(n.Left), h(n.Right))
+	}
+	return h(t.root)
+}
+
+// String 和 tour 的 Tree 一样，按 ((左) 值 (右)) 的形式输出树的结构
+func (t *Tree[T]) String() string {
+	var b strings.Builder
+	var walk func(n *Node[T])
+	walk = func(n *Node[T]) {
+		if n == nil {
+			b.WriteString("()")
+			return
+		}
+		b.WriteString("(")
+		if n.Left != nil {
+			walk(n.Left)
+			b.WriteString(" ")
+		}
+		fmt.Fprint(&b, n.Value)
+		if n.Right != nil {
+			b.WriteString(" ")
+			walk(n.Right)
+		}
+		b.WriteString(")")
+	}
+	walk(t.root)
+	return b.String()
+}
+
+// Values 按从小到大的顺序返回所有的值
+func (t *Tree[T]) Values() []T {
+	out := make([]T, 0, t.size)
+	for v := range t.InOrder() {
+		out = append(out, v)
+	}
+	return out
+}
+
+// InOrder 按中序（从小到大）遍历
+func (t *Tree[T]) InOrder() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		inOrder(t.root, yield)
+	}
+}
+
+// PreOrder 按先序（根、左、右）遍历
+func (t *Tree[T]) PreOrder() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		preOrder(t.root, yield)
+	}
+}
+
+// PostOrder 按后序（左、右、根）遍历
+func (t *Tree[T]) PostOrder() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		postOrder(t.root, yield)
+	}
+}
+
+// 三个遍历函数在 yield 返回 false 后立即停止，返回值表示是否继续
+func inOrder[T any](n *Node[T], yield func(T) bool) bool {
+	return n == nil || inOrder(n.Left, yield) && yield(n.Value) && inOrder(n.Right, yield)
+}
+
+func preOrder[T any](n *Node[T], yield func(T) bool) bool {
+	return n == nil || yield(n.Value) && preOrder(n.Left, yield) && preOrder(n.Right, yield)
+}
+
+func postOrder[T any](n *Node[T], yield func(T) bool) bool {
+	return n == nil || postOrder(n.Left, yield) && postOrder(n.Right, yield) && yield(n.Value)
+}
@@ -0,0 +1,131 @@
+package tree
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func collect[T any](seq func(func(T) bool)) []T {
+	var out []T
+	for v := range seq {
+		out = append(out, v)
+	}
+	return out
+}
+
+func Test_New(t *testing.T) {
+	tr := New(3)
+	want := []int{3, 6, 9, 12, 15, 18, 21, 24, 27, 30}
+	if got := tr.Values(); !slices.Equal(got, want) {
+		t.Errorf("New(3) = %v", got)
+	}
+	var got []int
+	for v := range tr.Walk(context.Background()) {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk = %v", got)
+	}
+}
+
+func Test_Orders(t *testing.T) {
+	tr := NewOrdered[string]()
+	for _, s := range []string{"m", "f", "t", "a", "h", "z"} {
+		tr.Insert(s)
+	}
+	if tr.Insert("h") {
+		t.Error("duplicate inserted")
+	}
+	if got := strings.Join(collect(tr.PreOrder()), ""); got != "mfahtz" {
+		t.Errorf("PreOrder = %s", got)
+	}
+	if got := strings.Join(collect(tr.PostOrder()), ""); got != "ahfztm" {
+		t.Errorf("PostOrder = %s", got)
+	}
+	if got := tr.String(); got != "(((a) f (h)) m (t (z)))" {
+		t.Errorf("String = %s", got)
+	}
+	// 提前结束的遍历
+	n := 0
+	for range tr.InOrder() {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("break after %d values", n)
+	}
+}
+
+func Test_InsertDelete(t *testing.T) {
+	tr := NewOrdered[int]()
+	ref := map[int]bool{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		v := r.Intn(200)
+		if r.Intn(3) == 0 {
+			if tr.Delete(v) != ref[v] {
+				t.Fatalf("Delete(%d) disagrees with reference", v)
+			}
+			delete(ref, v)
+		} else {
+			if tr.Insert(v) == ref[v] {
+				t.Fatalf("Insert(%d) disagrees with reference", v)
+			}
+			ref[v] = true
+		}
+	}
+	var want []int
+	for v := range ref {
+		want = append(want, v)
+	}
+	sort.Ints(want)
+	if got := tr.Values(); !slices.Equal(got, want) || tr.Len() != len(want) {
+		t.Fatalf("values = %v, want %v", got, want)
+	}
+	for v := range 200 {
+		if tr.Contains(v) != ref[v] {
+			t.Errorf("Contains(%d) = %v", v, !ref[v])
+		}
+	}
+	if lo, _ := tr.Min(); lo != want[0] {
+		t.Errorf("Min = %d", lo)
+	}
+	if hi, _ := tr.Max(); hi != want[len(want)-1] {
+		t.Errorf("Max = %d", hi)
+	}
+}
+
+func Test_Same(t *testing.T) {
+	if !Same(New(1), New(1)) || !SameChan(New(1), New(1)) {
+		t.Error("Same(New(1), New(1)) should be true")
+	}
+	if Same(New(1), New(2)) || SameChan(New(1), New(2)) {
+		t.Error("Same(New(1), New(2)) should be false")
+	}
+
+	short := NewOrdered[int]()
+	for _, v := range []int{1, 2, 3} {
+		short.Insert(v)
+	}
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		if SameChan(New(1), short) || SameChan(short, New(1)) || Same(short, New(1)) {
+			t.Fatal("trees of different length reported as same")
+		}
+	}
+	// 给被取消的 Go 程一点时间退出
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("goroutines leaked: %d before, %d after", before, n)
+	}
+}
@@ -0,0 +1,66 @@
+package tree
+
+import (
+	"context"
+	"iter"
+)
+
+// Walk 在一个新的 Go 程中按中序遍历 t，把值依次发送到返回的信道，遍历结束后关闭信道。
+// 不再需要后面的值时取消 ctx，Go 程会退出而不会阻塞在发送上。
+func (t *Tree[T]) Walk(ctx context.Context) <-chan T {
+	return walkChan(ctx, t.InOrder())
+}
+
+func walkChan[T any](ctx context.Context, seq iter.Seq[T]) <-chan T {
+	ch := make(chan T)
+	go func() {
+		defer close(ch)
+		for v := range seq {
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Same 判断 t1 和 t2 是否按相同的顺序保存了相同的值，和树的形状无关。
+// 发现第一个不同的值或者一棵树先结束时立即返回。
+func Same[T comparable](t1, t2 *Tree[T]) bool {
+	if t1.Len() != t2.Len() {
+		return false
+	}
+	next1, stop1 := iter.Pull(t1.InOrder())
+	defer stop1()
+	next2, stop2 := iter.Pull(t2.InOrder())
+	defer stop2()
+	for {
+		v1, ok1 := next1()
+		v2, ok2 := next2()
+		if ok1 != ok2 || v1 != v2 {
+			return false
+		}
+		if !ok1 {
+			return true
+		}
+	}
+}
+
+// SameChan 是用 Walk 实现的 Same，返回前会取消两个遍历的 Go 程，所以不会泄漏。
+func SameChan[T comparable](t1, t2 *Tree[T]) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch1, ch2 := t1.Walk(ctx), t2.Walk(ctx)
+	for {
+		v1, ok1 := <-ch1
+		v2, ok2 := <-ch2
+		if ok1 != ok2 || v1 != v2 {
+			return false
+		}
+		if !ok1 {
+			return true
+		}
+	}
+}
@@ -1,29 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os/exec"
+
+	"exec/runner"
 )
 
+// 先用 ReadAll 读完 stdout 再读 stderr，如果命令向 stderr 写满了管道缓冲区，
+// 它会阻塞在写 stderr 上而不再关闭 stdout，两边互相等待。
+// runner 同时读取两个流，并在每一行到达时调用回调。
 func main() {
-	c := exec.Command("/bin/bash", "-c", "vmstat 1 5")
-	fmt.Printf("out==> %s", "000\n")
-
-	stdout, _ := c.StdoutPipe()
-	stderr, _ := c.StderrPipe()
-
-	c.Start()
-	b1, _ := ioutil.ReadAll(stdout) // 过时？
-	b2, _ := ioutil.ReadAll(stderr)
-
-	fmt.Printf("1out==> %s", b1)
-	fmt.Printf("2err==> %s", b2)
-
-	c.Wait()
-	b1, _ = ioutil.ReadAll(stdout)
-	b2, _ = ioutil.ReadAll(stderr)
-
-	fmt.Printf("3out==> %s", b1)
-	fmt.Printf("4err==> %s", b2)
+	r, err := runner.Run(context.Background(), runner.Command{
+		Name:     "vmstat",
+		Args:     []string{"1", "5"},
+		OnStdout: func(line string) { fmt.Println("out==>", line) },
+		OnStderr: func(line string) { fmt.Println("err==>", line) },
+	})
+	if r == nil {
+		fmt.Println("Run:", err)
+		return
+	}
+	fmt.Printf("退出码 %d，运行了 %v\n", r.ExitCode, r.Duration)
 }
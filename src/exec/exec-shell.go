@@ -3,76 +3,56 @@ package main
 /* Golang语言执行linux命令行 */
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os/exec"
 	"time"
+
+	"exec/runner"
 )
 
-func run() {
-	cmd := exec.Command("/bin/bash", "-c", "ping 127.0.0.1")
-	// 命令的输出直接扔掉
-	_, err := cmd.Output()
-	// 命令出错
-	if err != nil {
-		panic(err.Error())
-	}
-	// 命令启动和启动时出错
-	if err := cmd.Start(); err != nil {
-		panic(err.Error())
-	}
-	// 等待结束
-	if err := cmd.Wait(); err != nil {
-		panic(err.Error())
+// 一个 exec.Cmd 只能启动一次：Output 内部已经调用了 Start 和 Wait，之后再 Start 会出错。
+// runner.Run 负责启动、同时读取两个输出流和等待，超时后杀死整个进程组。
+func run(done chan<- struct{}) {
+	defer close(done)
+	// ping 不会自己结束，最多运行 5 秒
+	r, err := runner.Run(context.Background(), runner.Command{
+		Name:    "ping",
+		Args:    []string{"127.0.0.1"},
+		Timeout: 5 * time.Second,
+	})
+	if r == nil {
+		fmt.Println("Run:", err)
+		return
 	}
+	fmt.Printf("ping: 退出码 %d，信号 %v，超时 %v，运行了 %v\n", r.ExitCode, r.Signal, r.TimedOut, r.Duration.Round(time.Millisecond))
 }
+
 func main() {
 	// 异步线程
-	go run()
+	done := make(chan struct{})
+	go run(done)
 	fmt.Println(time.Now().Format("2006.01.02 15:04:05"))
 	// 等待1秒
-	time.Sleep(1e9)
-	cmd := exec.Command("/bin/bash", "-c", `ps -ef | grep -v "grep" | grep "ping"`)
-	// 接收命令的标准输出
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		fmt.Println("StdoutPipe: " + err.Error())
-		return
-	}
-	// 接受命令的标准错误
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		fmt.Println("StderrPipe: ", err.Error())
-		return
-	}
-	// 启动
-	if err := cmd.Start(); err != nil {
-		fmt.Println("Start: ", err.Error())
-		return
-	}
-	// 读取错误信息
-	bytesErr, err := ioutil.ReadAll(stderr)
-	if err != nil {
-		fmt.Println("ReadAll stderr: ", err.Error())
-		return
-	}
-	if len(bytesErr) != 0 {
-		fmt.Printf("stderr is not nil: %s", bytesErr)
-		return
-	}
-	// 读取输出
-	bytes, err := ioutil.ReadAll(stdout)
-	if err != nil {
-		fmt.Println("ReadAll stdout: ", err.Error())
+	time.Sleep(time.Second)
+	// 相当于 /bin/bash -c 'ps -ef | grep -v "grep" | grep "ping"'，但不经过 shell，
+	// 要查找的字符串即使来自用户输入也不会被当作命令执行
+	r, err := runner.Pipe(
+		runner.Cmd("ps", "-ef"),
+		runner.Cmd("grep", "-v", "grep"),
+		runner.Cmd("grep", "ping"),
+	).Run(context.Background())
+	if r == nil {
+		fmt.Println("Run:", err)
 		return
 	}
-	// 等等命令执行完成
-	if err := cmd.Wait(); err != nil {
-		fmt.Println("Wait: ", err.Error())
-		return
+	for _, s := range r.Stages {
+		if len(s.Stderr) != 0 {
+			fmt.Printf("%s: stderr is not nil: %s", s.Stage, s.Stderr)
+			return
+		}
 	}
 	// 打印输出
-	fmt.Printf("stdout: %s", bytes)
-	// 等待主程序退出,携程退出.
-	time.Sleep(1e9 * 10)
+	fmt.Printf("stdout: %s", r.Stdout)
+	// 等待 ping 被杀死
+	<-done
 }
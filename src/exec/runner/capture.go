@@ -0,0 +1,64 @@
+package runner
+
+import "bytes"
+
+// capture 是进程的一个输出流，保存最多 limit 字节，并把完整的行交给 onLine。
+// 超过 limit（不保存输出时是 DefaultMaxOutput）的行被分成几段交给 onLine，以免一直没有换行符的输出耗尽内存。
+// os/exec 会在单独的 Go 程中调用 Write，所以不需要加锁。
+type capture struct {
+	limit     int
+	buf       []byte
+	truncated bool
+
+	onLine  func(string)
+	partial []byte // 还没有遇到换行符的行
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	if c.limit >= 0 {
+		if room := c.limit - len(c.buf); len(p) <= room {
+			c.buf = append(c.buf, p...)
+		} else {
+			c.buf = append(c.buf, p[:room]...)
+			c.truncated = true
+		}
+	}
+	if c.onLine != nil {
+		data := p
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				c.partial = append(c.partial, data...)
+				for n := c.maxLine(); len(c.partial) >= n; {
+					c.onLine(string(c.partial[:n]))
+					c.partial = append(c.partial[:0], c.partial[n:]...)
+				}
+				break
+			}
+			line := data[:i]
+			if len(c.partial) > 0 {
+				line = append(c.partial, line...)
+				c.partial = c.partial[:0]
+			}
+			c.onLine(string(bytes.TrimSuffix(line, []byte{'\r'})))
+			data = data[i+1:]
+		}
+	}
+	return len(p), nil
+}
+
+// maxLine 是交给 onLine 的一行的最大长度
+func (c *capture) maxLine() int {
+	if c.limit > 0 {
+		return c.limit
+	}
+	return DefaultMaxOutput
+}
+
+// flush 把最后一个没有换行符的行交给 onLine
+func (c *capture) flush() {
+	if c.onLine != nil && len(c.partial) > 0 {
+		c.onLine(string(c.partial))
+		c.partial = nil
+	}
+}
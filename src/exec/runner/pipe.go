@@ -0,0 +1,280 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Stage 是管道中的一个命令。参数原样传给命令，不经过 shell，
+// 所以参数中的 |、;、$ 等字符不会被解释，来自用户的参数不会造成命令注入。
+type Stage struct {
+	Name string
+	Args []string
+	Dir  string
+	Env  []string // 为 nil 时继承当前进程的环境变量
+}
+
+// Cmd 创建一个管道阶段
+func Cmd(name string, args ...string) Stage {
+	return Stage{Name: name, Args: args}
+}
+
+func (s Stage) String() string {
+	return strings.Join(append([]string{s.Name}, s.Args...), " ")
+}
+
+// Pipeline 把多个命令的标准输出和下一个命令的标准输入连接起来，相当于 shell 中的 a | b | c。
+// 所有命令在同一个进程组中运行，取消时一起被杀死。
+type Pipeline struct {
+	Stages []Stage
+
+	// Stdin 是第一个命令的标准输入，StdinFile 不为空时从这个文件读取
+	Stdin     io.Reader
+	StdinFile string
+	// StdoutFile 不为空时最后一个命令的标准输出写入这个文件（相当于 >），
+	// AppendStdout 为 true 时追加到文件末尾（相当于 >>），否则保存在结果中
+	StdoutFile   string
+	AppendStdout bool
+	// StderrFile 不为空时所有命令的标准错误写入这个文件（相当于 2>），否则分别保存在每个阶段的结果中
+	StderrFile string
+
+	// Pipefail 为 true 时任何一个命令失败整个管道就失败，退出码是最右边失败的命令的退出码；
+	// 为 false 时只看最后一个命令（和 shell 的默认行为相同）
+	Pipefail bool
+
+	Timeout   time.Duration
+	KillGrace time.Duration
+	MaxOutput int
+	OnStdout  func(line string)
+}
+
+// Pipe 创建一个由 stages 组成的管道
+func Pipe(stages ...Stage) *Pipeline {
+	return &Pipeline{Stages: stages}
+}
+
+// StageResult 是管道中一个命令的结果
+type StageResult struct {
+	Stage           Stage
+	ExitCode        int
+	Signal          os.Signal
+	Stderr          []byte
+	StderrTruncated bool
+}
+
+// Success 表示命令正常退出且退出码为 0
+func (r *StageResult) Success() bool {
+	return r.ExitCode == 0 && r.Signal == nil
+}
+
+// PipeResult 是管道的结果
+type PipeResult struct {
+	Stages []StageResult
+	// ExitCode 是按 Pipefail 规则得出的整个管道的退出码
+	ExitCode int
+	// Stdout 是最后一个命令的输出，重定向到文件时为空
+	Stdout          []byte
+	StdoutTruncated bool
+	Duration        time.Duration
+	TimedOut        bool
+}
+
+// Success 表示按 Pipefail 规则整个管道成功
+func (r *PipeResult) Success() bool {
+	return r.ExitCode == 0
+}
+
+// PipeError 是管道没有成功时 Run 返回的错误
+type PipeError struct {
+	*PipeResult
+	Failed int // 决定了退出码的那个阶段的下标
+}
+
+func (e *PipeError) Error() string {
+	s := &e.Stages[e.Failed]
+	if s.Signal != nil {
+		return fmt.Sprintf("runner: pipeline stage %d (%s): killed by signal: %v", e.Failed, s.Stage.Name, s.Signal)
+	}
+	return fmt.Sprintf("runner: pipeline stage %d (%s): exit status %d", e.Failed, s.Stage.Name, s.ExitCode)
+}
+
+// exitStatus 把信号转换成 shell 的退出码 128+n
+func exitStatus(r *StageResult) int {
+	if r.Signal != nil {
+		if n, ok := signalNumber(r.Signal); ok {
+			return 128 + n
+		}
+	}
+	return r.ExitCode
+}
+
+// Run 启动管道中所有的命令并等待它们结束。
+// 有命令无法启动时杀死已经启动的命令，返回 nil 和错误；
+// 管道没有成功时同时返回 PipeResult 和 *PipeError；ctx 结束时错误还包装了 ctx 的错误。
+func (p *Pipeline) Run(ctx context.Context) (*PipeResult, error) {
+	if len(p.Stages) == 0 {
+		return nil, errors.New("runner: empty pipeline")
+	}
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	// closers 是父进程在命令启动后要关闭的文件：重定向的文件和管道的两端
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}()
+
+	limit := p.MaxOutput
+	if limit == 0 {
+		limit = DefaultMaxOutput
+	}
+	n := len(p.Stages)
+	cmds := make([]*exec.Cmd, n)
+	stderrs := make([]*capture, n)
+	for i, s := range p.Stages {
+		cmd := exec.Command(s.Name, s.Args...)
+		cmd.Dir, cmd.Env = s.Dir, s.Env
+		cmds[i] = cmd
+	}
+
+	if p.StdinFile != "" {
+		f, err := os.Open(p.StdinFile)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, f)
+		cmds[0].Stdin = f
+	} else {
+		cmds[0].Stdin = p.Stdin
+	}
+
+	stdout := &capture{limit: limit, onLine: p.OnStdout}
+	if p.StdoutFile != "" {
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if p.AppendStdout {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		f, err := os.OpenFile(p.StdoutFile, flag, 0o666)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, f)
+		cmds[n-1].Stdout = f
+	} else {
+		cmds[n-1].Stdout = stdout
+	}
+
+	var stderrFile *os.File
+	if p.StderrFile != "" {
+		f, err := os.OpenFile(p.StderrFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, f)
+		stderrFile = f
+	}
+	for i, cmd := range cmds {
+		if stderrFile != nil {
+			cmd.Stderr = stderrFile
+		} else {
+			stderrs[i] = &capture{limit: limit}
+			cmd.Stderr = stderrs[i]
+		}
+	}
+
+	// 用 os.Pipe 直接连接相邻的命令，数据不经过当前进程
+	for i := 0; i < n-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, r, w)
+		cmds[i].Stdout, cmds[i+1].Stdin = w, r
+	}
+
+	start := time.Now()
+	pgid := 0
+	for i, cmd := range cmds {
+		setProcessGroup(cmd, pgid)
+		cmd.WaitDelay = p.KillGrace + time.Second
+		if err := cmd.Start(); err != nil {
+			if pgid != 0 {
+				terminate(cmds[0].Process, pgid, 0)
+			}
+			for _, c := range cmds[:i] {
+				c.Wait()
+			}
+			return nil, fmt.Errorf("runner: pipeline stage %d: %w", i, err)
+		}
+		if i == 0 {
+			pgid = cmd.Process.Pid
+		}
+	}
+	// 父进程必须关闭自己持有的管道写端，否则下游的命令永远读不到 EOF
+	for _, c := range closers {
+		c.Close()
+	}
+	closers = nil
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			terminate(cmds[0].Process, pgid, p.KillGrace)
+		case <-done:
+		}
+	}()
+	var ioErr error
+	for _, cmd := range cmds {
+		err := cmd.Wait()
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) && !errors.Is(err, exec.ErrWaitDelay) && ioErr == nil {
+			ioErr = err
+		}
+	}
+	close(done)
+	stdout.flush()
+
+	r := &PipeResult{
+		Stages:          make([]StageResult, n),
+		Stdout:          stdout.buf,
+		StdoutTruncated: stdout.truncated,
+		Duration:        time.Since(start),
+		TimedOut:        errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	failed := n - 1
+	for i, cmd := range cmds {
+		s := &r.Stages[i]
+		s.Stage = p.Stages[i]
+		s.ExitCode = cmd.ProcessState.ExitCode()
+		s.Signal = exitSignal(cmd.ProcessState)
+		if c := stderrs[i]; c != nil {
+			s.Stderr, s.StderrTruncated = c.buf, c.truncated
+		}
+		if p.Pipefail && !s.Success() {
+			failed = i
+		}
+	}
+	r.ExitCode = exitStatus(&r.Stages[failed])
+
+	switch {
+	case ioErr != nil:
+		return r, ioErr
+	case r.ExitCode == 0:
+		return r, nil
+	case ctx.Err() != nil:
+		return r, &canceledError{&PipeError{r, failed}, ctx.Err()}
+	}
+	return r, &PipeError{r, failed}
+}
@@ -0,0 +1,128 @@
+//go:build linux
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_Pipe(t *testing.T) {
+	p := Pipe(Cmd("printf", `b\na\nb\nc\n`), Cmd("sort"), Cmd("uniq", "-c"), Cmd("sort", "-rn"), Cmd("head", "-n", "1"))
+	r, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(r.Stdout)); got != "2 b" {
+		t.Errorf("stdout = %q", got)
+	}
+	if len(r.Stages) != 5 || !r.Success() {
+		t.Errorf("result = %+v", r)
+	}
+}
+
+func Test_PipeNoShell(t *testing.T) {
+	// 参数中的 shell 元字符原样传给命令
+	arg := "x; rm -rf / | $(id)"
+	r, err := Pipe(Cmd("echo", arg), Cmd("cat")).Run(context.Background())
+	if err != nil || string(r.Stdout) != arg+"\n" {
+		t.Errorf("stdout = %q, %v", r.Stdout, err)
+	}
+}
+
+func Test_Pipefail(t *testing.T) {
+	p := Pipe(Cmd("sh", "-c", "echo oops >&2; exit 3"), Cmd("cat"))
+	r, err := p.Run(context.Background())
+	if err != nil || r.ExitCode != 0 {
+		t.Fatalf("without pipefail: %v, exit %d", err, r.ExitCode)
+	}
+	if r.Stages[0].ExitCode != 3 || string(r.Stages[0].Stderr) != "oops\n" {
+		t.Errorf("stage 0 = %+v", r.Stages[0])
+	}
+
+	p.Pipefail = true
+	r, err = p.Run(context.Background())
+	var pe *PipeError
+	if !errors.As(err, &pe) || pe.Failed != 0 || r.ExitCode != 3 {
+		t.Fatalf("with pipefail: %v, exit %d", err, r.ExitCode)
+	}
+
+	// yes 在 head 退出后收到 SIGPIPE
+	p = Pipe(Cmd("yes"), Cmd("head", "-n", "3"))
+	r, err = p.Run(context.Background())
+	if err != nil || string(r.Stdout) != "y\ny\ny\n" {
+		t.Fatalf("yes | head = %q, %v", r.Stdout, err)
+	}
+	if r.Stages[0].Signal != syscall.SIGPIPE {
+		t.Errorf("yes signal = %v", r.Stages[0].Signal)
+	}
+	p.Pipefail = true
+	if r, _ := p.Run(context.Background()); r.ExitCode != 128+int(syscall.SIGPIPE) {
+		t.Errorf("pipefail exit = %d", r.ExitCode)
+	}
+}
+
+func Test_PipeRedirect(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out")
+	errFile := filepath.Join(dir, "err")
+	os.WriteFile(in, []byte("hello\nworld\n"), 0o666)
+
+	p := &Pipeline{
+		Stages:     []Stage{Cmd("tr", "a-z", "A-Z"), Cmd("sh", "-c", "cat; echo done >&2")},
+		StdinFile:  in,
+		StdoutFile: out,
+		StderrFile: errFile,
+	}
+	for range 2 {
+		if _, err := p.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		p.AppendStdout = true
+	}
+	if b, _ := os.ReadFile(out); string(b) != "HELLO\nWORLD\nHELLO\nWORLD\n" {
+		t.Errorf("out = %q", b)
+	}
+	if b, _ := os.ReadFile(errFile); string(b) != "done\n" {
+		t.Errorf("err = %q", b)
+	}
+
+	p.StdinFile = filepath.Join(dir, "missing")
+	if _, err := p.Run(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing stdin file: %v", err)
+	}
+}
+
+func Test_PipeStartFailure(t *testing.T) {
+	start := time.Now()
+	_, err := Pipe(Cmd("sleep", "30"), Cmd("/nonexistent")).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "stage 1") {
+		t.Fatalf("err = %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("started stage was not killed")
+	}
+}
+
+func Test_PipeTimeout(t *testing.T) {
+	p := Pipe(Cmd("sleep", "30"), Cmd("cat"))
+	p.Timeout = 200 * time.Millisecond
+	r, err := p.Run(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) || !r.TimedOut {
+		t.Fatalf("err = %v", err)
+	}
+	var pe *PipeError
+	if !errors.As(err, &pe) {
+		t.Errorf("err %T is not a *PipeError", err)
+	}
+	if r.Duration > 5*time.Second {
+		t.Errorf("took %v", r.Duration)
+	}
+}
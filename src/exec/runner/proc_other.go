@@ -0,0 +1,25 @@
+//go:build !unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup 在没有进程组的系统上什么也不做
+func setProcessGroup(cmd *exec.Cmd, pgid int) {}
+
+// terminate 在没有进程组的系统上只能杀死进程本身，不支持宽限时间
+func terminate(p *os.Process, pgid int, grace time.Duration) {
+	p.Kill()
+}
+
+func exitSignal(ps *os.ProcessState) os.Signal {
+	return nil
+}
+
+func signalNumber(sig os.Signal) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup 让命令加入进程组 pgid，pgid 为 0 时创建以它自己为首的新进程组。
+// 取消时向整个进程组发送信号，这样 bash -c 之类启动的子进程也会一起被杀死。
+func setProcessGroup(cmd *exec.Cmd, pgid int) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pgid: pgid}
+}
+
+// terminate 杀死进程组 pgid：grace 为 0 时直接发送 SIGKILL，
+// 否则先发送 SIGTERM，grace 之后再发送 SIGKILL
+func terminate(p *os.Process, pgid int, grace time.Duration) {
+	if grace <= 0 {
+		syscall.Kill(-pgid, syscall.SIGKILL)
+		return
+	}
+	syscall.Kill(-pgid, syscall.SIGTERM)
+	time.AfterFunc(grace, func() { syscall.Kill(-pgid, syscall.SIGKILL) })
+}
+
+func exitSignal(ps *os.ProcessState) os.Signal {
+	if ws, ok := ps.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ws.Signal()
+	}
+	return nil
+}
+
+// signalNumber 返回信号的编号
+func signalNumber(sig os.Signal) (int, bool) {
+	s, ok := sig.(syscall.Signal)
+	return int(s), ok
+}
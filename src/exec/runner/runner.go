@@ -0,0 +1,171 @@
+// Package runner 执行外部命令并收集结果。
+//
+// 和直接使用 os/exec 相比：标准输出和标准错误同时读取（先读完一个再读另一个可能死锁），
+// 保存的输出有大小上限，每一行可以交给回调函数处理，超时或取消时杀死整个进程组，
+// 结果中包含退出码、运行时间和终止进程的信号。
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// DefaultMaxOutput 是 Command.MaxOutput 为 0 时每个输出流保存的最大字节数
+const DefaultMaxOutput = 1 << 20
+
+// Command 描述要执行的命令
+type Command struct {
+	Name  string
+	Args  []string
+	Dir   string
+	Env   []string // 为 nil 时继承当前进程的环境变量
+	Stdin io.Reader
+
+	// Timeout 是最长的运行时间，0 表示只受 ctx 限制
+	Timeout time.Duration
+	// KillGrace 是取消时先发送 SIGTERM、等待多久再发送 SIGKILL，0 表示直接 SIGKILL
+	KillGrace time.Duration
+
+	// MaxOutput 是 Result 中每个输出流最多保存的字节数，0 表示 DefaultMaxOutput，负数表示不保存
+	MaxOutput int
+	// OnStdout 和 OnStderr 对每一行输出调用（不含换行符），回调在读取输出的 Go 程中执行，
+	// 同一个流的各行按顺序调用，两个流的回调可能并发执行。超过 MaxOutput 的行分成几段调用
+	OnStdout func(line string)
+	OnStderr func(line string)
+	// OnStart 在进程启动后调用，可以用来记录进程号或向进程发送信号
+	OnStart func(p *os.Process)
+}
+
+// Result 是命令执行的结果
+type Result struct {
+	// ExitCode 是退出码，进程被信号终止或没有运行时为 -1
+	ExitCode int
+	// Signal 是终止进程的信号，正常退出时为 nil
+	Signal os.Signal
+	// Stdout 和 Stderr 是保存的输出，超过上限的部分被丢弃
+	Stdout, Stderr []byte
+	// StdoutTruncated 和 StderrTruncated 表示输出是否超过了上限
+	StdoutTruncated, StderrTruncated bool
+	// Duration 是从启动到结束的时间
+	Duration time.Duration
+	// TimedOut 表示进程是因为 Timeout 或 ctx 的截止时间被杀死的
+	TimedOut bool
+}
+
+// Success 表示进程正常退出且退出码为 0
+func (r *Result) Success() bool {
+	return r.ExitCode == 0 && r.Signal == nil
+}
+
+// ExitError 是命令没有成功退出时 Run 返回的错误
+type ExitError struct {
+	*Result
+}
+
+func (e *ExitError) Error() string {
+	switch {
+	case e.TimedOut:
+		return fmt.Sprintf("runner: timed out after %v", e.Duration.Round(time.Millisecond))
+	case e.Signal != nil:
+		return "runner: killed by signal: " + e.Signal.String()
+	}
+	return fmt.Sprintf("runner: exit status %d", e.ExitCode)
+}
+
+// Run 执行命令并等待它结束。
+// 命令无法启动时返回 nil 和错误；命令没有成功退出时同时返回 Result 和 *ExitError；
+// ctx 被取消时 ExitError 包装 ctx 的错误，可以用 errors.Is 判断。
+func Run(ctx context.Context, c Command) (*Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Env = c.Env
+	cmd.Stdin = c.Stdin
+	limit := c.MaxOutput
+	if limit == 0 {
+		limit = DefaultMaxOutput
+	}
+	stdout := &capture{limit: limit, onLine: c.OnStdout}
+	stderr := &capture{limit: limit, onLine: c.OnStderr}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	setProcessGroup(cmd, 0)
+	cmd.Cancel = func() error {
+		terminate(cmd.Process, cmd.Process.Pid, c.KillGrace)
+		return nil
+	}
+	// 进程退出后，如果还有孙进程占用着输出管道，最多再等这么久
+	cmd.WaitDelay = c.KillGrace + time.Second
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if c.OnStart != nil {
+		c.OnStart(cmd.Process)
+	}
+	err := cmd.Wait()
+	stdout.flush()
+	stderr.flush()
+
+	r := &Result{
+		ExitCode:        -1,
+		Stdout:          stdout.buf,
+		Stderr:          stderr.buf,
+		StdoutTruncated: stdout.truncated,
+		StderrTruncated: stderr.truncated,
+		Duration:        time.Since(start),
+		TimedOut:        errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	if ps := cmd.ProcessState; ps != nil {
+		r.ExitCode = ps.ExitCode()
+		r.Signal = exitSignal(ps)
+	}
+	if err == nil {
+		return r, nil
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return r, &canceledError{&ExitError{r}, ctx.Err()}
+	case errors.As(err, &exitErr):
+		return r, &ExitError{r}
+	case errors.Is(err, exec.ErrWaitDelay):
+		// 进程已经成功退出，只是它留下的后台进程还占用着输出管道
+		return r, nil
+	}
+	return r, err
+}
+
+// canceledError 是因为 ctx 结束而被杀死的进程的错误，
+// errors.As 可以取出其中的 *ExitError，errors.Is 可以判断 ctx 的错误
+type canceledError struct {
+	err    error
+	ctxErr error
+}
+
+func (e *canceledError) Error() string {
+	return e.err.Error() + ": " + e.ctxErr.Error()
+}
+
+func (e *canceledError) Unwrap() []error {
+	return []error{e.err, e.ctxErr}
+}
+
+// Output 执行命令并返回标准输出，相当于 Run 之后取 Result.Stdout
+func Output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r, err := Run(ctx, Command{Name: name, Args: args})
+	if r == nil {
+		return nil, err
+	}
+	return r.Stdout, err
+}
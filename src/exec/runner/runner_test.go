@@ -0,0 +1,152 @@
+//go:build linux
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_Run(t *testing.T) {
+	r, err := Run(context.Background(), Command{
+		Name:  "sh",
+		Args:  []string{"-c", "read x; echo out $x; echo err >&2; exit 3"},
+		Stdin: strings.NewReader("hi\n"),
+	})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 3 {
+		t.Fatalf("err = %v", err)
+	}
+	if string(r.Stdout) != "out hi\n" || string(r.Stderr) != "err\n" || r.Success() {
+		t.Errorf("result = %+v", r)
+	}
+
+	if _, err := Run(context.Background(), Command{Name: "/nonexistent"}); err == nil {
+		t.Error("started a missing command")
+	}
+	out, err := Output(context.Background(), "echo", "a", "b")
+	if err != nil || string(out) != "a b\n" {
+		t.Errorf("Output = %q, %v", out, err)
+	}
+}
+
+func Test_BothStreams(t *testing.T) {
+	// 两个流都写很多数据，如果先读完一个再读另一个，进程会阻塞在写满的管道上
+	script := "i=0; while [ $i -lt 2000 ]; do echo stdout-line-$i; echo stderr-line-$i >&2; i=$((i+1)); done"
+	var mu sync.Mutex
+	lines := map[string]int{}
+	r, err := Run(context.Background(), Command{
+		Name:      "sh",
+		Args:      []string{"-c", script},
+		Timeout:   10 * time.Second,
+		MaxOutput: 1000,
+		OnStdout:  func(string) { mu.Lock(); lines["out"]++; mu.Unlock() },
+		OnStderr:  func(l string) { mu.Lock(); lines[strings.SplitN(l, "-", 2)[0]]++; mu.Unlock() },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Stdout) != 1000 || !r.StdoutTruncated || !r.StderrTruncated {
+		t.Errorf("stdout %d bytes, truncated %v %v", len(r.Stdout), r.StdoutTruncated, r.StderrTruncated)
+	}
+	if lines["out"] != 2000 || lines["stderr"] != 2000 {
+		t.Errorf("lines = %v", lines)
+	}
+}
+
+func Test_PartialLine(t *testing.T) {
+	var got []string
+	_, err := Run(context.Background(), Command{
+		Name:      "printf",
+		Args:      []string{"a\r\nb\nc"},
+		MaxOutput: -1,
+		OnStdout:  func(l string) { got = append(got, l) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != "a|b|c" {
+		t.Errorf("lines = %q", got)
+	}
+}
+
+func Test_LongLine(t *testing.T) {
+	// 没有换行符的输出按 MaxOutput 分段，不会一直积累
+	var got []int
+	_, err := Run(context.Background(), Command{
+		Name:      "sh",
+		Args:      []string{"-c", "head -c 2500 /dev/zero | tr '\\0' x"},
+		MaxOutput: 1000,
+		OnStdout:  func(l string) { got = append(got, len(l)) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1000, 1000, 500}; !slices.Equal(got, want) {
+		t.Errorf("line lengths = %v", got)
+	}
+}
+
+// processAlive 检查进程是否存在
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
+func Test_TimeoutKillsGroup(t *testing.T) {
+	pidFile := t.TempDir() + "/pid"
+	// sh 在后台启动 sleep，然后自己也等待；超时时两者都应该被杀死
+	r, err := Run(context.Background(), Command{
+		Name:    "sh",
+		Args:    []string{"-c", "sleep 30 & echo $! > " + pidFile + "; wait"},
+		Timeout: 200 * time.Millisecond,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v", err)
+	}
+	if !r.TimedOut || r.Signal != syscall.SIGKILL || r.ExitCode != -1 {
+		t.Errorf("result = %+v", r)
+	}
+	if r.Duration > 5*time.Second {
+		t.Errorf("took %v", r.Duration)
+	}
+	b, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(pid) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if processAlive(pid) {
+		t.Errorf("grandchild %d still running", pid)
+	}
+}
+
+func Test_KillGrace(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	// 收到 SIGTERM 后输出一行再退出
+	r, err := Run(ctx, Command{
+		Name:      "sh",
+		Args:      []string{"-c", "trap 'echo term; exit 7' TERM; while :; do sleep 0.05; done"},
+		KillGrace: 2 * time.Second,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	if r.TimedOut || r.ExitCode != 7 || !strings.Contains(string(r.Stdout), "term") {
+		t.Errorf("result = %+v, stdout %q", r, r.Stdout)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+/* 用配置文件管理长期运行的子进程
+
+   go run supervise.go -config supervisor.json                 启动 supervisor
+   go run supervise.go -ctl 127.0.0.1:9001 status              查看状态
+   go run supervise.go -ctl 127.0.0.1:9001 restart ping        启停程序（start/stop/restart）
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"exec/supervisor"
+)
+
+func main() {
+	config := flag.String("config", "supervisor.json", "配置文件")
+	ctl := flag.String("ctl", "", "管理接口的地址，指定时作为客户端运行")
+	flag.Parse()
+
+	if *ctl != "" {
+		if err := control(*ctl, flag.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := supervisor.LoadConfig(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s, err := supervisor.New(cfg.Programs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// SIGINT 和 SIGTERM 停止所有程序后退出，其它信号转发给子进程
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range sigs {
+			if sig == syscall.SIGINT || sig == syscall.SIGTERM {
+				log.Printf("supervisor: %v, stopping", sig)
+				cancel()
+				continue
+			}
+			log.Printf("supervisor: forwarding %v", sig)
+			s.Signal(sig)
+		}
+	}()
+
+	if cfg.Listen != "" {
+		srv := &http.Server{Addr: cfg.Listen, Handler: s.Handler()}
+		go func() {
+			log.Printf("supervisor: listening on %s", cfg.Listen)
+			if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+				log.Print(err)
+			}
+		}()
+		defer srv.Close()
+	}
+	s.Run(ctx)
+}
+
+// control 向正在运行的 supervisor 发送命令
+func control(addr string, args []string) error {
+	if len(args) == 0 || args[0] == "status" {
+		resp, err := http.Get("http://" + addr + "/programs")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		var all []supervisor.Status
+		if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTATE\tPID\tUPTIME\tRESTARTS\tLAST ERROR")
+		for _, st := range all {
+			uptime := ""
+			if !st.StartedAt.IsZero() {
+				uptime = time.Since(st.StartedAt).Round(time.Second).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\n", st.Name, st.State, st.PID, uptime, st.Restarts, st.LastError)
+		}
+		return w.Flush()
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("usage: -ctl addr [status | start NAME | stop NAME | restart NAME]")
+	}
+	resp, err := http.Post("http://"+addr+"/programs/"+args[1]+"/"+args[0], "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("%s %s: %s", args[0], args[1], resp.Status)
+	}
+	fmt.Println(args[0], args[1], "ok")
+	return nil
+}
@@ -0,0 +1,139 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Duration 是 JSON 中写成 "1s"、"500ms" 这样的字符串的时间长度
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Restart 是进程退出后是否重启的策略
+type Restart string
+
+const (
+	Always    Restart = "always"     // 总是重启
+	OnFailure Restart = "on-failure" // 退出码不为 0 或被信号杀死时重启
+	Never     Restart = "never"      // 不重启
+)
+
+// Config 是 supervisor 的配置文件
+type Config struct {
+	// Listen 是 HTTP 管理接口的地址，为空时不启动
+	Listen string `json:"listen"`
+	// LogDir 是程序日志的默认目录，为空时不保存没有指定日志文件的程序的输出
+	LogDir   string    `json:"log_dir"`
+	Programs []Program `json:"programs"`
+}
+
+// Program 是一个被管理的程序
+type Program struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Dir     string   `json:"dir"`
+	Env     []string `json:"env"` // 追加到 supervisor 自己的环境变量之后
+
+	// Autostart 为 false 时需要通过管理接口启动，默认为 true
+	Autostart *bool   `json:"autostart"`
+	Restart   Restart `json:"restart"` // 默认为 on-failure
+	// 第 n 次连续重启前等待 MinBackoff*2^n，最多 MaxBackoff。
+	// 进程运行超过 MaxBackoff 之后退出不算连续失败。
+	MinBackoff Duration `json:"min_backoff"` // 默认 1s
+	MaxBackoff Duration `json:"max_backoff"` // 默认 1m
+	// StopTimeout 是停止时发送 SIGTERM 之后等待多久再发送 SIGKILL，默认 10s
+	StopTimeout Duration `json:"stop_timeout"`
+
+	// StdoutLog 和 StderrLog 是日志文件，为空时使用 LogDir 下的 <name>.out.log 和 <name>.err.log，
+	// 两者相同时写到同一个文件
+	StdoutLog string `json:"stdout_log"`
+	StderrLog string `json:"stderr_log"`
+	// LogMaxSize 是日志文件轮转的大小，默认 10MB；LogBackups 是保留的旧文件个数，默认 5
+	LogMaxSize int64 `json:"log_max_size"`
+	LogBackups int   `json:"log_backups"`
+}
+
+// LoadConfig 读取 JSON 格式的配置文件，检查并补全默认值
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("supervisor: %s: %v", path, err)
+	}
+	if err := c.setDefaults(); err != nil {
+		return nil, fmt.Errorf("supervisor: %s: %v", path, err)
+	}
+	return &c, nil
+}
+
+func (c *Config) setDefaults() error {
+	seen := map[string]bool{}
+	for i := range c.Programs {
+		p := &c.Programs[i]
+		if p.Name == "" || p.Command == "" {
+			return fmt.Errorf("program %d: name and command are required", i)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("duplicate program %q", p.Name)
+		}
+		seen[p.Name] = true
+		switch p.Restart {
+		case "":
+			p.Restart = OnFailure
+		case Always, OnFailure, Never:
+		default:
+			return fmt.Errorf("program %q: unknown restart policy %q", p.Name, p.Restart)
+		}
+		if p.MinBackoff <= 0 {
+			p.MinBackoff = Duration(time.Second)
+		}
+		if p.MaxBackoff < p.MinBackoff {
+			p.MaxBackoff = max(p.MinBackoff, Duration(time.Minute))
+		}
+		if p.StopTimeout <= 0 {
+			p.StopTimeout = Duration(10 * time.Second)
+		}
+		if p.LogMaxSize <= 0 {
+			p.LogMaxSize = 10 << 20
+		}
+		if p.LogBackups <= 0 {
+			p.LogBackups = 5
+		}
+		if c.LogDir != "" {
+			if p.StdoutLog == "" {
+				p.StdoutLog = filepath.Join(c.LogDir, p.Name+".out.log")
+			}
+			if p.StderrLog == "" {
+				p.StderrLog = filepath.Join(c.LogDir, p.Name+".err.log")
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Program) autostart() bool {
+	return p.Autostart == nil || *p.Autostart
+}
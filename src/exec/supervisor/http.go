@@ -0,0 +1,73 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Handler 返回 HTTP 管理接口：
+//
+//	GET  /programs                      所有程序的状态
+//	GET  /programs/{name}               一个程序的状态
+//	POST /programs/{name}/start         启动
+//	POST /programs/{name}/stop          停止
+//	POST /programs/{name}/restart       重启
+func (s *Supervisor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/programs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.Status())
+	})
+	mux.HandleFunc("/programs/", func(w http.ResponseWriter, r *http.Request) {
+		name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/programs/"), "/")
+		if action == "" {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			st, ok := s.Get(name)
+			if !ok {
+				http.Error(w, ErrUnknownProgram.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, st)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var do func(string) error
+		switch action {
+		case "start":
+			do = s.Start
+		case "stop":
+			do = s.Stop
+		case "restart":
+			do = s.Restart
+		default:
+			http.Error(w, "supervisor: unknown action", http.StatusBadRequest)
+			return
+		}
+		if err := do(name); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		// 启停是异步的，返回请求被接受时的状态
+		st, _ := s.Get(name)
+		writeJSON(w, http.StatusAccepted, st)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
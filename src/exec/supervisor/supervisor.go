@@ -0,0 +1,411 @@
+// Package supervisor 启动并看管长期运行的子进程：崩溃后按退避时间重启，
+// 转发信号，把输出写入按大小轮转的日志文件，并通过 HTTP 接口查看状态和启停程序。
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"exec/runner"
+)
+
+// State 是程序的状态
+type State string
+
+const (
+	Stopped State = "stopped" // 没有运行，等待通过管理接口启动
+	Running State = "running"
+	Backoff State = "backoff" // 退出了，正在等待重启
+	Exited  State = "exited"  // 退出了，按重启策略不再重启
+	Fatal   State = "fatal"   // 无法启动，例如命令不存在
+)
+
+// Status 是程序的当前状态
+type Status struct {
+	Name      string    `json:"name"`
+	State     State     `json:"state"`
+	PID       int       `json:"pid,omitempty"`
+	StartedAt time.Time `json:"started_at,omitzero"`
+	// Restarts 是自动或手动重启的次数
+	Restarts int `json:"restarts"`
+	// ExitCode 和 LastError 描述最近一次退出
+	ExitCode  int    `json:"exit_code"`
+	LastError string `json:"last_error,omitempty"`
+	// NextStart 是处于 Backoff 状态时计划重启的时间
+	NextStart time.Time `json:"next_start,omitzero"`
+}
+
+// ErrUnknownProgram 表示配置中没有这个程序
+var ErrUnknownProgram = errors.New("supervisor: unknown program")
+
+// Supervisor 管理一组程序，需要用 New 创建
+type Supervisor struct {
+	// ErrorLog 记录程序的启动、退出和重启，为 nil 时使用 log 包的默认 Logger
+	ErrorLog *log.Logger
+
+	procs  []*process
+	byName map[string]*process
+}
+
+// process 是一个被管理的程序和它的运行状态
+type process struct {
+	prog   Program
+	sup    *Supervisor
+	stdout io.WriteCloser // 为 nil 时丢弃输出
+	stderr io.WriteCloser
+	wake   chan struct{} // 启停请求通知 loop，容量为 1
+
+	mu          sync.Mutex
+	status      Status
+	wantUp      bool
+	skipBackoff bool // Restart 或等待重启时的 Start 请求立即重启
+	proc        *os.Process
+	cancel      context.CancelFunc // 取消当前的运行
+}
+
+// New 创建一个 Supervisor 并打开所有程序的日志文件。程序在调用 Run 之后才会启动。
+func New(programs []Program) (*Supervisor, error) {
+	s := &Supervisor{byName: make(map[string]*process)}
+	for _, prog := range programs {
+		p := &process{
+			prog:   prog,
+			sup:    s,
+			wake:   make(chan struct{}, 1),
+			status: Status{Name: prog.Name, State: Stopped},
+			wantUp: prog.autostart(),
+		}
+		s.procs = append(s.procs, p)
+		s.byName[prog.Name] = p
+		if err := p.openLogs(); err != nil {
+			s.closeLogs()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// openLogs 打开日志文件。打开失败时不能把 nil 的 *RotatingFile 存进接口，否则 closeLogs 会调用它的 Close。
+func (p *process) openLogs() error {
+	if p.prog.StdoutLog != "" {
+		f, err := OpenRotating(p.prog.StdoutLog, p.prog.LogMaxSize, p.prog.LogBackups)
+		if err != nil {
+			return err
+		}
+		p.stdout = f
+	}
+	switch {
+	case p.prog.StderrLog == p.prog.StdoutLog:
+		p.stderr = p.stdout
+	case p.prog.StderrLog != "":
+		f, err := OpenRotating(p.prog.StderrLog, p.prog.LogMaxSize, p.prog.LogBackups)
+		if err != nil {
+			return err
+		}
+		p.stderr = f
+	}
+	return nil
+}
+
+func (s *Supervisor) closeLogs() {
+	for _, p := range s.procs {
+		if p.stdout != nil {
+			p.stdout.Close()
+		}
+		if p.stderr != nil && p.stderr != p.stdout {
+			p.stderr.Close()
+		}
+	}
+}
+
+func (s *Supervisor) logf(format string, args ...any) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// Run 启动所有自动启动的程序并看管它们，直到 ctx 结束。
+// ctx 结束后停止所有程序（先 SIGTERM，超过 StopTimeout 后 SIGKILL），等它们退出后关闭日志文件。
+func (s *Supervisor) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, p := range s.procs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.loop(ctx)
+		}()
+	}
+	wg.Wait()
+	s.closeLogs()
+	return ctx.Err()
+}
+
+// Status 返回所有程序的状态，按名字排序
+func (s *Supervisor) Status() []Status {
+	out := make([]Status, 0, len(s.procs))
+	for _, p := range s.procs {
+		out = append(out, p.getStatus())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Get 返回一个程序的状态
+func (s *Supervisor) Get(name string) (Status, bool) {
+	p, ok := s.byName[name]
+	if !ok {
+		return Status{}, false
+	}
+	return p.getStatus(), true
+}
+
+func (p *process) getStatus() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// control 在持有锁的情况下修改程序的状态，然后通知 loop
+func (s *Supervisor) control(name string, f func(p *process)) error {
+	p, ok := s.byName[name]
+	if !ok {
+		return ErrUnknownProgram
+	}
+	p.mu.Lock()
+	f(p)
+	p.mu.Unlock()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start 启动一个没有运行的程序，正在等待重启的程序会立即启动
+func (s *Supervisor) Start(name string) error {
+	return s.control(name, func(p *process) {
+		p.wantUp = true
+		if p.status.State == Backoff {
+			p.skipBackoff = true
+		}
+	})
+}
+
+// Stop 停止一个程序，它不会再被自动重启，直到调用 Start
+func (s *Supervisor) Stop(name string) error {
+	return s.control(name, func(p *process) {
+		p.wantUp = false
+		if p.cancel != nil {
+			p.cancel()
+		}
+	})
+}
+
+// Restart 停止正在运行的程序并立即重新启动它，不等待退避时间
+func (s *Supervisor) Restart(name string) error {
+	return s.control(name, func(p *process) {
+		p.wantUp = true
+		p.skipBackoff = true
+		if p.cancel != nil {
+			p.cancel()
+		}
+	})
+}
+
+// Signal 把信号转发给所有正在运行的程序
+func (s *Supervisor) Signal(sig os.Signal) {
+	for _, p := range s.procs {
+		p.mu.Lock()
+		if p.proc != nil {
+			p.proc.Signal(sig)
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *process) loop(ctx context.Context) {
+	failures := 0
+	for {
+		p.mu.Lock()
+		up := p.wantUp
+		if up {
+			// 没有运行时的 Restart 已经由这次启动完成
+			p.skipBackoff = false
+		}
+		p.mu.Unlock()
+		if !up {
+			select {
+			case <-p.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		start := time.Now()
+		r, err := p.runOnce(ctx)
+		uptime := time.Since(start)
+
+		p.mu.Lock()
+		st := &p.status
+		st.PID, st.StartedAt = 0, time.Time{}
+		st.ExitCode, st.LastError = -1, ""
+		if r != nil {
+			st.ExitCode = r.ExitCode
+		}
+		if err != nil {
+			st.LastError = err.Error()
+		}
+		var delay time.Duration
+		switch {
+		case ctx.Err() != nil || !p.wantUp:
+			st.State = Stopped
+		case p.skipBackoff:
+			p.skipBackoff = false
+			st.State = Backoff
+		case !p.shouldRestart(r):
+			p.wantUp = false
+			st.State = Exited
+			if r == nil {
+				st.State = Fatal
+			}
+		default:
+			// 运行了足够长的时间才退出，不算连续失败
+			if uptime >= time.Duration(p.prog.MaxBackoff) {
+				failures = 0
+			}
+			delay = min(time.Duration(p.prog.MinBackoff)<<failures, time.Duration(p.prog.MaxBackoff))
+			// 到达上限后不再增加，否则继续左移会溢出成负数或 0，程序会不等待就重启
+			if delay < time.Duration(p.prog.MaxBackoff) {
+				failures++
+			}
+			st.State = Backoff
+			st.NextStart = time.Now().Add(delay)
+		}
+		state := st.State
+		p.mu.Unlock()
+
+		p.sup.logf("supervisor: %s exited after %v (%s), now %s", p.prog.Name, uptime.Round(time.Millisecond), describe(r, err), state)
+		if ctx.Err() != nil {
+			return
+		}
+		if state != Backoff {
+			continue
+		}
+		if delay > 0 && !p.waitBackoff(ctx, delay) {
+			p.setState(Stopped)
+			return
+		}
+		p.mu.Lock()
+		if p.wantUp {
+			p.status.Restarts++
+		} else {
+			// 等待期间被 Stop，不会再重启
+			p.status.State = Stopped
+		}
+		p.status.NextStart = time.Time{}
+		p.mu.Unlock()
+	}
+}
+
+// waitBackoff 等待 delay 后返回 true，期间收到 Stop、Start 或 Restart 时提前返回 true，ctx 结束时返回 false。
+// 运行时的 Start 等请求也会在 wake 中留下通知，所以醒来后要检查状态是否真的改变了，否则继续等待。
+func (p *process) waitBackoff(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return true
+		case <-p.wake:
+			p.mu.Lock()
+			woken := !p.wantUp || p.skipBackoff
+			p.skipBackoff = false
+			p.mu.Unlock()
+			if woken {
+				return true
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// shouldRestart 按重启策略判断退出的程序是否需要重启，r 为 nil 表示无法启动
+func (p *process) shouldRestart(r *runner.Result) bool {
+	switch p.prog.Restart {
+	case Always:
+		return true
+	case OnFailure:
+		return r == nil || !r.Success()
+	}
+	return false
+}
+
+func (p *process) setState(s State) {
+	p.mu.Lock()
+	p.status.State = s
+	p.mu.Unlock()
+}
+
+func describe(r *runner.Result, err error) string {
+	if r == nil || err != nil {
+		return err.Error()
+	}
+	return "exit status 0"
+}
+
+// runOnce 运行一次程序直到它退出或被取消
+func (p *process) runOnce(ctx context.Context) (*runner.Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	p.mu.Lock()
+	p.cancel = cancel
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.cancel, p.proc = nil, nil
+		p.mu.Unlock()
+	}()
+
+	var env []string
+	if len(p.prog.Env) > 0 {
+		env = append(os.Environ(), p.prog.Env...)
+	}
+	return runner.Run(ctx, runner.Command{
+		Name:      p.prog.Command,
+		Args:      p.prog.Args,
+		Dir:       p.prog.Dir,
+		Env:       env,
+		KillGrace: time.Duration(p.prog.StopTimeout),
+		MaxOutput: -1,
+		OnStdout:  lineWriter(p.stdout),
+		OnStderr:  lineWriter(p.stderr),
+		OnStart: func(proc *os.Process) {
+			p.mu.Lock()
+			p.proc = proc
+			p.status.State = Running
+			p.status.PID = proc.Pid
+			p.status.StartedAt = time.Now()
+			p.mu.Unlock()
+			p.sup.logf("supervisor: %s started, pid %d", p.prog.Name, proc.Pid)
+		},
+	})
+}
+
+// lineWriter 把每一行输出写入日志文件，w 为 nil 时丢弃
+func lineWriter(w io.Writer) func(string) {
+	if w == nil {
+		return nil
+	}
+	return func(line string) {
+		io.WriteString(w, line+"\n")
+	}
+}
@@ -0,0 +1,313 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitFor 等待 cond 成立，最多等 5 秒
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func program(name string, script string) Program {
+	return Program{Name: name, Command: "sh", Args: []string{"-c", script}}
+}
+
+// start 用 programs 创建 Supervisor 并在后台运行，测试结束时停止它。没有设置退避时间的程序使用很短的退避时间。
+func start(t *testing.T, logDir string, programs ...Program) *Supervisor {
+	t.Helper()
+	c := &Config{LogDir: logDir, Programs: programs}
+	for i := range c.Programs {
+		if c.Programs[i].MinBackoff == 0 {
+			c.Programs[i].MinBackoff = Duration(20 * time.Millisecond)
+			c.Programs[i].MaxBackoff = Duration(100 * time.Millisecond)
+		}
+		c.Programs[i].StopTimeout = Duration(time.Second)
+	}
+	if err := c.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(c.Programs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ErrorLog = log.New(io.Discard, "", 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("Run did not return after cancel")
+		}
+	})
+	return s
+}
+
+func state(s *Supervisor, name string) Status {
+	st, _ := s.Get(name)
+	return st
+}
+
+func Test_RestartWithBackoff(t *testing.T) {
+	dir := t.TempDir()
+	s := start(t, dir,
+		program("crash", "echo started; echo oops >&2; exit 2"),
+		program("once", "exit 0"),
+	)
+	waitFor(t, "restarts", func() bool { return state(s, "crash").Restarts >= 3 })
+	st := state(s, "crash")
+	if st.ExitCode != 2 || !strings.Contains(st.LastError, "exit status 2") {
+		t.Errorf("status = %+v", st)
+	}
+	// on-failure：正常退出的程序不重启
+	waitFor(t, "once exited", func() bool { return state(s, "once").State == Exited })
+	if st := state(s, "once"); st.Restarts != 0 || st.ExitCode != 0 {
+		t.Errorf("once = %+v", st)
+	}
+
+	out, _ := os.ReadFile(filepath.Join(dir, "crash.out.log"))
+	errLog, _ := os.ReadFile(filepath.Join(dir, "crash.err.log"))
+	if strings.Count(string(out), "started\n") < 3 || !strings.HasPrefix(string(errLog), "oops\n") {
+		t.Errorf("logs = %q, %q", out, errLog)
+	}
+}
+
+func Test_StartKeepsBackoff(t *testing.T) {
+	// 运行时调用 Start 不能让下一次崩溃跳过退避时间
+	p := program("crash", "sleep 0.2; exit 1")
+	p.MinBackoff = Duration(3 * time.Second)
+	p.MaxBackoff = Duration(3 * time.Second)
+	s := start(t, "", p)
+	waitFor(t, "running", func() bool { return state(s, "crash").State == Running })
+	if err := s.Start("crash"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "backoff", func() bool { return state(s, "crash").State == Backoff })
+	time.Sleep(300 * time.Millisecond)
+	if st := state(s, "crash"); st.State != Backoff || st.Restarts != 0 {
+		t.Fatalf("after Start while running: %+v", st)
+	}
+
+	// 等待重启时调用 Start 立即重启
+	s.Start("crash")
+	deadline := time.Now().Add(time.Second)
+	for state(s, "crash").Restarts == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Start during backoff did not restart: %+v", state(s, "crash"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func Test_FatalAndNever(t *testing.T) {
+	missing := Program{Name: "missing", Command: "/nonexistent", Restart: Never}
+	never := program("never", "exit 1")
+	never.Restart = Never
+	s := start(t, "", missing, never)
+	waitFor(t, "fatal", func() bool { return state(s, "missing").State == Fatal })
+	waitFor(t, "exited", func() bool { return state(s, "never").State == Exited })
+}
+
+func Test_HTTPControl(t *testing.T) {
+	off := false
+	idle := program("idle", "exec sleep 30")
+	manual := program("manual", "exec sleep 30")
+	manual.Autostart = &off
+	crash := program("crash", "exit 1")
+	s := start(t, "", idle, manual, crash)
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	post := func(path string) int {
+		resp, err := http.Post(srv.URL+path, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	waitFor(t, "idle running", func() bool { return state(s, "idle").State == Running })
+	if st := state(s, "manual"); st.State != Stopped {
+		t.Errorf("manual = %+v", st)
+	}
+	pid := state(s, "idle").PID
+
+	if code := post("/programs/idle/restart"); code != http.StatusAccepted {
+		t.Fatalf("restart = %d", code)
+	}
+	waitFor(t, "new pid", func() bool {
+		st := state(s, "idle")
+		return st.State == Running && st.PID != pid && st.PID != 0
+	})
+	if st := state(s, "idle"); st.Restarts != 1 {
+		t.Errorf("restarts = %d", st.Restarts)
+	}
+
+	post("/programs/idle/stop")
+	waitFor(t, "idle stopped", func() bool { return state(s, "idle").State == Stopped })
+	post("/programs/manual/start")
+	waitFor(t, "manual running", func() bool { return state(s, "manual").State == Running })
+
+	// 在退避等待中停止的程序也是 Stopped
+	waitFor(t, "crash backoff", func() bool { return state(s, "crash").State == Backoff })
+	post("/programs/crash/stop")
+	waitFor(t, "crash stopped", func() bool { return state(s, "crash").State == Stopped })
+
+	if code := post("/programs/nope/start"); code != http.StatusNotFound {
+		t.Errorf("unknown program = %d", code)
+	}
+	if code := post("/programs/idle/explode"); code != http.StatusBadRequest {
+		t.Errorf("unknown action = %d", code)
+	}
+
+	resp, err := http.Get(srv.URL + "/programs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var all []Status
+	if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[1].Name != "idle" || all[2].State != Running {
+		t.Errorf("GET /programs = %+v", all)
+	}
+}
+
+func Test_SignalForwarding(t *testing.T) {
+	dir := t.TempDir()
+	s := start(t, dir, program("trap", `trap 'echo got usr1' USR1; echo ready; while :; do sleep 0.02; done`))
+	logFile := filepath.Join(dir, "trap.out.log")
+	contains := func(s string) func() bool {
+		return func() bool {
+			b, _ := os.ReadFile(logFile)
+			return strings.Contains(string(b), s)
+		}
+	}
+	waitFor(t, "ready", contains("ready"))
+	s.Signal(syscall.SIGUSR1)
+	waitFor(t, "trap output", contains("got usr1"))
+}
+
+func Test_Shutdown(t *testing.T) {
+	p := program("term", "trap 'exit 0' TERM; while :; do sleep 0.02; done")
+	c := &Config{Programs: []Program{p}}
+	c.setDefaults()
+	s, _ := New(c.Programs)
+	s.ErrorLog = log.New(io.Discard, "", 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	waitFor(t, "running", func() bool { return state(s, "term").State == Running })
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	if st := state(s, "term"); st.State != Stopped || st.PID != 0 {
+		t.Errorf("status after shutdown = %+v", st)
+	}
+}
+
+func Test_RotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	r, err := OpenRotating(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		io.WriteString(r, s)
+		io.WriteString(r, s)
+	}
+	io.WriteString(r, "tail\n")
+	r.Close()
+
+	read := func(p string) string {
+		b, _ := os.ReadFile(p)
+		return string(b)
+	}
+	if got := read(path); got != "tail\n" {
+		t.Errorf("current = %q", got)
+	}
+	if got := read(path + ".1"); got != "dddddd\ndddddd\n" {
+		t.Errorf(".1 = %q", got)
+	}
+	if got := read(path + ".2"); got != "cccccc\ncccccc\n" {
+		t.Errorf(".2 = %q", got)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("more backups than configured")
+	}
+}
+
+func Test_BadLogPath(t *testing.T) {
+	// 日志目录的位置是一个普通文件，打开日志失败
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0o644)
+	good := program("good", "true")
+	good.StdoutLog = filepath.Join(t.TempDir(), "good.log")
+	bad := program("bad", "true")
+	bad.StdoutLog = filepath.Join(file, "bad.log")
+	bad.StderrLog = filepath.Join(file, "bad.err.log")
+	if _, err := New([]Program{good, bad}); err == nil {
+		t.Error("New with a bad log path: no error")
+	}
+}
+
+func Test_LoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "supervisor.json")
+	os.WriteFile(path, []byte(`{
+		"log_dir": "logs",
+		"programs": [{"name": "a", "command": "true", "restart": "always", "min_backoff": "250ms"}]
+	}`), 0o644)
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.Programs[0]
+	if p.MinBackoff != Duration(250*time.Millisecond) || p.MaxBackoff != Duration(time.Minute) ||
+		p.StdoutLog != filepath.Join("logs", "a.out.log") || !p.autostart() {
+		t.Errorf("program = %+v", p)
+	}
+
+	for _, bad := range []string{
+		`{"programs": [{"name": "a"}]}`,
+		`{"programs": [{"name": "a", "command": "x"}, {"name": "a", "command": "y"}]}`,
+		`{"programs": [{"name": "a", "command": "x", "restart": "sometimes"}]}`,
+		`{"programs": [{"name": "a", "command": "x", "min_backoff": "soon"}]}`,
+	} {
+		os.WriteFile(path, []byte(bad), 0o644)
+		if _, err := LoadConfig(path); err == nil {
+			t.Errorf("LoadConfig(%s) succeeded", bad)
+		}
+	}
+}
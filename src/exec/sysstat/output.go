@@ -0,0 +1,104 @@
+package sysstat
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WriteJSON 把 v（通常是 *Rates 或 *Snapshot）写成一行 JSON，适合追加到 JSON Lines 文件
+func WriteJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// promWriter 按 Prometheus 文本格式输出指标，每个指标的 HELP 和 TYPE 只写一次
+type promWriter struct {
+	w    *bufio.Writer
+	seen map[string]bool
+}
+
+func (p *promWriter) metric(name, typ, help, labels string, v float64) {
+	if !p.seen[name] {
+		p.seen[name] = true
+		fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(p.w, "%s%s %g\n", name, labels, v)
+}
+
+// WritePrometheus 把 Snapshot 写成 Prometheus 的文本格式。
+// 指标的名字和 node_exporter 相同，累计值以 counter 输出，速率由 Prometheus 用 rate() 计算。
+func WritePrometheus(w io.Writer, s *Snapshot) error {
+	p := &promWriter{w: bufio.NewWriter(w), seen: map[string]bool{}}
+	const cpuHelp = "Seconds the CPUs spent in each mode."
+	for _, c := range s.CPUs {
+		if c.Name == "cpu" {
+			continue // 合计值可以由各个 CPU 相加得到
+		}
+		cpu := c.Name[len("cpu"):]
+		for _, m := range []struct {
+			mode string
+			v    uint64
+		}{
+			{"user", c.User}, {"nice", c.Nice}, {"system", c.System}, {"idle", c.Idle},
+			{"iowait", c.IOWait}, {"irq", c.IRQ}, {"softirq", c.SoftIRQ}, {"steal", c.Steal},
+		} {
+			p.metric("node_cpu_seconds_total", "counter", cpuHelp,
+				fmt.Sprintf(`cpu=%q,mode=%q`, cpu, m.mode), float64(m.v)/userHZ)
+		}
+	}
+	p.metric("node_context_switches_total", "counter", "Total number of context switches.", "", float64(s.ContextSwitches))
+	p.metric("node_forks_total", "counter", "Total number of forks.", "", float64(s.Forks))
+
+	m := s.Memory
+	for _, g := range []struct {
+		name string
+		v    uint64
+	}{
+		{"MemTotal", m.Total}, {"MemFree", m.Free}, {"MemAvailable", m.Available},
+		{"Buffers", m.Buffers}, {"Cached", m.Cached}, {"SwapTotal", m.SwapTotal}, {"SwapFree", m.SwapFree},
+	} {
+		p.metric("node_memory_"+g.name+"_bytes", "gauge", "Memory information field "+g.name+"_bytes.", "", float64(g.v))
+	}
+
+	p.metric("node_load1", "gauge", "1m load average.", "", s.Load.Load1)
+	p.metric("node_load5", "gauge", "5m load average.", "", s.Load.Load5)
+	p.metric("node_load15", "gauge", "15m load average.", "", s.Load.Load15)
+	p.metric("node_procs_running", "gauge", "Number of processes in runnable state.", "", float64(s.Load.Running))
+
+	for _, d := range s.Disks {
+		l := fmt.Sprintf("device=%q", d.Name)
+		p.metric("node_disk_reads_completed_total", "counter", "The total number of reads completed successfully.", l, float64(d.Reads))
+		p.metric("node_disk_writes_completed_total", "counter", "The total number of writes completed successfully.", l, float64(d.Writes))
+		p.metric("node_disk_read_bytes_total", "counter", "The total number of bytes read successfully.", l, float64(d.ReadBytes))
+		p.metric("node_disk_written_bytes_total", "counter", "The total number of bytes written successfully.", l, float64(d.WriteBytes))
+		p.metric("node_disk_io_time_seconds_total", "counter", "Total seconds spent doing I/Os.", l, float64(d.IOTime)/1000)
+	}
+	for _, n := range s.Net {
+		l := fmt.Sprintf("device=%q", n.Name)
+		p.metric("node_network_receive_bytes_total", "counter", "Network device statistic receive_bytes.", l, float64(n.RxBytes))
+		p.metric("node_network_receive_packets_total", "counter", "Network device statistic receive_packets.", l, float64(n.RxPackets))
+		p.metric("node_network_receive_errs_total", "counter", "Network device statistic receive_errs.", l, float64(n.RxErrors))
+		p.metric("node_network_transmit_bytes_total", "counter", "Network device statistic transmit_bytes.", l, float64(n.TxBytes))
+		p.metric("node_network_transmit_packets_total", "counter", "Network device statistic transmit_packets.", l, float64(n.TxPackets))
+		p.metric("node_network_transmit_errs_total", "counter", "Network device statistic transmit_errs.", l, float64(n.TxErrors))
+	}
+	return p.w.Flush()
+}
+
+// Handler 返回一个 HTTP 处理器，每次请求时读取统计信息并以 Prometheus 文本格式返回
+func (r Reader) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s, err := r.Read()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheus(w, s)
+	})
+}
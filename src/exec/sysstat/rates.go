@@ -0,0 +1,173 @@
+package sysstat
+
+import (
+	"context"
+	"time"
+)
+
+// CPUUsage 是一段时间内 CPU 在各种状态下的时间占比，单位是百分比
+type CPUUsage struct {
+	Name   string  `json:"name"`
+	User   float64 `json:"user"`   // 包括 nice
+	System float64 `json:"system"` // 包括 irq 和 softirq
+	IOWait float64 `json:"iowait"`
+	Steal  float64 `json:"steal"`
+	Idle   float64 `json:"idle"`
+}
+
+// Busy 返回非空闲的时间占比
+func (u CPUUsage) Busy() float64 {
+	return 100 - u.Idle - u.IOWait
+}
+
+// DiskRate 是一段时间内块设备每秒的读写量
+type DiskRate struct {
+	Name        string  `json:"name"`
+	Reads       float64 `json:"reads_per_sec"`
+	Writes      float64 `json:"writes_per_sec"`
+	ReadBytes   float64 `json:"read_bytes_per_sec"`
+	WriteBytes  float64 `json:"write_bytes_per_sec"`
+	Utilization float64 `json:"util"` // 设备忙碌的时间占比，百分比
+}
+
+// NetRate 是一段时间内网络接口每秒的收发量
+type NetRate struct {
+	Name      string  `json:"name"`
+	RxBytes   float64 `json:"rx_bytes_per_sec"`
+	RxPackets float64 `json:"rx_packets_per_sec"`
+	TxBytes   float64 `json:"tx_bytes_per_sec"`
+	TxPackets float64 `json:"tx_packets_per_sec"`
+	Errors    float64 `json:"errors_per_sec"`
+}
+
+// Rates 是两次 Snapshot 之间的变化，内存和负载是后一次的值
+type Rates struct {
+	Time            time.Time     `json:"time"`
+	Interval        time.Duration `json:"interval_ns"`
+	CPUs            []CPUUsage    `json:"cpus"`
+	ContextSwitches float64       `json:"context_switches_per_sec"`
+	Forks           float64       `json:"forks_per_sec"`
+	Memory          Memory        `json:"memory"`
+	Load            Load          `json:"load"`
+	Disks           []DiskRate    `json:"disks"`
+	Net             []NetRate     `json:"net"`
+}
+
+// delta 返回计数的增量，计数器回绕或重置（例如网卡重新加载）时返回 0
+func delta(prev, cur uint64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur - prev)
+}
+
+func percent(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * part / total
+}
+
+// Diff 计算从 prev 到 cur 之间的使用率和速率。只在 prev 中出现过的 CPU、磁盘和网络接口会被计算。
+func Diff(prev, cur *Snapshot) *Rates {
+	d := cur.Time.Sub(prev.Time)
+	secs := d.Seconds()
+	perSec := func(p, c uint64) float64 {
+		if secs <= 0 {
+			return 0
+		}
+		return delta(p, c) / secs
+	}
+	r := &Rates{
+		Time:            cur.Time,
+		Interval:        d,
+		ContextSwitches: perSec(prev.ContextSwitches, cur.ContextSwitches),
+		Forks:           perSec(prev.Forks, cur.Forks),
+		Memory:          cur.Memory,
+		Load:            cur.Load,
+	}
+
+	prevCPU := make(map[string]CPU, len(prev.CPUs))
+	for _, c := range prev.CPUs {
+		prevCPU[c.Name] = c
+	}
+	for _, c := range cur.CPUs {
+		p, ok := prevCPU[c.Name]
+		if !ok {
+			continue
+		}
+		total := delta(p.Total(), c.Total())
+		r.CPUs = append(r.CPUs, CPUUsage{
+			Name:   c.Name,
+			User:   percent(delta(p.User, c.User)+delta(p.Nice, c.Nice), total),
+			System: percent(delta(p.System, c.System)+delta(p.IRQ, c.IRQ)+delta(p.SoftIRQ, c.SoftIRQ), total),
+			IOWait: percent(delta(p.IOWait, c.IOWait), total),
+			Steal:  percent(delta(p.Steal, c.Steal), total),
+			Idle:   percent(delta(p.Idle, c.Idle), total),
+		})
+	}
+
+	prevDisk := make(map[string]Disk, len(prev.Disks))
+	for _, x := range prev.Disks {
+		prevDisk[x.Name] = x
+	}
+	for _, x := range cur.Disks {
+		p, ok := prevDisk[x.Name]
+		if !ok {
+			continue
+		}
+		r.Disks = append(r.Disks, DiskRate{
+			Name:        x.Name,
+			Reads:       perSec(p.Reads, x.Reads),
+			Writes:      perSec(p.Writes, x.Writes),
+			ReadBytes:   perSec(p.ReadBytes, x.ReadBytes),
+			WriteBytes:  perSec(p.WriteBytes, x.WriteBytes),
+			Utilization: min(100, percent(delta(p.IOTime, x.IOTime), float64(d.Milliseconds()))),
+		})
+	}
+
+	prevNet := make(map[string]Net, len(prev.Net))
+	for _, x := range prev.Net {
+		prevNet[x.Name] = x
+	}
+	for _, x := range cur.Net {
+		p, ok := prevNet[x.Name]
+		if !ok {
+			continue
+		}
+		r.Net = append(r.Net, NetRate{
+			Name:      x.Name,
+			RxBytes:   perSec(p.RxBytes, x.RxBytes),
+			RxPackets: perSec(p.RxPackets, x.RxPackets),
+			TxBytes:   perSec(p.TxBytes, x.TxBytes),
+			TxPackets: perSec(p.TxPackets, x.TxPackets),
+			Errors:    perSec(p.RxErrors+p.TxErrors, x.RxErrors+x.TxErrors),
+		})
+	}
+	return r
+}
+
+// Sample 每隔 interval 读取一次统计信息，把和上一次的差值交给 f，直到 ctx 结束或 f 返回错误
+func (r Reader) Sample(ctx context.Context, interval time.Duration, f func(*Rates) error) error {
+	prev, err := r.Read()
+	if err != nil {
+		return err
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		cur, err := r.Read()
+		if err != nil {
+			return err
+		}
+		if err := f(Diff(prev, cur)); err != nil {
+			return err
+		}
+		prev = cur
+	}
+}
@@ -0,0 +1,282 @@
+// Package sysstat 直接从 /proc 读取 CPU、内存、负载、磁盘和网络的统计信息，
+// 代替执行 vmstat 之类的命令再解析它们的文本输出。
+//
+// Read 返回某一时刻的累计计数（Snapshot），Diff 用前后两次的计数算出这段时间内的使用率和速率（Rates）。
+// 结果可以写成 JSON 行，或者 Prometheus 的文本格式。
+package sysstat
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userHZ 是 /proc/stat 中时间的单位（每秒的 jiffies），Linux 上固定为 100
+const userHZ = 100
+
+// sectorSize 是 /proc/diskstats 中扇区的大小，和磁盘实际的扇区大小无关
+const sectorSize = 512
+
+// CPU 是一个 CPU（或者 Name 为 "cpu" 时所有 CPU 的合计）在各种状态下累计的时间，单位是 jiffies
+type CPU struct {
+	Name    string `json:"name"`
+	User    uint64 `json:"user"`
+	Nice    uint64 `json:"nice"`
+	System  uint64 `json:"system"`
+	Idle    uint64 `json:"idle"`
+	IOWait  uint64 `json:"iowait"`
+	IRQ     uint64 `json:"irq"`
+	SoftIRQ uint64 `json:"softirq"`
+	Steal   uint64 `json:"steal"`
+}
+
+// Total 返回所有状态的时间之和
+func (c CPU) Total() uint64 {
+	return c.User + c.Nice + c.System + c.Idle + c.IOWait + c.IRQ + c.SoftIRQ + c.Steal
+}
+
+// Memory 是内存的使用情况，单位是字节
+type Memory struct {
+	Total     uint64 `json:"total"`
+	Free      uint64 `json:"free"`
+	Available uint64 `json:"available"`
+	Buffers   uint64 `json:"buffers"`
+	Cached    uint64 `json:"cached"`
+	SwapTotal uint64 `json:"swap_total"`
+	SwapFree  uint64 `json:"swap_free"`
+}
+
+// Used 返回已经使用的内存，不包括可以回收的缓存
+func (m Memory) Used() uint64 {
+	return m.Total - m.Available
+}
+
+// Load 是系统的平均负载
+type Load struct {
+	Load1   float64 `json:"load1"`
+	Load5   float64 `json:"load5"`
+	Load15  float64 `json:"load15"`
+	Running int     `json:"running"` // 可运行的进程（线程）数
+	Total   int     `json:"total"`   // 所有进程（线程）数
+}
+
+// Disk 是一个块设备累计的读写计数
+type Disk struct {
+	Name       string `json:"name"`
+	Reads      uint64 `json:"reads"`
+	Writes     uint64 `json:"writes"`
+	ReadBytes  uint64 `json:"read_bytes"`
+	WriteBytes uint64 `json:"write_bytes"`
+	IOTime     uint64 `json:"io_time_ms"` // 设备忙碌的时间，单位是毫秒
+}
+
+// Net 是一个网络接口累计的收发计数
+type Net struct {
+	Name      string `json:"name"`
+	RxBytes   uint64 `json:"rx_bytes"`
+	RxPackets uint64 `json:"rx_packets"`
+	RxErrors  uint64 `json:"rx_errors"`
+	TxBytes   uint64 `json:"tx_bytes"`
+	TxPackets uint64 `json:"tx_packets"`
+	TxErrors  uint64 `json:"tx_errors"`
+}
+
+// Snapshot 是某一时刻的统计信息，CPU、磁盘和网络都是从开机起的累计值
+type Snapshot struct {
+	Time            time.Time `json:"time"`
+	CPUs            []CPU     `json:"cpus"` // 第一个是合计的 "cpu"
+	ContextSwitches uint64    `json:"context_switches"`
+	Forks           uint64    `json:"forks"`
+	Memory          Memory    `json:"memory"`
+	Load            Load      `json:"load"`
+	Disks           []Disk    `json:"disks"`
+	Net             []Net     `json:"net"`
+}
+
+// Reader 从 Root 目录读取统计信息，Root 为空时是 /proc。测试时可以指向准备好的文件。
+type Reader struct {
+	Root string
+}
+
+func (r Reader) open(name string) (*os.File, error) {
+	root := r.Root
+	if root == "" {
+		root = "/proc"
+	}
+	return os.Open(filepath.Join(root, name))
+}
+
+// scan 对文件的每一行调用 f，行被拆分成字段
+func (r Reader) scan(name string, f func(fields []string) error) error {
+	file, err := r.open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		if err := f(strings.Fields(s.Text())); err != nil {
+			return fmt.Errorf("sysstat: %s: %v", name, err)
+		}
+	}
+	return s.Err()
+}
+
+// Read 读取当前的统计信息
+func (r Reader) Read() (*Snapshot, error) {
+	s := &Snapshot{Time: time.Now()}
+	for _, read := range []func(*Snapshot) error{r.readStat, r.readMeminfo, r.readLoadavg, r.readDiskstats, r.readNetDev} {
+		if err := read(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Read 用默认的 Reader 读取 /proc
+func Read() (*Snapshot, error) {
+	return Reader{}.Read()
+}
+
+func parseUint(s string) (uint64, error) {
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// parseUints 解析 fields 中的数字，依次存入 dst
+func parseUints(fields []string, dst ...*uint64) error {
+	for i, p := range dst {
+		if i >= len(fields) {
+			break // 老版本的内核字段较少
+		}
+		v, err := parseUint(fields[i])
+		if err != nil {
+			return err
+		}
+		*p = v
+	}
+	return nil
+}
+
+func (r Reader) readStat(s *Snapshot) error {
+	return r.scan("stat", func(f []string) error {
+		if len(f) < 2 {
+			return nil
+		}
+		switch {
+		case strings.HasPrefix(f[0], "cpu"):
+			c := CPU{Name: f[0]}
+			if err := parseUints(f[1:], &c.User, &c.Nice, &c.System, &c.Idle, &c.IOWait, &c.IRQ, &c.SoftIRQ, &c.Steal); err != nil {
+				return err
+			}
+			s.CPUs = append(s.CPUs, c)
+		case f[0] == "ctxt":
+			return parseUints(f[1:], &s.ContextSwitches)
+		case f[0] == "processes":
+			return parseUints(f[1:], &s.Forks)
+		}
+		return nil
+	})
+}
+
+func (r Reader) readMeminfo(s *Snapshot) error {
+	fields := map[string]*uint64{
+		"MemTotal:":     &s.Memory.Total,
+		"MemFree:":      &s.Memory.Free,
+		"MemAvailable:": &s.Memory.Available,
+		"Buffers:":      &s.Memory.Buffers,
+		"Cached:":       &s.Memory.Cached,
+		"SwapTotal:":    &s.Memory.SwapTotal,
+		"SwapFree:":     &s.Memory.SwapFree,
+	}
+	return r.scan("meminfo", func(f []string) error {
+		p, ok := fields[f[0]]
+		if !ok || len(f) < 2 {
+			return nil
+		}
+		v, err := parseUint(f[1])
+		if err != nil {
+			return err
+		}
+		if len(f) > 2 && f[2] == "kB" {
+			v *= 1024
+		}
+		*p = v
+		return nil
+	})
+}
+
+func (r Reader) readLoadavg(s *Snapshot) error {
+	return r.scan("loadavg", func(f []string) error {
+		if len(f) < 4 {
+			return fmt.Errorf("bad loadavg %q", f)
+		}
+		var err error
+		l := &s.Load
+		for i, p := range []*float64{&l.Load1, &l.Load5, &l.Load15} {
+			if *p, err = strconv.ParseFloat(f[i], 64); err != nil {
+				return err
+			}
+		}
+		running, total, _ := strings.Cut(f[3], "/")
+		if l.Running, err = strconv.Atoi(running); err != nil {
+			return err
+		}
+		l.Total, err = strconv.Atoi(total)
+		return err
+	})
+}
+
+// readDiskstats 读取块设备的计数，跳过从来没有读写过的设备（例如没有使用的 loop 设备）
+func (r Reader) readDiskstats(s *Snapshot) error {
+	return r.scan("diskstats", func(f []string) error {
+		if len(f) < 14 {
+			return nil
+		}
+		d := Disk{Name: f[2]}
+		var merged, readSectors, readMs, writeSectors uint64
+		err := parseUints(f[3:], &d.Reads, &merged, &readSectors, &readMs, &d.Writes, &merged, &writeSectors)
+		if err != nil {
+			return err
+		}
+		if d.IOTime, err = parseUint(f[12]); err != nil {
+			return err
+		}
+		if d.Reads == 0 && d.Writes == 0 {
+			return nil
+		}
+		d.ReadBytes, d.WriteBytes = readSectors*sectorSize, writeSectors*sectorSize
+		s.Disks = append(s.Disks, d)
+		return nil
+	})
+}
+
+func (r Reader) readNetDev(s *Snapshot) error {
+	return r.scan("net/dev", func(f []string) error {
+		// 前两行是表头，接口名和第一个数字之间可能没有空格，例如 "eth0:123"
+		if len(f) == 0 || !strings.Contains(f[0], ":") {
+			return nil
+		}
+		name, first, _ := strings.Cut(f[0], ":")
+		if first != "" {
+			f = append([]string{name, first}, f[1:]...)
+		} else {
+			f[0] = name
+		}
+		if len(f) < 17 {
+			return fmt.Errorf("bad line for %s", name)
+		}
+		n := Net{Name: name}
+		var skip uint64
+		err := parseUints(f[1:], &n.RxBytes, &n.RxPackets, &n.RxErrors, &skip, &skip, &skip, &skip, &skip,
+			&n.TxBytes, &n.TxPackets, &n.TxErrors)
+		if err != nil {
+			return err
+		}
+		s.Net = append(s.Net, n)
+		return nil
+	})
+}
@@ -0,0 +1,149 @@
+package sysstat
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeProc 在临时目录中准备一份 /proc，files 的键是相对路径
+func writeProc(t *testing.T, files map[string]string) Reader {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return Reader{Root: root}
+}
+
+func procFiles(user, idle, ctxt, diskReads, ioMs, rx uint64) map[string]string {
+	return map[string]string{
+		"stat": strings.Join([]string{
+			"cpu  " + u(user) + " 0 100 " + u(idle) + " 50 0 0 0 0 0",
+			"cpu0 " + u(user) + " 0 100 " + u(idle) + " 50 0 0 0 0 0",
+			"intr 1 2 3",
+			"ctxt " + u(ctxt),
+			"processes 42",
+		}, "\n"),
+		"meminfo": "MemTotal:        1000 kB\nMemFree:          200 kB\nMemAvailable:     600 kB\nBuffers:           10 kB\nCached:           300 kB\nSwapTotal:          0 kB\nSwapFree:           0 kB\n",
+		"loadavg": "0.50 0.25 0.10 2/123 4567\n",
+		"diskstats": "   7       0 loop0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n" +
+			"   8       0 sda " + u(diskReads) + " 0 " + u(diskReads*8) + " 10 20 0 160 10 0 " + u(ioMs) + " 20 0 0 0 0 0 0\n",
+		"net/dev": "Inter-|   Receive                                                |  Transmit\n" +
+			" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n" +
+			"    lo: 100 1 0 0 0 0 0 0 100 1 0 0 0 0 0 0\n" +
+			"  eth0:" + u(rx) + " 10 1 0 0 0 0 0 500 5 0 0 0 0 0 0\n",
+	}
+}
+
+func u(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+func Test_Read(t *testing.T) {
+	s, err := writeProc(t, procFiles(1000, 8000, 5000, 100, 300, 2000)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.CPUs) != 2 || s.CPUs[0].Name != "cpu" || s.CPUs[1].User != 1000 || s.CPUs[0].Total() != 9150 {
+		t.Errorf("CPUs = %+v", s.CPUs)
+	}
+	if s.ContextSwitches != 5000 || s.Forks != 42 {
+		t.Errorf("ctxt = %d, forks = %d", s.ContextSwitches, s.Forks)
+	}
+	if s.Memory.Total != 1000*1024 || s.Memory.Used() != 400*1024 {
+		t.Errorf("Memory = %+v", s.Memory)
+	}
+	if s.Load != (Load{0.5, 0.25, 0.1, 2, 123}) {
+		t.Errorf("Load = %+v", s.Load)
+	}
+	// loop0 没有读写过，被跳过
+	if len(s.Disks) != 1 || s.Disks[0] != (Disk{"sda", 100, 20, 100 * 8 * 512, 160 * 512, 300}) {
+		t.Errorf("Disks = %+v", s.Disks)
+	}
+	if len(s.Net) != 2 || s.Net[1] != (Net{"eth0", 2000, 10, 1, 500, 5, 0}) {
+		t.Errorf("Net = %+v", s.Net)
+	}
+
+	if _, err := (Reader{Root: t.TempDir()}).Read(); err == nil {
+		t.Error("Read of empty directory succeeded")
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	prev, _ := writeProc(t, procFiles(1000, 8000, 5000, 100, 300, 2000)).Read()
+	cur, _ := writeProc(t, procFiles(1300, 8700, 6000, 150, 800, 1000)).Read()
+	prev.Time = time.Unix(100, 0)
+	cur.Time = time.Unix(102, 0)
+
+	r := Diff(prev, cur)
+	if r.Interval != 2*time.Second || r.ContextSwitches != 500 {
+		t.Errorf("interval %v, ctxt %v", r.Interval, r.ContextSwitches)
+	}
+	c := r.CPUs[0]
+	if c.User != 30 || c.Idle != 70 || c.Busy() != 30 {
+		t.Errorf("CPU = %+v", c)
+	}
+	d := r.Disks[0]
+	if d.Reads != 25 || d.ReadBytes != 25*8*512 || d.Utilization != 25 {
+		t.Errorf("Disk = %+v", d)
+	}
+	// eth0 的计数变小了（计数器被重置），速率记为 0
+	if n := r.Net[1]; n.RxBytes != 0 {
+		t.Errorf("Net = %+v", n)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 1 || !json.Valid(buf.Bytes()) {
+		t.Errorf("JSON line = %q", buf.String())
+	}
+}
+
+func Test_Prometheus(t *testing.T) {
+	r := writeProc(t, procFiles(1000, 8000, 5000, 100, 300, 2000))
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE node_cpu_seconds_total counter\n",
+		`node_cpu_seconds_total{cpu="0",mode="user"} 10` + "\n",
+		"node_memory_MemAvailable_bytes 614400\n",
+		"node_load1 0.5\n",
+		`node_disk_io_time_seconds_total{device="sda"} 0.3` + "\n",
+		`node_network_receive_bytes_total{device="eth0"} 2000` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Count(body, "# HELP node_cpu_seconds_total") != 1 {
+		t.Error("HELP repeated")
+	}
+}
+
+func Test_ReadProc(t *testing.T) {
+	if _, err := os.Stat("/proc/stat"); err != nil {
+		t.Skip("no /proc")
+	}
+	s, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.CPUs) < 2 || s.Memory.Total == 0 || math.IsNaN(s.Load.Load1) {
+		t.Errorf("snapshot = %+v", s)
+	}
+}
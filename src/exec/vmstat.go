@@ -0,0 +1,92 @@
+package main
+
+/* 不执行 vmstat，直接从 /proc 读取系统的统计信息
+
+   go run vmstat.go -i 1s -n 5                每秒输出一行，共 5 行（相当于 vmstat 1 5）
+   go run vmstat.go -format json              输出 JSON 行
+   go run vmstat.go -format prom -n 1         输出一次 Prometheus 文本格式
+   go run vmstat.go -listen :9100             在 /metrics 上提供 Prometheus 指标
+*/
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"exec/sysstat"
+)
+
+func main() {
+	interval := flag.Duration("i", time.Second, "采样间隔")
+	count := flag.Int("n", 0, "输出的行数，0 表示一直输出")
+	format := flag.String("format", "table", "输出格式：table、json 或 prom")
+	listen := flag.String("listen", "", "在这个地址的 /metrics 上提供 Prometheus 指标")
+	flag.Parse()
+
+	var r sysstat.Reader
+	if *listen != "" {
+		http.Handle("/metrics", r.Handler())
+		log.Fatal(http.ListenAndServe(*listen, nil))
+	}
+
+	if *format == "prom" {
+		s, err := r.Read()
+		if err != nil {
+			log.Fatal(err)
+		}
+		sysstat.WritePrometheus(os.Stdout, s)
+		return
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	errDone := errors.New("done")
+	n := 0
+	if *format == "table" {
+		fmt.Printf("%-8s %6s %5s %5s %5s %5s %9s %9s %10s %10s %10s %10s\n",
+			"time", "load1", "us", "sy", "wa", "id", "used(MB)", "avail(MB)", "read KB/s", "write KB/s", "rx KB/s", "tx KB/s")
+	}
+	err := r.Sample(ctx, *interval, func(rt *sysstat.Rates) error {
+		if *format == "json" {
+			sysstat.WriteJSON(os.Stdout, rt)
+		} else {
+			printRow(rt)
+		}
+		if n++; *count > 0 && n >= *count {
+			return errDone
+		}
+		return nil
+	})
+	if err != nil && err != errDone && err != context.Canceled {
+		log.Fatal(err)
+	}
+}
+
+// printRow 输出 vmstat 风格的一行，磁盘和网络是所有设备的合计
+func printRow(r *sysstat.Rates) {
+	var cpu sysstat.CPUUsage
+	if len(r.CPUs) > 0 {
+		cpu = r.CPUs[0]
+	}
+	var read, write, rx, tx float64
+	for _, d := range r.Disks {
+		read += d.ReadBytes
+		write += d.WriteBytes
+	}
+	for _, n := range r.Net {
+		if n.Name != "lo" {
+			rx += n.RxBytes
+			tx += n.TxBytes
+		}
+	}
+	const mb = 1 << 20
+	fmt.Printf("%-8s %6.2f %5.1f %5.1f %5.1f %5.1f %9d %9d %10.1f %10.1f %10.1f %10.1f\n",
+		r.Time.Format("15:04:05"), r.Load.Load1, cpu.User, cpu.System, cpu.IOWait, cpu.Idle,
+		r.Memory.Used()/mb, r.Memory.Available/mb, read/1024, write/1024, rx/1024, tx/1024)
+}
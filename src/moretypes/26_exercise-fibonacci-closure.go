@@ -3,6 +3,7 @@ package main
 import "fmt"
 
 // 实现一个 fibonacci 函数，它返回一个函数（闭包），该闭包返回一个斐波纳契数列 `(0, 1, 1, 2, 3, 5, ...)`。
+// concurrency/gen 中的 FromFunc 可以把这样的闭包变成 range-over-func 的迭代器。
 func fibonacci() func() int {
 	a, b := 0, 1
 	return func() int {